@@ -161,7 +161,8 @@ class CallbackModule(CallbackBase):
 
     def v2_playbook_on_play_start(self, play):
         data = {
-            'name': play.name
+            'name': play.name,
+            'checkpoint': play.get_vars().get('checkpoint', '')
         }
         e = self._new_event(self.PLAY_START, data)
         self._print_event(e)
@@ -92,6 +92,9 @@ func docForType(typeName string, allTypes []*godoc.Type, parentFieldName string)
 				// Recurse to get the docs for NodeGroup
 				d := docForType(tt.Name, allTypes, parentFieldName)
 				docs = append(docs, d...)
+			case *ast.MapType:
+				// Map types, such as the one used for InspectorRule, do not have
+				// documented fields
 			case *ast.StructType:
 				for _, f := range tt.Fields.List {
 					fieldName := fieldName(parentFieldName, f)
@@ -10,6 +10,7 @@ type ClusterCatalog struct {
 	ClusterName               string `yaml:"kubernetes_cluster_name"`
 	AdminPassword             string `yaml:"kubernetes_admin_password"`
 	TLSDirectory              string `yaml:"tls_directory"`
+	TLSCAFile                 string `yaml:"tls_ca_file,omitempty"`
 	ServicesCIDR              string `yaml:"kubernetes_services_cidr"`
 	PodCIDR                   string `yaml:"kubernetes_pods_cidr"`
 	DNSServiceIP              string `yaml:"kubernetes_dns_service_ip"`
@@ -43,8 +44,12 @@ type ClusterCatalog struct {
 	EnableConfigureIngress bool `yaml:"configure_ingress"`
 
 	KismaticPreflightCheckerLinux string `yaml:"kismatic_preflight_checker"`
+	KismaticInspectorTLSDirectory string `yaml:"kismatic_inspector_tls_directory"`
+	KismaticInspectorRules        string `yaml:"kismatic_inspector_rules_file"`
+	KismaticInspectorUpgradeRules string `yaml:"kismatic_inspector_upgrade_rules_file"`
 
-	WorkerNode string `yaml:"worker_node"`
+	WorkerNode   string `yaml:"worker_node"`
+	RemoveWorker bool   `yaml:"remove_worker"`
 
 	NFSVolumes []NFSVolume `yaml:"nfs_volumes"`
 
@@ -69,6 +74,9 @@ type ClusterCatalog struct {
 	DiagnosticsDirectory string `yaml:"diagnostics_dir"`
 	DiagnosticsDateTime  string `yaml:"diagnostics_date_time"`
 
+	EtcdBackupDirectory string `yaml:"etcd_backup_dir"`
+	EtcdSnapshotFile    string `yaml:"etcd_snapshot_file"`
+
 	Docker struct {
 		Logs struct {
 			Driver string            `yaml:"driver"`
@@ -141,6 +149,9 @@ type ClusterCatalog struct {
 
 	NodeLabels         map[string][]string          `yaml:"node_labels"`
 	KubeletNodeOptions map[string]map[string]string `yaml:"kubelet_node_overrides"`
+
+	// the plays that completed on each host, used when resuming an installation
+	CompletedPlays map[string][]string `yaml:"completed_plays"`
 }
 
 type NFSVolume struct {
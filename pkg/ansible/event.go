@@ -56,6 +56,9 @@ func (e *PlaybookEndEvent) Type() string {
 // PlayStartEvent signals the beginning of a play
 type PlayStartEvent struct {
 	namedEvent
+	// Checkpoint is the key that records the play's completion when
+	// resuming a playbook. Empty if the play is always run.
+	Checkpoint string
 }
 
 func (e *PlayStartEvent) Type() string {
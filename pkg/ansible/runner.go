@@ -49,6 +49,8 @@ type runner struct {
 	runDir       string
 	waitPlaybook func() error
 	namedPipe    string
+	// pipeWriter keeps the named pipe open for writing until ansible exits
+	pipeWriter *os.File
 }
 
 // NewRunner returns a new runner for running Ansible playbooks.
@@ -80,7 +82,9 @@ func (r *runner) WaitPlaybook() error {
 		return fmt.Errorf("wait called, but playbook not started")
 	}
 	execErr := r.waitPlaybook()
-	// Process exited, we can clean up named pipe
+	// Process exited, so the event stream ends once the remaining events are read
+	r.pipeWriter.Close()
+	// we can clean up named pipe
 	removeErr := os.Remove(r.namedPipe)
 	if removeErr != nil && execErr != nil {
 		return fmt.Errorf("an error occurred running ansible: %v. Removing named pipe at %q failed: %v", execErr, r.namedPipe, removeErr)
@@ -178,11 +182,23 @@ func (r *runner) startPlaybook(playbookFile string, inv Inventory, cc ClusterCat
 	}
 	r.waitPlaybook = cmd.Wait
 
-	// Create the event stream out of the named pipe
-	eventStreamFile, err := os.OpenFile(r.namedPipe, os.O_RDWR, os.ModeNamedPipe)
+	// Create the event stream out of the named pipe. The pipe is also held open
+	// for writing until ansible exits, so that the stream does not end before
+	// ansible opens the pipe, and ends after ansible writes the last event.
+	// Opening the read end without blocking allows opening the write end.
+	eventStreamFile, err := os.OpenFile(r.namedPipe, os.O_RDONLY|syscall.O_NONBLOCK, os.ModeNamedPipe)
 	if err != nil {
 		return nil, fmt.Errorf("error openning event stream pipe: %v", err)
 	}
+	if r.pipeWriter, err = os.OpenFile(r.namedPipe, os.O_WRONLY, os.ModeNamedPipe); err != nil {
+		eventStreamFile.Close()
+		return nil, fmt.Errorf("error openning event stream pipe: %v", err)
+	}
+	if err = syscall.SetNonblock(int(eventStreamFile.Fd()), false); err != nil {
+		eventStreamFile.Close()
+		r.pipeWriter.Close()
+		return nil, fmt.Errorf("error openning event stream pipe: %v", err)
+	}
 	eventStream := EventStream(eventStreamFile)
 	return eventStream, nil
 }
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type addNodeOpts struct {
+	Roles                    []string
+	NodeLabels               []string
+	GeneratedAssetsDirectory string
+	RestartServices          bool
+	OutputFormat             string
+	Verbose                  bool
+	SkipPreFlight            bool
+}
+
+// NewCmdAddNode returns the command for adding nodes to the cluster
+func NewCmdAddNode(out io.Writer, installOpts *installOpts) *cobra.Command {
+	opts := &addNodeOpts{}
+	cmd := &cobra.Command{
+		Use:   "add-node NODE_NAME NODE_IP [NODE_INTERNAL_IP]",
+		Short: "add a node with the given roles to an existing Kubernetes cluster",
+		Long: `Add a node with the given roles to an existing Kubernetes cluster.
+
+Nodes with the etcd role are added as members of the existing etcd clusters, after
+which the API servers are reconfigured to use them. The plan file is only updated
+once the node has been added successfully.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 || len(args) > 3 {
+				return cmd.Usage()
+			}
+			newNode := install.Node{
+				Host: args[0],
+				IP:   args[1],
+			}
+			if len(args) == 3 {
+				newNode.InternalIP = args[2]
+			}
+			if len(opts.NodeLabels) > 0 {
+				newNode.Labels = make(map[string]string)
+				for _, l := range opts.NodeLabels {
+					pair := strings.Split(l, "=")
+					if len(pair) != 2 {
+						return fmt.Errorf("invalid label %q provided, must be key=value pair", l)
+					}
+					newNode.Labels[pair[0]] = pair[1]
+				}
+			}
+			planner := &install.FilePlanner{File: installOpts.planFilename}
+			execOpts := install.ExecutorOptions{
+				GeneratedAssetsDirectory: opts.GeneratedAssetsDirectory,
+				RestartServices:          opts.RestartServices,
+				OutputFormat:             opts.OutputFormat,
+				Verbose:                  opts.Verbose,
+				EventSinks:               installOpts.eventSinks,
+			}
+			executor, err := install.NewExecutor(out, os.Stderr, execOpts)
+			if err != nil {
+				return err
+			}
+			return doAddNode(messagesOut(out, opts.OutputFormat), installOpts.planFilename, planner, executor, opts, newNode)
+		},
+	}
+	cmd.Flags().StringSliceVar(&opts.Roles, "roles", []string{}, "roles of the new node separated by ',' (options \"etcd\"|\"master\"|\"worker\"|\"ingress\"|\"storage\")")
+	cmd.Flags().StringSliceVarP(&opts.NodeLabels, "labels", "l", []string{}, "key=value pairs separated by ','")
+	cmd.Flags().StringVar(&opts.GeneratedAssetsDirectory, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
+	cmd.Flags().BoolVar(&opts.RestartServices, "restart-services", false, "force restart clusters services (Use with care)")
+	cmd.Flags().BoolVar(&opts.Verbose, "verbose", false, "enable verbose logging from the installation")
+	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\")")
+	cmd.Flags().BoolVar(&opts.SkipPreFlight, "skip-preflight", false, "skip pre-flight checks, useful when rerunning kismatic")
+	return cmd
+}
+
+func doAddNode(out io.Writer, planFile string, planner install.Planner, executor install.Executor, opts *addNodeOpts, newNode install.Node) error {
+	if len(opts.Roles) == 0 {
+		return errors.New("the roles of the new node must be provided with --roles")
+	}
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read plan file: %v", err)
+	}
+	if _, errs := install.ValidateNode(&newNode); errs != nil {
+		util.PrintValidationErrors(out, errs)
+		return errors.New("information provided about the new node is invalid")
+	}
+	if _, errs := install.ValidatePlan(plan); errs != nil {
+		util.PrintValidationErrors(out, errs)
+		return errors.New("the plan file failed validation")
+	}
+	nodeSSHCon := &install.SSHConnection{
+		SSHConfig: &plan.Cluster.SSH,
+		Node:      &newNode,
+	}
+	if _, errs := install.ValidateSSHConnection(nodeSSHCon, "New node"); errs != nil {
+		util.PrintValidationErrors(out, errs)
+		return errors.New("could not establish SSH connection to the new node")
+	}
+	if !opts.SkipPreFlight {
+		util.PrintHeader(out, "Running Pre-Flight Checks On New Node", '=')
+		if err = executor.RunNewNodePreFlightCheck(*plan, newNode, opts.Roles); err != nil {
+			return err
+		}
+	}
+	updatedPlan, err := executor.AddNode(plan, newNode, opts.Roles)
+	if err != nil {
+		return err
+	}
+	if err := planner.Write(updatedPlan); err != nil {
+		return fmt.Errorf("error updating plan file to include new node: %v", err)
+	}
+	fmt.Fprintln(out)
+	util.PrintColor(out, util.Green, "Node %q was added to the cluster with roles %v\n", newNode.Host, opts.Roles)
+	return nil
+}
@@ -4,63 +4,111 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 
 	"github.com/apprenda/kismatic/pkg/install"
 	"github.com/apprenda/kismatic/pkg/util"
 	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
 )
 
 type addWorkerOpts struct {
 	NodeLabels               []string
+	Nodes                    []string
+	NodesFile                string
 	GeneratedAssetsDirectory string
 	RestartServices          bool
 	OutputFormat             string
 	Verbose                  bool
 	SkipPreFlight            bool
+	EventSinks               []string
 }
 
 // NewCmdAddWorker returns the command for adding workers to the cluster
 func NewCmdAddWorker(out io.Writer, installOpts *installOpts) *cobra.Command {
 	opts := &addWorkerOpts{}
 	cmd := &cobra.Command{
-		Use:   "add-worker WORKER_NAME WORKER_IP [WORKER_INTERNAL_IP]",
+		Use:   "add-worker [WORKER_NAME WORKER_IP [WORKER_INTERNAL_IP]]",
 		Short: "add a Worker node to an existing Kubernetes cluster",
+		Long: `Add one or more Worker nodes to an existing Kubernetes cluster.
+
+Multiple workers can be added at once by repeating the --node flag, or by providing
+a file that contains a YAML list of nodes with --nodes-file:
+
+- host: worker1
+  ip: 10.0.0.1
+  internalip: 192.168.0.1
+  labels:
+    team: blue
+
+The labels provided with --labels are applied to all the new workers. The labels
+of a worker in the nodes file take precedence over the labels with the same key.
+
+The workers are added in parallel. A worker that fails the pre-flight checks or the
+installation is left out, and the other workers continue to be added. Only the
+workers that were added successfully are added to the plan file.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) < 2 || len(args) > 3 {
+			if len(args) == 1 || len(args) > 3 {
 				return cmd.Usage()
 			}
-			newWorker := install.Node{
-				Host: args[0],
-				IP:   args[1],
+			var newWorkers []install.Node
+			if len(args) > 1 {
+				newWorker := install.Node{
+					Host: args[0],
+					IP:   args[1],
+				}
+				if len(args) == 3 {
+					newWorker.InternalIP = args[2]
+				}
+				newWorkers = append(newWorkers, newWorker)
+			}
+			for _, n := range opts.Nodes {
+				newWorker, err := parseNode(n)
+				if err != nil {
+					return err
+				}
+				newWorkers = append(newWorkers, *newWorker)
 			}
-			if len(args) == 3 {
-				newWorker.InternalIP = args[2]
+			if opts.NodesFile != "" {
+				fileWorkers, err := readNodesFile(opts.NodesFile)
+				if err != nil {
+					return err
+				}
+				newWorkers = append(newWorkers, fileWorkers...)
 			}
 			if len(opts.NodeLabels) > 0 {
-				newWorker.Labels = make(map[string]string)
+				labels := make(map[string]string)
 				for _, l := range opts.NodeLabels {
 					pair := strings.Split(l, "=")
 					if len(pair) != 2 {
 						return fmt.Errorf("invalid label %q provided, must be key=value pair", l)
 					}
-					newWorker.Labels[pair[0]] = pair[1]
+					labels[pair[0]] = pair[1]
 				}
+				applyNodeLabels(labels, newWorkers)
 			}
-			return doAddWorker(out, installOpts.planFilename, opts, newWorker)
+			if len(newWorkers) == 0 {
+				return cmd.Usage()
+			}
+			opts.EventSinks = installOpts.eventSinks
+			return doAddWorker(out, installOpts.planFilename, opts, newWorkers)
 		},
 	}
 	cmd.Flags().StringSliceVarP(&opts.NodeLabels, "labels", "l", []string{}, "key=value pairs separated by ','")
+	cmd.Flags().StringArrayVar(&opts.Nodes, "node", []string{}, "worker to add as NAME,IP[,INTERNAL_IP] (can be repeated)")
+	cmd.Flags().StringVar(&opts.NodesFile, "nodes-file", "", "path to a file that contains a YAML list of workers to add")
 	cmd.Flags().StringVar(&opts.GeneratedAssetsDirectory, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&opts.RestartServices, "restart-services", false, "force restart clusters services (Use with care)")
 	cmd.Flags().BoolVar(&opts.Verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\")")
 	cmd.Flags().BoolVar(&opts.SkipPreFlight, "skip-preflight", false, "skip pre-flight checks, useful when rerunning kismatic")
 	return cmd
 }
 
-func doAddWorker(out io.Writer, planFile string, opts *addWorkerOpts, newWorker install.Node) error {
+func doAddWorker(stdout io.Writer, planFile string, opts *addWorkerOpts, newWorkers []install.Node) error {
+	out := messagesOut(stdout, opts.OutputFormat)
 	planner := &install.FilePlanner{File: planFile}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: planFile}
@@ -70,46 +118,168 @@ func doAddWorker(out io.Writer, planFile string, opts *addWorkerOpts, newWorker
 		RestartServices:          opts.RestartServices,
 		OutputFormat:             opts.OutputFormat,
 		Verbose:                  opts.Verbose,
+		EventSinks:               opts.EventSinks,
 	}
-	executor, err := install.NewExecutor(out, os.Stderr, execOpts)
+	executor, err := install.NewExecutor(stdout, os.Stderr, execOpts)
 	if err != nil {
 		return err
 	}
+	return doAddWorkers(out, planner, executor, opts, newWorkers)
+}
+
+func doAddWorkers(out io.Writer, planner install.Planner, executor install.Executor, opts *addWorkerOpts, newWorkers []install.Node) error {
 	plan, err := planner.Read()
 	if err != nil {
 		return fmt.Errorf("failed to read plan file: %v", err)
 	}
-	if _, errs := install.ValidateNode(&newWorker); errs != nil {
-		util.PrintValidationErrors(out, errs)
-		return errors.New("information provided about the new worker node is invalid")
-	}
 	if _, errs := install.ValidatePlan(plan); errs != nil {
 		util.PrintValidationErrors(out, errs)
 		return errors.New("the plan file failed validation")
 	}
+	// an invalid or unreachable worker is not added, but the others still are
+	var candidates []install.Node
+	for _, w := range newWorkers {
+		if err := validateNewWorker(out, plan, w); err != nil {
+			if len(newWorkers) == 1 {
+				return err
+			}
+			util.PrettyPrintErr(out, "Validating worker %q: %v", w.Host, err)
+			continue
+		}
+		candidates = append(candidates, w)
+	}
+	if err = ensureNodesAreNew(*plan, candidates); err != nil {
+		return err
+	}
+	// the workers that failed the pre-flight checks are not added
+	errs := map[string]error{}
+	if !opts.SkipPreFlight && len(candidates) > 0 {
+		util.PrintHeader(out, "Running Pre-Flight Checks On New Worker", '=')
+		if errs, err = executor.RunNewWorkersPreFlightCheck(*plan, candidates); err != nil {
+			return err
+		}
+	}
+	// Keep the original behavior when adding a single worker
+	if len(newWorkers) == 1 {
+		if err = errs[newWorkers[0].Host]; err != nil {
+			return err
+		}
+		updatedPlan, err := executor.AddWorker(plan, newWorkers[0])
+		if err != nil {
+			return err
+		}
+		if err := planner.Write(updatedPlan); err != nil {
+			return fmt.Errorf("error updating plan file to inlcude new worker node: %v", err)
+		}
+		return nil
+	}
+	var passed []install.Node
+	for _, w := range candidates {
+		if err := errs[w.Host]; err != nil {
+			util.PrettyPrintErr(out, "Pre-flight checks on worker %q: %v", w.Host, err)
+			continue
+		}
+		passed = append(passed, w)
+	}
+	failed := len(newWorkers) - len(passed)
+	if len(passed) > 0 {
+		updatedPlan, results, err := executor.AddWorkers(plan, passed)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+		for _, r := range results {
+			if r.Error != nil {
+				failed++
+				util.PrettyPrintErr(out, "Adding worker %q: %v", r.Node.Host, r.Error)
+				continue
+			}
+			util.PrettyPrintOk(out, "Adding worker %q", r.Node.Host)
+		}
+		if failed < len(newWorkers) {
+			if err := planner.Write(updatedPlan); err != nil {
+				return fmt.Errorf("error updating plan file to inlcude new worker nodes: %v", err)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d workers could not be added to the cluster", failed, len(newWorkers))
+	}
+	return nil
+}
+
+// validateNewWorker returns an error if the information about the new worker
+// is invalid, or if it cannot be reached over SSH
+func validateNewWorker(out io.Writer, plan *install.Plan, w install.Node) error {
+	if _, errs := install.ValidateNode(&w); errs != nil {
+		util.PrintValidationErrors(out, errs)
+		return fmt.Errorf("information provided about the new worker node %q is invalid", w.Host)
+	}
 	workerSSHCon := &install.SSHConnection{
 		SSHConfig: &plan.Cluster.SSH,
-		Node:      &newWorker,
+		Node:      &w,
 	}
 	if _, errs := install.ValidateSSHConnection(workerSSHCon, "New worker node"); errs != nil {
 		util.PrintValidationErrors(out, errs)
-		return errors.New("could not establish SSH connection to the new node")
-	}
-	if err = ensureNodeIsNew(*plan, newWorker); err != nil {
-		return err
+		return fmt.Errorf("could not establish SSH connection to the new node %q", w.Host)
 	}
-	if !opts.SkipPreFlight {
-		util.PrintHeader(out, "Running Pre-Flight Checks On New Worker", '=')
-		if err = executor.RunNewWorkerPreFlightCheck(*plan, newWorker); err != nil {
-			return err
+	return nil
+}
+
+// applies the labels to the nodes. The labels of a node take precedence
+// over the labels with the same key.
+func applyNodeLabels(labels map[string]string, nodes []install.Node) {
+	for i := range nodes {
+		nodeLabels := make(map[string]string, len(labels)+len(nodes[i].Labels))
+		for k, v := range labels {
+			nodeLabels[k] = v
 		}
+		for k, v := range nodes[i].Labels {
+			nodeLabels[k] = v
+		}
+		nodes[i].Labels = nodeLabels
+	}
+}
+
+// parses a node in the NAME,IP[,INTERNAL_IP] format
+func parseNode(s string) (*install.Node, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid node %q provided, must be NAME,IP[,INTERNAL_IP]", s)
+	}
+	n := &install.Node{
+		Host: parts[0],
+		IP:   parts[1],
+	}
+	if len(parts) == 3 {
+		n.InternalIP = parts[2]
 	}
-	updatedPlan, err := executor.AddWorker(plan, newWorker)
+	return n, nil
+}
+
+func readNodesFile(file string) ([]install.Node, error) {
+	b, err := ioutil.ReadFile(file)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error reading nodes file: %v", err)
+	}
+	var nodes []install.Node
+	if err = yaml.Unmarshal(b, &nodes); err != nil {
+		return nil, fmt.Errorf("error unmarshaling nodes file %q: %v", file, err)
 	}
-	if err := planner.Write(updatedPlan); err != nil {
-		return fmt.Errorf("error updating plan file to inlcude new worker node: %v", err)
+	return nodes, nil
+}
+
+// returns an error if the plan contains a worker that is "equivalent"
+// to one of the new workers that are being added, or if the same
+// worker is being added twice
+func ensureNodesAreNew(plan install.Plan, newWorkers []install.Node) error {
+	// the workers that come before each new worker will be in the plan
+	plan.Worker.Nodes = append([]install.Node{}, plan.Worker.Nodes...)
+	for _, w := range newWorkers {
+		if err := ensureNodeIsNew(plan, w); err != nil {
+			return fmt.Errorf("%s: %v", w.Host, err)
+		}
+		plan.Worker.Nodes = append(plan.Worker.Nodes, w)
 	}
 	return nil
 }
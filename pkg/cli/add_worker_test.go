@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/install"
+)
+
+func TestParseNode(t *testing.T) {
+	tests := []struct {
+		in    string
+		node  install.Node
+		valid bool
+	}{
+		{in: "worker1,10.0.0.1", node: install.Node{Host: "worker1", IP: "10.0.0.1"}, valid: true},
+		{in: "worker1,10.0.0.1,192.168.0.1", node: install.Node{Host: "worker1", IP: "10.0.0.1", InternalIP: "192.168.0.1"}, valid: true},
+		{in: "worker1"},
+		{in: "worker1,10.0.0.1,192.168.0.1,foo"},
+	}
+	for _, test := range tests {
+		n, err := parseNode(test.in)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%q: expected an error, but didn't get one", test.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.in, err)
+			continue
+		}
+		if !n.Equal(test.node) {
+			t.Errorf("%q: expected %+v, but got %+v", test.in, test.node, *n)
+		}
+	}
+}
+
+func TestEnsureNodesAreNew(t *testing.T) {
+	plan := install.Plan{
+		Worker: install.NodeGroup{
+			Nodes: []install.Node{{Host: "existing", IP: "10.0.0.1"}},
+		},
+	}
+	tests := []struct {
+		nodes []install.Node
+		valid bool
+	}{
+		{nodes: []install.Node{{Host: "worker1", IP: "10.0.0.2"}, {Host: "worker2", IP: "10.0.0.3"}}, valid: true},
+		{nodes: []install.Node{{Host: "existing", IP: "10.0.0.2"}}},
+		{nodes: []install.Node{{Host: "worker1", IP: "10.0.0.2"}, {Host: "worker1", IP: "10.0.0.3"}}},
+		{nodes: []install.Node{{Host: "worker1", IP: "10.0.0.2"}, {Host: "worker2", IP: "10.0.0.2"}}},
+	}
+	for i, test := range tests {
+		err := ensureNodesAreNew(plan, test.nodes)
+		if test.valid && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("test %d: expected an error, but didn't get one", i)
+		}
+	}
+	if len(plan.Worker.Nodes) != 1 {
+		t.Errorf("the plan was modified")
+	}
+}
+
+func TestApplyNodeLabels(t *testing.T) {
+	nodes := []install.Node{
+		{Host: "worker1"},
+		{Host: "worker2", Labels: map[string]string{"team": "blue", "zone": "a"}},
+	}
+	applyNodeLabels(map[string]string{"team": "red", "env": "prod"}, nodes)
+	expected := []map[string]string{
+		{"team": "red", "env": "prod"},
+		{"team": "blue", "env": "prod", "zone": "a"},
+	}
+	for i, n := range nodes {
+		if !reflect.DeepEqual(n.Labels, expected[i]) {
+			t.Errorf("%s: expected labels %v, but got %v", n.Host, expected[i], n.Labels)
+		}
+	}
+	// the nodes do not share the labels
+	nodes[0].Labels["env"] = "dev"
+	if nodes[1].Labels["env"] != "prod" {
+		t.Errorf("the labels of the nodes are shared")
+	}
+}
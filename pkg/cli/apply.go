@@ -19,6 +19,7 @@ type applyCmd struct {
 	verbose            bool
 	outputFormat       string
 	skipPreFlight      bool
+	eventSinks         []string
 }
 
 type applyOpts struct {
@@ -27,6 +28,7 @@ type applyOpts struct {
 	verbose            bool
 	outputFormat       string
 	skipPreFlight      bool
+	resume             bool
 }
 
 // NewCmdApply creates a cluter using the plan file
@@ -45,6 +47,8 @@ func NewCmdApply(out io.Writer, installOpts *installOpts) *cobra.Command {
 				RestartServices:          applyOpts.restartServices,
 				OutputFormat:             applyOpts.outputFormat,
 				Verbose:                  applyOpts.verbose,
+				Resume:                   applyOpts.resume,
+				EventSinks:               installOpts.eventSinks,
 			}
 			executor, err := install.NewExecutor(out, os.Stderr, executorOpts)
 			if err != nil {
@@ -59,7 +63,9 @@ func NewCmdApply(out io.Writer, installOpts *installOpts) *cobra.Command {
 				generatedAssetsDir: applyOpts.generatedAssetsDir,
 				verbose:            applyOpts.verbose,
 				outputFormat:       applyOpts.outputFormat,
-				skipPreFlight:      applyOpts.skipPreFlight,
+				eventSinks:         installOpts.eventSinks,
+				// the nodes of a partially installed cluster fail the pre-flight checks
+				skipPreFlight: applyOpts.skipPreFlight || applyOpts.resume,
 			}
 			return applyCmd.run()
 		},
@@ -69,13 +75,15 @@ func NewCmdApply(out io.Writer, installOpts *installOpts) *cobra.Command {
 	cmd.Flags().StringVar(&applyOpts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&applyOpts.restartServices, "restart-services", false, "force restart cluster services (Use with care)")
 	cmd.Flags().BoolVar(&applyOpts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&applyOpts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().StringVarP(&applyOpts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\")")
 	cmd.Flags().BoolVar(&applyOpts.skipPreFlight, "skip-preflight", false, "skip pre-flight checks, useful when rerunning kismatic")
+	cmd.Flags().BoolVar(&applyOpts.resume, "resume", false, "resume a failed installation, skipping the plays that completed on each node if the plan file has not changed (implies --skip-preflight)")
 
 	return cmd
 }
 
 func (c *applyCmd) run() error {
+	out := messagesOut(c.out, c.outputFormat)
 	// Validate and run pre-flight
 	opts := &validateOpts{
 		planFile:           c.planFile,
@@ -83,6 +91,7 @@ func (c *applyCmd) run() error {
 		outputFormat:       c.outputFormat,
 		skipPreFlight:      c.skipPreFlight,
 		generatedAssetsDir: c.generatedAssetsDir,
+		eventSinks:         c.eventSinks,
 	}
 	err := doValidate(c.out, c.planner, opts)
 	if err != nil {
@@ -99,12 +108,12 @@ func (c *applyCmd) run() error {
 	}
 
 	// Generate kubeconfig
-	util.PrintHeader(c.out, "Generating Kubeconfig File", '=')
+	util.PrintHeader(out, "Generating Kubeconfig File", '=')
 	err = install.GenerateKubeconfig(plan, c.generatedAssetsDir)
 	if err != nil {
 		return fmt.Errorf("error generating kubeconfig file: %v", err)
 	}
-	util.PrettyPrintOk(c.out, "Generated kubeconfig file in the %q directory", c.generatedAssetsDir)
+	util.PrettyPrintOk(out, "Generated kubeconfig file in the %q directory", c.generatedAssetsDir)
 
 	// Perform the installation
 	if err := c.executor.Install(plan); err != nil {
@@ -119,16 +128,16 @@ func (c *applyCmd) run() error {
 		}
 	}
 
-	util.PrintColor(c.out, util.Green, "\nThe cluster was installed successfully!\n")
-	fmt.Fprintln(c.out)
+	util.PrintColor(out, util.Green, "\nThe cluster was installed successfully!\n")
+	fmt.Fprintln(out)
 
 	msg := "- To use the generated kubeconfig file with kubectl:" +
 		"\n    * use \"./kubectl --kubeconfig %s/kubeconfig\"" +
 		"\n    * or copy the config file \"cp %[1]s/kubeconfig ~/.kube/config\"\n"
-	util.PrintColor(c.out, util.Blue, msg, c.generatedAssetsDir)
-	util.PrintColor(c.out, util.Blue, "- To view the Kubernetes dashboard: \"./kismatic dashboard\"\n")
-	util.PrintColor(c.out, util.Blue, "- To SSH into a cluster node: \"./kismatic ssh etcd|master|worker|storage|$node.host\"\n")
-	fmt.Fprintln(c.out)
+	util.PrintColor(out, util.Blue, msg, c.generatedAssetsDir)
+	util.PrintColor(out, util.Blue, "- To view the Kubernetes dashboard: \"./kismatic dashboard\"\n")
+	util.PrintColor(out, util.Blue, "- To SSH into a cluster node: \"./kismatic ssh etcd|master|worker|storage|$node.host\"\n")
+	fmt.Fprintln(out)
 
 	return nil
 }
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+type backupOpts struct {
+	planFile           string
+	generatedAssetsDir string
+	verbose            bool
+	outputFormat       string
+}
+
+// NewCmdBackup returns the backup command
+func NewCmdBackup(in io.Reader, out io.Writer) *cobra.Command {
+	opts := &backupOpts{}
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Manage backups of the Kubernetes etcd cluster",
+		Long: `Manage backups of the Kubernetes etcd cluster.
+
+Backups are stored in the "backups" directory of the generated assets directory.
+Each backup contains a snapshot of the Kubernetes etcd cluster, along with the
+plan file and certificates that were in use when the snapshot was taken.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFile)
+	cmd.PersistentFlags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
+
+	cmd.AddCommand(NewCmdBackupCreate(out, opts))
+	cmd.AddCommand(NewCmdBackupList(out, opts))
+	cmd.AddCommand(NewCmdBackupRestore(in, out, opts))
+	return cmd
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdBackupCreate returns the command for backing up the etcd cluster
+func NewCmdBackupCreate(out io.Writer, opts *backupOpts) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "take a snapshot of the Kubernetes etcd cluster",
+		Long: `Take a snapshot of the Kubernetes etcd cluster and store it in the generated assets directory.
+
+This command does not prompt for input, and can be run on a schedule (e.g. from cron).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			planner := &install.FilePlanner{File: opts.planFile}
+			execOpts := install.ExecutorOptions{
+				GeneratedAssetsDirectory: opts.generatedAssetsDir,
+				OutputFormat:             opts.outputFormat,
+				Verbose:                  opts.verbose,
+			}
+			executor, err := install.NewExecutor(out, os.Stderr, execOpts)
+			if err != nil {
+				return err
+			}
+			return doBackupCreate(messagesOut(out, opts.outputFormat), planner, executor, opts, time.Now())
+		},
+	}
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\")")
+	return cmd
+}
+
+func doBackupCreate(out io.Writer, planner install.Planner, executor install.Executor, opts *backupOpts, now time.Time) error {
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: opts.planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	if err = validateSSHConnectivity(out, plan); err != nil {
+		return err
+	}
+
+	name := install.NewEtcdBackupName(now)
+	backupDir := filepath.Join(install.BackupsDirectory(opts.generatedAssetsDir), name)
+	if err = executor.BackupEtcd(*plan, backupDir); err != nil {
+		return fmt.Errorf("error backing up etcd: %v", err)
+	}
+	fmt.Fprintln(out)
+	util.PrintColor(out, util.Green, "Backup %q was created in %q\n", name, backupDir)
+	return nil
+}
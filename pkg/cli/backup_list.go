@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+type backupListOpts struct {
+	outputFormat string
+}
+
+// NewCmdBackupList returns the command for listing etcd backups
+func NewCmdBackupList(out io.Writer, opts *backupOpts) *cobra.Command {
+	listOpts := backupListOpts{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the backups found in the generated assets directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			return doBackupList(out, install.BackupsDirectory(opts.generatedAssetsDir), listOpts)
+		},
+	}
+	cmd.Flags().StringVarP(&listOpts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
+	return cmd
+}
+
+func doBackupList(out io.Writer, backupsDir string, opts backupListOpts) error {
+	if opts.outputFormat != "simple" && opts.outputFormat != "json" {
+		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
+	}
+	backups, err := install.ListEtcdBackups(backupsDir)
+	if err != nil {
+		return err
+	}
+	if opts.outputFormat == "json" {
+		if backups == nil {
+			backups = []install.EtcdBackup{}
+		}
+		b, err := json.MarshalIndent(backups, "", "    ")
+		if err != nil {
+			return fmt.Errorf("marshal error: %v", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+	if len(backups) == 0 {
+		fmt.Fprintln(out, "No backups were found. You may use `kismatic backup create` to create a new backup.")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "NAME\tCREATED\tSIZE\tDIRECTORY\n")
+	for _, b := range backups {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", b.Name, b.CreatedAt.Format("2006-01-02 15:04:05"), HumanFormat(float64(b.SnapshotSize)), b.Directory)
+	}
+	return w.Flush()
+}
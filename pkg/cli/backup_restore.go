@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type backupRestoreOpts struct {
+	force bool
+}
+
+// NewCmdBackupRestore returns the command for restoring the etcd cluster from a backup
+func NewCmdBackupRestore(in io.Reader, out io.Writer, opts *backupOpts) *cobra.Command {
+	restoreOpts := backupRestoreOpts{}
+	cmd := &cobra.Command{
+		Use:   "restore BACKUP_NAME",
+		Short: "restore the Kubernetes etcd cluster from a backup",
+		Long: `Restore the Kubernetes etcd cluster from a backup created with 'kismatic backup create'.
+
+The Kubernetes control plane is stopped while every etcd member is restored from the
+snapshot, and started again once the etcd cluster is healthy. The existing etcd data
+is moved aside on each etcd node, and is not deleted.
+
+WARNING all changes made to the cluster after the backup was taken will be lost.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			if !restoreOpts.force {
+				ans, err := util.PromptForString(in, messagesOut(out, opts.outputFormat), "Are you sure you want to restore the etcd cluster? All changes made after the backup was taken will be lost", "N", []string{"N", "y"})
+				if err != nil {
+					return fmt.Errorf("error getting user response: %v", err)
+				}
+				if strings.ToLower(ans) != "y" {
+					return nil
+				}
+			}
+			planner := &install.FilePlanner{File: opts.planFile}
+			execOpts := install.ExecutorOptions{
+				GeneratedAssetsDirectory: opts.generatedAssetsDir,
+				OutputFormat:             opts.outputFormat,
+				Verbose:                  opts.verbose,
+			}
+			executor, err := install.NewExecutor(out, os.Stderr, execOpts)
+			if err != nil {
+				return err
+			}
+			return doBackupRestore(messagesOut(out, opts.outputFormat), planner, executor, opts, args[0])
+		},
+	}
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\")")
+	cmd.Flags().BoolVar(&restoreOpts.force, "force", false, "do not prompt")
+	return cmd
+}
+
+func doBackupRestore(out io.Writer, planner install.Planner, executor install.Executor, opts *backupOpts, name string) error {
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: opts.planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	backup, err := install.GetEtcdBackup(install.BackupsDirectory(opts.generatedAssetsDir), name)
+	if err != nil {
+		return err
+	}
+	// The snapshot is restored onto the etcd nodes of the current plan.
+	// Warn if they are not the ones the backup was taken from.
+	backupPlanner := install.FilePlanner{File: backup.PlanFile()}
+	if backupPlanner.PlanExists() {
+		backupPlan, err := backupPlanner.Read()
+		if err != nil {
+			return fmt.Errorf("error reading plan file of backup %q: %v", name, err)
+		}
+		if !sameNodes(backupPlan.Etcd.Nodes, plan.Etcd.Nodes) {
+			util.PrettyPrintWarn(out, "The etcd nodes in the plan file are different from the ones recorded in backup %q", name)
+		}
+	}
+	if err = validateSSHConnectivity(out, plan); err != nil {
+		return err
+	}
+	if err = executor.RestoreEtcd(*plan, backup.Directory); err != nil {
+		return fmt.Errorf("error restoring etcd: %v", err)
+	}
+	fmt.Fprintln(out)
+	util.PrintColor(out, util.Green, "The etcd cluster was restored from backup %q\n", name)
+	return nil
+}
+
+func sameNodes(a []install.Node, b []install.Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/install"
+)
+
+func TestBackupListNoBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup-list-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	out := &bytes.Buffer{}
+	if err = doBackupList(out, dir, backupListOpts{outputFormat: "simple"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "No backups were found") {
+		t.Errorf("expected no backups message, got %q", out.String())
+	}
+}
+
+func TestBackupListInvalidOutput(t *testing.T) {
+	if err := doBackupList(&bytes.Buffer{}, "", backupListOpts{outputFormat: "yaml"}); err == nil {
+		t.Errorf("expected an error due to unsupported output format, but did not get one")
+	}
+}
+
+func TestBackupList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup-list-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	backupDir := filepath.Join(dir, "2018-01-02-03-04-05")
+	if err = os.MkdirAll(backupDir, 0700); err != nil {
+		t.Fatalf("error creating backup dir: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(backupDir, "etcd-k8s-snapshot.db"), []byte("snapshot"), 0600); err != nil {
+		t.Fatalf("error writing snapshot: %v", err)
+	}
+	out := &bytes.Buffer{}
+	if err = doBackupList(out, dir, backupListOpts{outputFormat: "simple"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "2018-01-02-03-04-05") {
+		t.Errorf("expected backup to be listed, got %q", out.String())
+	}
+}
+
+func TestBackupRestoreBackupNotFound(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup-restore-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	fp := &fakePlanner{
+		exists: true,
+		plan:   &install.Plan{},
+	}
+	opts := &backupOpts{generatedAssetsDir: dir}
+	if err = doBackupRestore(&bytes.Buffer{}, fp, &fakeExecutor{}, opts, "doesnotexist"); err == nil {
+		t.Errorf("expected an error due to missing backup, but did not get one")
+	}
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type certificatesListOpts struct {
+	planFile           string
+	generatedAssetsDir string
+	outputFormat       string
+	localOnly          bool
+}
+
+// NewCmdList creates a new certificates list command
+func NewCmdList(out io.Writer) *cobra.Command {
+	opts := &certificatesListOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the cluster certificates and their expiration",
+		Long: `List the certificates of the cluster described by the plan file, as found in the
+--generated-assets-dir, along with the number of days remaining until they expire.
+
+Unless --local-only is set, the certificates deployed on the nodes are compared with
+the local copies, and any differences are reported.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			planner := &install.FilePlanner{File: opts.planFile}
+			return doCertificatesList(out, planner, opts)
+		},
+	}
+
+	addPlanFileFlag(cmd.Flags(), &opts.planFile)
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "table", `output format (options "table"|"json")`)
+	cmd.Flags().BoolVar(&opts.localOnly, "local-only", false, "do not compare the local certificates with the ones deployed on the nodes")
+
+	return cmd
+}
+
+func doCertificatesList(out io.Writer, planner install.Planner, opts *certificatesListOpts) error {
+	if opts.outputFormat != "table" && opts.outputFormat != "json" {
+		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
+	}
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: opts.planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	certs, err := install.ListClusterCertificates(plan, filepath.Join(opts.generatedAssetsDir, "keys"))
+	if err != nil {
+		return err
+	}
+	if !opts.localOnly {
+		if err = validateSSHConnectivity(messagesOut(out, opts.outputFormat), plan); err != nil {
+			return err
+		}
+		if err = install.CompareDeployedCertificates(plan, certs); err != nil {
+			return fmt.Errorf("error comparing deployed certificates: %v", err)
+		}
+	}
+	return printCertificates(out, certs, opts.outputFormat, !opts.localOnly)
+}
+
+func printCertificates(out io.Writer, certs []install.CertificateInfo, format string, deployed bool) error {
+	if format == "json" {
+		b, err := json.MarshalIndent(certs, "", "    ")
+		if err != nil {
+			return fmt.Errorf("marshal error: %v", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	header := "CERTIFICATE\tSUBJECT\tSANS\tORGANIZATIONS\tISSUER\tDAYS REMAINING"
+	if deployed {
+		header += "\tDEPLOYED"
+	}
+	fmt.Fprintln(w, header)
+	for _, c := range certs {
+		if c.Missing {
+			fmt.Fprintf(w, "%s\t\t\t\t\tmissing", c.Name)
+			if deployed {
+				fmt.Fprintf(w, "\t")
+			}
+			fmt.Fprintln(w)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d", c.Name, c.Subject, strings.Join(c.SubjectAlternateNames, ","), strings.Join(c.Organizations, ","), c.Issuer, c.DaysRemaining)
+		if deployed {
+			fmt.Fprintf(w, "\t%s", deployedSummary(c))
+		}
+		fmt.Fprintln(w)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !deployed {
+		return nil
+	}
+	// Print the details of the certificates that have drifted
+	for _, c := range certs {
+		for _, d := range c.Drift() {
+			if d.Error != "" {
+				util.PrettyPrintErr(out, "%s on %s (%s): %s", c.Name, d.Host, d.Path, d.Error)
+				continue
+			}
+			util.PrettyPrintWarn(out, "%s on %s (%s) does not match the local copy", c.Name, d.Host, d.Path)
+		}
+	}
+	return nil
+}
+
+func deployedSummary(c install.CertificateInfo) string {
+	if len(c.Deployed) == 0 {
+		return "-"
+	}
+	drift := len(c.Drift())
+	if drift == 0 {
+		return fmt.Sprintf("%d/%d match", len(c.Deployed), len(c.Deployed))
+	}
+	return fmt.Sprintf("%d/%d differ", drift, len(c.Deployed))
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type certificatesRotateOpts struct {
+	planFile           string
+	generatedAssetsDir string
+	rotateCA           bool
+	force              bool
+	verbose            bool
+	outputFormat       string
+}
+
+// NewCmdRotate creates a new certificates rotate command
+func NewCmdRotate(in io.Reader, out io.Writer) *cobra.Command {
+	opts := &certificatesRotateOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Reissue the cluster certificates and distribute them to the nodes",
+		Long: `Reissue the cluster certificates and distribute them to the nodes.
+
+All the certificates described by the plan file are reissued using the validity period
+set in the plan file, and copied to the nodes. Existing certificates are backed up
+to the "keys-backup" directory of the generated assets directory.
+
+Components are then restarted one node at a time, in the following order:
+
+1. Etcd nodes
+2. Master nodes
+3. Kubelet and kube-proxy on all Kubernetes nodes
+
+The service account signing certificate is not reissued, as doing so would
+invalidate all the service account tokens in the cluster.
+
+When using --rotate-ca, a new Certificate Authority is also generated, and the components
+are restarted three times, so that etcd members and control plane components always trust
+the certificates presented by their peers:
+
+1. A bundle of the previous and the new CA is distributed to the nodes
+2. The certificates signed by the new CA are distributed to the nodes
+3. The previous CA is removed from the bundle
+
+Service account token secrets keep the previous CA in their "ca.crt" field until
+they are recreated, so pods that use it to verify the API server must be restarted
+after deleting their service account token secrets.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			if !opts.force {
+				ans, err := util.PromptForString(in, messagesOut(out, opts.outputFormat), "Are you sure you want to rotate the cluster certificates? Cluster components will be restarted", "N", []string{"N", "y"})
+				if err != nil {
+					return fmt.Errorf("error getting user response: %v", err)
+				}
+				if strings.ToLower(ans) != "y" {
+					return nil
+				}
+			}
+			planner := &install.FilePlanner{File: opts.planFile}
+			execOpts := install.ExecutorOptions{
+				GeneratedAssetsDirectory: opts.generatedAssetsDir,
+				OutputFormat:             opts.outputFormat,
+				Verbose:                  opts.verbose,
+			}
+			executor, err := install.NewExecutor(out, os.Stderr, execOpts)
+			if err != nil {
+				return err
+			}
+			return doCertificatesRotate(messagesOut(out, opts.outputFormat), planner, executor, opts)
+		},
+	}
+
+	addPlanFileFlag(cmd.Flags(), &opts.planFile)
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
+	cmd.Flags().BoolVar(&opts.rotateCA, "rotate-ca", false, "also generate a new Certificate Authority for the cluster (Use with care)")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "do not prompt")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\")")
+
+	return cmd
+}
+
+func doCertificatesRotate(out io.Writer, planner install.Planner, executor install.Executor, opts *certificatesRotateOpts) error {
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: opts.planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	if err = validatePlan(out, plan); err != nil {
+		return err
+	}
+	if err = validateSSHConnectivity(out, plan); err != nil {
+		return err
+	}
+
+	if err = executor.RotateCertificates(*plan, opts.rotateCA); err != nil {
+		return fmt.Errorf("error rotating certificates: %v", err)
+	}
+
+	util.PrintHeader(out, "Generating Kubeconfig File", '=')
+	isDiff, err := install.RegenerateKubeconfig(plan, opts.generatedAssetsDir)
+	if err != nil {
+		return fmt.Errorf("error generating kubeconfig file: %v", err)
+	}
+	if isDiff {
+		util.PrettyPrintWarn(out, "An updated kubeconfig file has been generated in %q", opts.generatedAssetsDir)
+	} else {
+		util.PrettyPrintOk(out, "Found existing kubeconfig file in %q", opts.generatedAssetsDir)
+	}
+
+	if opts.rotateCA {
+		util.PrettyPrintWarn(out, "Service account token secrets contain the previous CA in \"ca.crt\" until they are recreated")
+	}
+
+	fmt.Fprintln(out)
+	util.PrintColor(out, util.Green, "The cluster certificates were rotated successfully!\n")
+	fmt.Fprintln(out)
+	return nil
+}
@@ -2,6 +2,8 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/spf13/pflag"
 )
@@ -10,6 +12,20 @@ func addPlanFileFlag(flagSet *pflag.FlagSet, p *string) {
 	flagSet.StringVarP(p, "plan-file", "f", "kismatic-cluster.yaml", "path to the installation plan file")
 }
 
+func addEventSinkFlag(flagSet *pflag.FlagSet, p *[]string) {
+	flagSet.StringArrayVar(p, "event-sink", []string{}, `send the installation events to a sink, in addition to the console (options "jsonl"|"webhook=URL"|"prometheus=URL", can be repeated)`)
+}
+
+// messagesOut returns the writer for the messages of a command that runs ansible.
+// When the "json" output format is used, stdout only contains the ansible events,
+// so the messages are written to stderr instead.
+func messagesOut(stdout io.Writer, outputFormat string) io.Writer {
+	if outputFormat == "json" {
+		return os.Stderr
+	}
+	return stdout
+}
+
 type planFileNotFoundErr struct {
 	filename string
 }
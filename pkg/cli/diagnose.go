@@ -35,12 +35,13 @@ func NewCmdDiagnostic(out io.Writer) *cobra.Command {
 	// PersistentFlags
 	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\")")
 
 	return cmd
 }
 
-func doDiagnostics(out io.Writer, opts *diagsOpts) error {
+func doDiagnostics(stdout io.Writer, opts *diagsOpts) error {
+	out := messagesOut(stdout, opts.outputFormat)
 	util.PrintHeader(out, "Gathering Diagnostic Data", '=')
 
 	planFile := opts.planFilename
@@ -71,7 +72,7 @@ func doDiagnostics(out io.Writer, opts *diagsOpts) error {
 		OutputFormat: opts.outputFormat,
 		Verbose:      opts.verbose,
 	}
-	executor, err := install.NewDiagnosticsExecutor(out, os.Stderr, options)
+	executor, err := install.NewDiagnosticsExecutor(stdout, os.Stderr, options)
 	if err != nil {
 		return err
 	}
@@ -35,10 +35,26 @@ func (fe *fakeExecutor) AddWorker(p *install.Plan, newWorker install.Node) (*ins
 	return nil, nil
 }
 
+func (fe *fakeExecutor) AddWorkers(p *install.Plan, newWorkers []install.Node) (*install.Plan, []install.AddWorkerResult, error) {
+	return nil, nil, nil
+}
+
+func (fe *fakeExecutor) RemoveWorker(p *install.Plan, workerName string) (*install.Plan, error) {
+	return nil, nil
+}
+
+func (fe *fakeExecutor) AddNode(p *install.Plan, newNode install.Node, roles []string) (*install.Plan, error) {
+	return nil, nil
+}
+
 func (fe *fakeExecutor) GenerateCertificates(*install.Plan, bool) error {
 	return nil
 }
 
+func (fe *fakeExecutor) RotateCertificates(install.Plan, bool) error {
+	return nil
+}
+
 func (fe *fakeExecutor) Install(p *install.Plan) error {
 	fe.installCalled = true
 	return fe.err
@@ -56,11 +72,31 @@ func (fe *fakeExecutor) RunNewWorkerPreFlightCheck(install.Plan, install.Node) e
 	return nil
 }
 
+func (fe *fakeExecutor) RunNewWorkersPreFlightCheck(install.Plan, []install.Node) (map[string]error, error) {
+	return nil, nil
+}
+
+func (fe *fakeExecutor) RunNewNodePreFlightCheck(install.Plan, install.Node, []string) error {
+	return nil
+}
+
 func (fe *fakeExecutor) RunUpgradePreFlightCheck(*install.Plan, install.ListableNode) error {
 	return nil
 }
 
-func (fe *fakeExecutor) UpgradeNodes(install.Plan, []install.ListableNode, bool, int) error {
+func (fe *fakeExecutor) UpgradeNodes(install.Plan, []install.ListableNode, bool, install.WorkerUpgradeOptions) error {
+	return nil
+}
+
+func (fe *fakeExecutor) RollbackNodes(plan install.Plan, nodes []install.ListableNode) error {
+	return nil
+}
+
+func (fe *fakeExecutor) BackupEtcd(install.Plan, string) error {
+	return nil
+}
+
+func (fe *fakeExecutor) RestoreEtcd(install.Plan, string) error {
 	return nil
 }
 
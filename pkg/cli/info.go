@@ -81,9 +81,13 @@ func list(out io.Writer, opts *infoOpts) error {
 	fmt.Fprintln(out)
 	fmt.Fprintf(out, "Nodes:\n")
 	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
-	fmt.Fprint(w, "Name\tIP\tRoles\tKismatic Version\n")
+	fmt.Fprint(w, "Name\tIP\tRoles\tKismatic Version\tRollback Version\n")
 	for _, listNode := range lv.Nodes {
-		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", listNode.Node.Host, listNode.Node.IP, strings.Join(listNode.Roles, ","), listNode.Version)
+		rollbackVersion := "-"
+		if listNode.RollbackVersion != nil {
+			rollbackVersion = listNode.RollbackVersion.String()
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", listNode.Node.Host, listNode.Node.IP, strings.Join(listNode.Roles, ","), listNode.Version, rollbackVersion)
 	}
 	return w.Flush()
 }
@@ -8,6 +8,7 @@ import (
 
 type installOpts struct {
 	planFilename string
+	eventSinks   []string
 }
 
 // NewCmdInstall creates a new install command
@@ -27,10 +28,13 @@ func NewCmdInstall(in io.Reader, out io.Writer) *cobra.Command {
 	cmd.AddCommand(NewCmdValidate(out, opts))
 	cmd.AddCommand(NewCmdApply(out, opts))
 	cmd.AddCommand(NewCmdAddWorker(out, opts))
+	cmd.AddCommand(NewCmdAddNode(out, opts))
+	cmd.AddCommand(NewCmdRemoveWorker(in, out, opts))
 	cmd.AddCommand(NewCmdStep(out, opts))
 
 	// PersistentFlags
 	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addEventSinkFlag(cmd.PersistentFlags(), &opts.eventSinks)
 
 	return cmd
 }
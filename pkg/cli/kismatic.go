@@ -29,8 +29,9 @@ more documentation is available at https://github.com/apprenda/kismatic`,
 	cmd.AddCommand(NewCmdInfo(out))
 	cmd.AddCommand(NewCmdUpgrade(in, out))
 	cmd.AddCommand(NewCmdDiagnostic(out))
-	cmd.AddCommand(NewCmdCertificates(out))
+	cmd.AddCommand(NewCmdCertificates(in, out))
 	cmd.AddCommand(NewCmdSeedRegistry(out, stderr))
+	cmd.AddCommand(NewCmdBackup(in, out))
 
 	return cmd, nil
 }
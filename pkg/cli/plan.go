@@ -23,6 +23,9 @@ func NewCmdPlan(in io.Reader, out io.Writer, options *installOpts) *cobra.Comman
 		},
 	}
 
+	// Subcommands
+	cmd.AddCommand(NewCmdPlanDiff(out, options))
+
 	return cmd
 }
 
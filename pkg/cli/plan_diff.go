@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type planDiffOpts struct {
+	from         string
+	runsDir      string
+	outputFormat string
+}
+
+// NewCmdPlanDiff creates a new install plan diff command
+func NewCmdPlanDiff(out io.Writer, installOpts *installOpts) *cobra.Command {
+	opts := &planDiffOpts{}
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "compare the plan file with the plan that was last used, and preview the changes",
+		Long: `Compare the plan file with the plan that was recorded in the most recent run in
+the runs directory, or with the plan file given in --from.
+
+The added and removed nodes, the changed component overrides, add-ons and certificate
+subject alternate names are listed, along with the components that would be configured
+again and the services that would be restarted when running "kismatic install apply".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			planner := &install.FilePlanner{File: installOpts.planFilename}
+			return doPlanDiff(out, planner, installOpts.planFilename, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.from, "from", "", "path to the plan file to compare with, instead of the last plan recorded in the runs directory")
+	cmd.Flags().StringVar(&opts.runsDir, "runs-dir", "runs", "path to the directory where information about the runs is kept")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "text", `output format (options "text"|"json")`)
+	return cmd
+}
+
+func doPlanDiff(out io.Writer, planner install.Planner, planFile string, opts *planDiffOpts) error {
+	if opts.outputFormat != "text" && opts.outputFormat != "json" {
+		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
+	}
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: planFile}
+	}
+	newPlan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	from := opts.from
+	if from == "" {
+		from, err = install.LastRecordedPlan(opts.runsDir)
+		if err != nil {
+			return err
+		}
+		if from == "" {
+			return errors.New("no plan has been recorded in the runs directory, use --from to provide the plan file to compare with")
+		}
+	}
+	oldPlanner := &install.FilePlanner{File: from}
+	if !oldPlanner.PlanExists() {
+		return planFileNotFoundErr{filename: from}
+	}
+	oldPlan, err := oldPlanner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file %q: %v", from, err)
+	}
+	diff, err := install.DiffPlans(*oldPlan, *newPlan)
+	if err != nil {
+		return fmt.Errorf("error comparing plans: %v", err)
+	}
+	if opts.outputFormat == "json" {
+		b, err := json.MarshalIndent(diff, "", "    ")
+		if err != nil {
+			return fmt.Errorf("error marshaling plan diff: %v", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+	printPlanDiff(out, planFile, from, *diff)
+	return nil
+}
+
+func printPlanDiff(out io.Writer, planFile, from string, d install.PlanDiff) {
+	fmt.Fprintf(out, "Comparing %q with %q\n", planFile, from)
+	if d.Empty() {
+		util.PrintColor(out, util.Green, "\nThere are no changes.\n")
+		return
+	}
+	if len(d.AddedNodes) > 0 || len(d.RemovedNodes) > 0 || len(d.NodeChanges) > 0 {
+		fmt.Fprintln(out, "\nNodes:")
+		for _, n := range d.AddedNodes {
+			util.PrintColor(out, util.Green, "  + %s (%s) [%s]\n", n.Host, n.IP, strings.Join(n.Roles, ", "))
+		}
+		for _, n := range d.RemovedNodes {
+			util.PrintColor(out, util.Red, "  - %s (%s) [%s]\n", n.Host, n.IP, strings.Join(n.Roles, ", "))
+		}
+		printSettingChanges(out, d.NodeChanges)
+	}
+	if len(d.OverrideChanges) > 0 {
+		fmt.Fprintln(out, "\nComponent overrides:")
+		printSettingChanges(out, d.OverrideChanges)
+	}
+	if len(d.AddOnChanges) > 0 {
+		fmt.Fprintln(out, "\nAdd-ons:")
+		printSettingChanges(out, d.AddOnChanges)
+	}
+	if len(d.CertificateChanges) > 0 {
+		fmt.Fprintln(out, "\nCertificate subject alternate names:")
+		for _, c := range d.CertificateChanges {
+			fmt.Fprintf(out, "  ~ %s\n", c.Certificate)
+			for _, s := range c.AddedSANs {
+				util.PrintColor(out, util.Green, "      + %s\n", s)
+			}
+			for _, s := range c.RemovedSANs {
+				util.PrintColor(out, util.Red, "      - %s\n", s)
+			}
+		}
+	}
+	if len(d.AffectedComponents) > 0 {
+		fmt.Fprintln(out, "\nComponents that would be configured again:")
+		for _, c := range d.AffectedComponents {
+			fmt.Fprintf(out, "  %s\n", c)
+		}
+	}
+	if len(d.RestartedServices) > 0 {
+		fmt.Fprintln(out, "\nServices that would be restarted:")
+		for _, s := range d.RestartedServices {
+			fmt.Fprintf(out, "  %s\n", s)
+		}
+	}
+	if len(d.Warnings) > 0 {
+		fmt.Fprintln(out)
+		for _, w := range d.Warnings {
+			util.PrintColor(out, util.Orange, "Warning: %s\n", w)
+		}
+	}
+}
+
+func printSettingChanges(out io.Writer, changes []install.SettingChange) {
+	for _, c := range changes {
+		switch {
+		case c.Old == "":
+			util.PrintColor(out, util.Green, "  + %s: %q\n", c.Setting, c.New)
+		case c.New == "":
+			util.PrintColor(out, util.Red, "  - %s: %q\n", c.Setting, c.Old)
+		default:
+			fmt.Fprintf(out, "  ~ %s: %q -> %q\n", c.Setting, c.Old, c.New)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/install"
+)
+
+func planDiffTestPlan() *install.Plan {
+	return &install.Plan{
+		Cluster: install.Cluster{
+			Networking: install.NetworkConfig{
+				ServiceCIDRBlock: "10.0.0.0/24",
+			},
+		},
+		Master: install.MasterNodeGroup{
+			Nodes: []install.Node{{Host: "master01", IP: "10.0.2.1"}},
+		},
+		Worker: install.NodeGroup{
+			Nodes: []install.Node{{Host: "worker01", IP: "10.0.3.1"}},
+		},
+	}
+}
+
+func TestPlanDiffNoRecordedPlan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plan-diff-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	planner := &fakePlanner{exists: true, plan: planDiffTestPlan()}
+	opts := &planDiffOpts{runsDir: dir, outputFormat: "text"}
+	if err := doPlanDiff(&bytes.Buffer{}, planner, "plan.yaml", opts); err == nil {
+		t.Errorf("expected an error, but didn't get one")
+	}
+}
+
+func TestPlanDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plan-diff-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	from := filepath.Join(dir, "old.yaml")
+	oldPlanner := &install.FilePlanner{File: from}
+	if err = oldPlanner.Write(planDiffTestPlan()); err != nil {
+		t.Fatalf("error writing plan: %v", err)
+	}
+
+	newPlan := planDiffTestPlan()
+	newPlan.Worker.Nodes = append(newPlan.Worker.Nodes, install.Node{Host: "worker02", IP: "10.0.3.2"})
+	newPlan.Cluster.KubeletOptions.Overrides = map[string]string{"v": "3"}
+	planner := &fakePlanner{exists: true, plan: newPlan}
+
+	out := &bytes.Buffer{}
+	opts := &planDiffOpts{from: from, outputFormat: "text"}
+	if err = doPlanDiff(out, planner, "plan.yaml", opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range []string{"+ worker02 (10.0.3.2) [worker]", `+ kubelet.v: "3"`, "Components that would be configured again:", "kubelet"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected output to contain %q, but got:\n%s", s, out.String())
+		}
+	}
+
+	out.Reset()
+	opts.outputFormat = "json"
+	if err = doPlanDiff(out, planner, "plan.yaml", opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var d install.PlanDiff
+	if err = json.Unmarshal(out.Bytes(), &d); err != nil {
+		t.Fatalf("error unmarshaling json output: %v", err)
+	}
+	if len(d.AddedNodes) != 1 || d.AddedNodes[0].Host != "worker02" {
+		t.Errorf("expected worker02 to be added, but got %v", d.AddedNodes)
+	}
+}
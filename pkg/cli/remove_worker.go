@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type removeWorkerOpts struct {
+	GeneratedAssetsDirectory string
+	OutputFormat             string
+	Verbose                  bool
+	Force                    bool
+}
+
+// NewCmdRemoveWorker returns the command for removing workers from the cluster
+func NewCmdRemoveWorker(in io.Reader, out io.Writer, installOpts *installOpts) *cobra.Command {
+	opts := &removeWorkerOpts{}
+	cmd := &cobra.Command{
+		Use:   "remove-worker WORKER_NAME",
+		Short: "remove a Worker node from an existing Kubernetes cluster",
+		Long: `Remove a Worker node from an existing Kubernetes cluster.
+
+The node is drained and deleted from the Kubernetes API server, and the cluster
+services running on it are stopped. The node is then removed from the plan file,
+and from the allow list of any storage volumes defined in the cluster.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			if !opts.Force {
+				ans, err := util.PromptForString(in, messagesOut(out, opts.OutputFormat), fmt.Sprintf("Are you sure you want to remove worker %q from the cluster? Workloads running on the node will be evicted", args[0]), "N", []string{"N", "y"})
+				if err != nil {
+					return fmt.Errorf("error getting user response: %v", err)
+				}
+				if strings.ToLower(ans) != "y" {
+					return nil
+				}
+			}
+			planner := &install.FilePlanner{File: installOpts.planFilename}
+			execOpts := install.ExecutorOptions{
+				GeneratedAssetsDirectory: opts.GeneratedAssetsDirectory,
+				OutputFormat:             opts.OutputFormat,
+				Verbose:                  opts.Verbose,
+				EventSinks:               installOpts.eventSinks,
+			}
+			executor, err := install.NewExecutor(out, os.Stderr, execOpts)
+			if err != nil {
+				return err
+			}
+			return doRemoveWorker(messagesOut(out, opts.OutputFormat), installOpts.planFilename, planner, executor, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&opts.GeneratedAssetsDirectory, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
+	cmd.Flags().BoolVar(&opts.Verbose, "verbose", false, "enable verbose logging from the installation")
+	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\")")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "do not prompt")
+	return cmd
+}
+
+func doRemoveWorker(out io.Writer, planFile string, planner install.Planner, executor install.Executor, workerName string) error {
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read plan file: %v", err)
+	}
+	if _, errs := install.ValidatePlan(plan); errs != nil {
+		util.PrintValidationErrors(out, errs)
+		return errors.New("the plan file failed validation")
+	}
+	if err = validateSSHConnectivity(out, plan); err != nil {
+		return err
+	}
+	updatedPlan, err := executor.RemoveWorker(plan, workerName)
+	if err != nil {
+		return err
+	}
+	if err := planner.Write(updatedPlan); err != nil {
+		return fmt.Errorf("error updating plan file to remove worker node: %v", err)
+	}
+	fmt.Fprintln(out)
+	util.PrintColor(out, util.Green, "Worker %q was removed from the cluster\n", workerName)
+	return nil
+}
@@ -42,6 +42,7 @@ func NewCmdStep(out io.Writer, opts *installOpts) *cobra.Command {
 				RestartServices:          stepCmd.restartServices,
 				OutputFormat:             stepCmd.outputFormat,
 				Verbose:                  stepCmd.verbose,
+				EventSinks:               opts.eventSinks,
 			}
 			executor, err := install.NewExecutor(out, os.Stderr, execOpts)
 			if err != nil {
@@ -57,7 +58,7 @@ func NewCmdStep(out io.Writer, opts *installOpts) *cobra.Command {
 	cmd.Flags().StringVar(&stepCmd.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&stepCmd.restartServices, "restart-services", false, "force restart cluster services (Use with care)")
 	cmd.Flags().BoolVar(&stepCmd.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&stepCmd.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().StringVarP(&stepCmd.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\")")
 	return cmd
 }
 
@@ -76,10 +77,11 @@ func (c stepCmd) run() error {
 	if err != nil {
 		return fmt.Errorf("error reading plan file: %v", err)
 	}
-	util.PrintHeader(c.out, "Running Task", '=')
+	out := messagesOut(c.out, c.outputFormat)
+	util.PrintHeader(out, "Running Task", '=')
 	if err := c.executor.RunPlay(c.task, plan); err != nil {
 		return err
 	}
-	util.PrintColor(c.out, util.Green, "\nTask completed successfully\n\n")
+	util.PrintColor(out, util.Green, "\nTask completed successfully\n\n")
 	return nil
 }
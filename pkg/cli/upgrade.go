@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/data"
 	"github.com/apprenda/kismatic/pkg/install"
@@ -24,7 +25,14 @@ type upgradeOpts struct {
 	restartServices    bool
 	partialAllowed     bool
 	maxParallelWorkers int
+	canary             bool
+	wavePercentage     int
+	wavePause          time.Duration
+	maxWaveFailures    int
 	dryRun             bool
+	reportFormat       string
+	reportFile         string
+	eventSinks         []string
 }
 
 // NewCmdUpgrade returns the upgrade command
@@ -52,16 +60,20 @@ Nodes in the cluster are upgraded in the following order:
 
 	cmd.PersistentFlags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.PersistentFlags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.PersistentFlags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.PersistentFlags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\")")
 	cmd.PersistentFlags().BoolVar(&opts.skipPreflight, "skip-preflight", false, "skip upgrade pre-flight checks")
 	cmd.PersistentFlags().BoolVar(&opts.restartServices, "restart-services", false, "force restart cluster services (Use with care)")
 	cmd.PersistentFlags().BoolVar(&opts.partialAllowed, "partial-ok", false, "allow the upgrade of ready nodes, and skip nodes that have been deemed unready for upgrade")
-	cmd.PersistentFlags().BoolVar(&opts.dryRun, "dry-run", false, "simulate the upgrade, but don't actually upgrade the cluster")
+	cmd.PersistentFlags().BoolVar(&opts.dryRun, "dry-run", false, "simulate the upgrade, and report what it would do, but don't actually upgrade the cluster")
+	cmd.PersistentFlags().StringVar(&opts.reportFormat, "report-format", "table", `format of the upgrade plan reported during a dry-run (options "table"|"json")`)
+	cmd.PersistentFlags().StringVar(&opts.reportFile, "report-file", "", "write the upgrade plan reported during a dry-run to this file, instead of the standard output")
 	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFile)
+	addEventSinkFlag(cmd.PersistentFlags(), &opts.eventSinks)
 
 	// Subcommands
 	cmd.AddCommand(NewCmdUpgradeOffline(in, out, &opts))
 	cmd.AddCommand(NewCmdUpgradeOnline(in, out, &opts))
+	cmd.AddCommand(NewCmdUpgradeRollback(in, out, &opts))
 	return cmd
 }
 
@@ -84,7 +96,7 @@ production workloads.
 			return doUpgrade(in, out, opts)
 		},
 	}
-	cmd.Flags().IntVar(&opts.maxParallelWorkers, "max-parallel-workers", 1, "the maximum number of worker nodes to be upgraded in parallel")
+	addWorkerUpgradeFlags(&cmd, opts)
 	return &cmd
 }
 
@@ -108,13 +120,35 @@ before any changes are applied.
 		},
 	}
 	cmd.PersistentFlags().BoolVar(&opts.ignoreSafetyChecks, "ignore-safety-checks", false, "ignore upgrade safety checks and continue with the upgrade")
+	addWorkerUpgradeFlags(&cmd, opts)
 	return &cmd
 }
 
-func doUpgrade(in io.Reader, out io.Writer, opts *upgradeOpts) error {
+func addWorkerUpgradeFlags(cmd *cobra.Command, opts *upgradeOpts) {
+	cmd.Flags().IntVar(&opts.maxParallelWorkers, "max-parallel-workers", 1, "the maximum number of worker nodes to be upgraded in parallel")
+	cmd.Flags().BoolVar(&opts.canary, "canary", false, "upgrade and smoke test a single worker node before upgrading the rest of the worker nodes")
+	cmd.Flags().IntVar(&opts.wavePercentage, "wave-percent", 0, "upgrade worker nodes in waves sized as a percentage of the worker nodes to upgrade, instead of using --max-parallel-workers")
+	cmd.Flags().DurationVar(&opts.wavePause, "wave-pause", 0, "the time to wait between waves of worker nodes")
+	cmd.Flags().IntVar(&opts.maxWaveFailures, "max-wave-failures", 0, "the number of worker nodes in a wave that can fail to upgrade or become Ready before the upgrade is aborted")
+}
+
+func doUpgrade(in io.Reader, stdout io.Writer, opts *upgradeOpts) error {
+	out := messagesOut(stdout, opts.outputFormat)
 	if opts.maxParallelWorkers < 1 {
 		return fmt.Errorf("max-parallel-workers must be greater or equal to 1, got: %d", opts.maxParallelWorkers)
 	}
+	if opts.wavePercentage < 0 || opts.wavePercentage > 100 {
+		return fmt.Errorf("wave-percent must be between 0 and 100, got: %d", opts.wavePercentage)
+	}
+	if opts.wavePause < 0 {
+		return fmt.Errorf("wave-pause must not be negative, got: %v", opts.wavePause)
+	}
+	if opts.reportFormat != "table" && opts.reportFormat != "json" {
+		return fmt.Errorf("report format %q is not supported", opts.reportFormat)
+	}
+	if opts.maxWaveFailures < 0 {
+		return fmt.Errorf("max-wave-failures must be greater or equal to 0, got: %d", opts.maxWaveFailures)
+	}
 
 	planFile := opts.planFile
 	planner := install.FilePlanner{File: planFile}
@@ -124,14 +158,15 @@ func doUpgrade(in io.Reader, out io.Writer, opts *upgradeOpts) error {
 		OutputFormat:             opts.outputFormat,
 		Verbose:                  opts.verbose,
 		DryRun:                   opts.dryRun,
+		EventSinks:               opts.eventSinks,
 	}
-	executor, err := install.NewExecutor(out, os.Stderr, executorOpts)
+	executor, err := install.NewExecutor(stdout, os.Stderr, executorOpts)
 	if err != nil {
 		return err
 	}
 	preflightExecOpts := executorOpts
 	preflightExecOpts.DryRun = false // We always want to run preflight, even if doing a dry-run
-	preflightExec, err := install.NewPreFlightExecutor(out, os.Stderr, preflightExecOpts)
+	preflightExec, err := install.NewPreFlightExecutor(stdout, os.Stderr, preflightExecOpts)
 	if err != nil {
 		return err
 	}
@@ -205,7 +240,7 @@ func doUpgrade(in io.Reader, out io.Writer, opts *upgradeOpts) error {
 	if len(toUpgrade) == 0 {
 		fmt.Fprintln(out, "All nodes are at the target version. Skipping node upgrades.")
 	} else {
-		if err = upgradeNodes(in, out, *plan, *opts, toUpgrade, executor, preflightExec); err != nil {
+		if err = upgradeNodes(in, out, *plan, *opts, cv.Nodes, toUpgrade, executor, preflightExec); err != nil {
 			return err
 		}
 	}
@@ -243,9 +278,22 @@ without the "--partial-ok" flag to perform a full upgrade.
 	return nil
 }
 
-func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOpts, nodesNeedUpgrade []install.ListableNode, executor install.Executor, preflightExec install.PreFlightExecutor) error {
+func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOpts, nodes []install.ListableNode, nodesNeedUpgrade []install.ListableNode, executor install.Executor, preflightExec install.PreFlightExecutor) error {
+	checks := map[string]nodeUpgradeChecks{}
+	// blockers are the conditions that stop the upgrade. They are reported,
+	// instead of returned, when doing a dry-run.
+	var blockers []string
+	block := func(err error) error {
+		if opts.dryRun {
+			blockers = append(blockers, err.Error())
+			return nil
+		}
+		return err
+	}
+
 	// Run safety checks if doing an online upgrade
 	unsafeNodes := []install.ListableNode{}
+	var kubeClient data.RemoteKubectl
 	if opts.online {
 		util.PrintHeader(out, "Validate Online Upgrade", '=')
 		// Use the first master node for running kubectl
@@ -253,10 +301,12 @@ func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOp
 		if err != nil {
 			return fmt.Errorf("error getting SSH client: %v", err)
 		}
-		kubeClient := data.RemoteKubectl{SSHClient: client}
+		kubeClient = data.RemoteKubectl{SSHClient: client}
+		safetyErrs := detectUpgradeSafety(plan, nodesNeedUpgrade, opts, kubeClient)
 		for _, node := range nodesNeedUpgrade {
 			util.PrettyPrint(out, "%s %v", node.Node.Host, node.Roles)
-			errs := install.DetectNodeUpgradeSafety(plan, node.Node, kubeClient)
+			errs := safetyErrs[node.Node.Host]
+			checks[node.Node.Host] = nodeUpgradeChecks{safetyChecked: true, safetyErrs: errs}
 			if len(errs) != 0 {
 				if opts.ignoreSafetyChecks {
 					util.PrintWarn(out)
@@ -297,7 +347,9 @@ func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOp
 				}
 			}
 			// did any safety checks fail
-			if safetyErr != nil {
+			if safetyErr != nil && opts.dryRun {
+				blockers = append(blockers, safetyErr.Error()+" Confirming the prompt, or using --ignore-safety-checks, would upgrade the unsafe nodes.")
+			} else if safetyErr != nil {
 				fmt.Fprintln(out)
 				ans, err := util.PromptForString(in, out, "Unsafe conditions detected, continue with the upgrade anyway?", "N", []string{"N", "y"})
 				if err != nil {
@@ -322,25 +374,41 @@ func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOp
 		}
 		for _, node := range nodesNeedUpgrade {
 			util.PrintHeader(out, fmt.Sprintf("Preflight Checks: %s %s", node.Node.Host, node.Roles), '=')
+			c := checks[node.Node.Host]
+			c.preflightChecked = true
 			if err := preflightExec.RunUpgradePreFlightCheck(&plan, node); err != nil {
 				// return fmt.Errorf("Upgrade preflight check failed: %v", err)
 				unreadyNodes = append(unreadyNodes, node)
+				c.preflightErr = err
 			}
+			checks[node.Node.Host] = c
+		}
+	} else {
+		for _, node := range nodesNeedUpgrade {
+			c := checks[node.Node.Host]
+			c.preflightSkipped = true
+			checks[node.Node.Host] = c
 		}
 	}
 
 	// Block upgrade if we found unready nodes, and we are not doing a partial upgrade
 	if len(unreadyNodes) > 0 && !opts.partialAllowed {
-		return errors.New("Errors found during preflight checks")
+		if err := block(errors.New("Errors found during preflight checks")); err != nil {
+			return err
+		}
 	}
 
 	// Block the upgrade if partial is allowed but there is an etcd or master node
 	// that cannot be upgraded
 	if opts.partialAllowed {
+	unready:
 		for _, n := range unreadyNodes {
 			for _, r := range n.Roles {
 				if r == "master" || r == "etcd" {
-					return errors.New("Errors found during preflight checks")
+					if err := block(errors.New("Errors found during preflight checks")); err != nil {
+						return err
+					}
+					break unready
 				}
 			}
 		}
@@ -368,9 +436,118 @@ func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOp
 		}
 	}
 
+	// Leaving out the unready nodes regroups the remaining nodes into different
+	// waves, so the safety of the new waves is evaluated again
+	if opts.online && !opts.ignoreSafetyChecks && len(unreadyNodes) > 0 {
+		safetyErrs := detectUpgradeSafety(plan, toUpgrade, opts, kubeClient)
+		safe := []install.ListableNode{}
+		for _, n := range toUpgrade {
+			errs := safetyErrs[n.Node.Host]
+			if len(errs) == 0 {
+				safe = append(safe, n)
+				continue
+			}
+			c := checks[n.Node.Host]
+			c.safetyErrs = errs
+			checks[n.Node.Host] = c
+			util.PrettyPrintWarn(out, "\nSkipping %s, as upgrading it along with the other nodes is unsafe:", n.Node.Host)
+			for _, err := range errs {
+				fmt.Fprintln(out, "-", err.Error())
+			}
+		}
+		toUpgrade = safe
+	}
+
+	// Report what the upgrade would do, instead of doing it
+	if opts.dryRun {
+		return reportUpgrade(out, plan, opts, nodes, toUpgrade, checks, blockers)
+	}
+
 	// Run the upgrade on the nodes that need it
-	if err := executor.UpgradeNodes(plan, toUpgrade, opts.online, opts.maxParallelWorkers); err != nil {
+	if err := executor.UpgradeNodes(plan, toUpgrade, opts.online, workerUpgradeOptions(opts)); err != nil {
 		return fmt.Errorf("Failed to upgrade nodes: %v", err)
 	}
 	return nil
 }
+
+// detectUpgradeSafety evaluates the safety of upgrading the nodes in the waves in
+// which they are upgraded, as the nodes of a wave are drained at the same time.
+// When a partial upgrade is allowed, the unsafe nodes are left out of the upgrade,
+// which regroups the remaining nodes into different waves. In that case, the
+// waves are evaluated again until all of them are safe.
+func detectUpgradeSafety(plan install.Plan, nodes []install.ListableNode, opts upgradeOpts, kubeClient data.RemoteKubectl) map[string][]error {
+	errs := map[string][]error{}
+	remaining := nodes
+	for {
+		safe := []install.ListableNode{}
+		for _, batch := range install.UpgradeBatches(remaining, workerUpgradeOptions(opts)) {
+			wave := make([]install.Node, 0, len(batch.Nodes))
+			for _, n := range batch.Nodes {
+				wave = append(wave, n.Node)
+			}
+			waveErrs := install.DetectWaveUpgradeSafety(plan, wave, kubeClient)
+			for _, n := range batch.Nodes {
+				errs[n.Node.Host] = waveErrs[n.Node.Host]
+				if len(waveErrs[n.Node.Host]) == 0 {
+					safe = append(safe, n)
+				}
+			}
+		}
+		if len(safe) == len(remaining) || !opts.partialAllowed || opts.ignoreSafetyChecks {
+			return errs
+		}
+		remaining = safe
+	}
+}
+
+func workerUpgradeOptions(opts upgradeOpts) install.WorkerUpgradeOptions {
+	return install.WorkerUpgradeOptions{
+		MaxParallelWorkers:    opts.maxParallelWorkers,
+		Canary:                opts.canary,
+		WavePercentage:        opts.wavePercentage,
+		WavePause:             opts.wavePause,
+		MaxFailedNodesPerWave: opts.maxWaveFailures,
+	}
+}
+
+func reportUpgrade(out io.Writer, plan install.Plan, opts upgradeOpts, nodes, toUpgrade []install.ListableNode, checks map[string]nodeUpgradeChecks, blockers []string) error {
+	util.PrintHeader(out, "Upgrade Plan", '=')
+	var components []componentVersionChange
+	var warnings []string
+	im, err := readImageManifest()
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("Unable to determine the component version changes: %v", err))
+	} else {
+		images, listWarnings := listRunningImages(plan, toUpgrade)
+		warnings = append(warnings, listWarnings...)
+		components = componentVersionChanges(im, images)
+	}
+	report := buildUpgradeReport(opts, nodes, toUpgrade, checks, components)
+	report.Blockers = blockers
+	report.Warnings = warnings
+	if opts.reportFile == "" {
+		err = printUpgradeReport(out, report, opts.reportFormat)
+	} else {
+		err = writeUpgradeReport(out, report, opts.reportFormat, opts.reportFile)
+	}
+	if err != nil {
+		return err
+	}
+	if len(blockers) > 0 {
+		return errors.New("The upgrade would be blocked by the conditions in the upgrade plan")
+	}
+	return nil
+}
+
+func writeUpgradeReport(out io.Writer, report upgradeReport, format, file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("error creating upgrade plan file: %v", err)
+	}
+	defer f.Close()
+	if err := printUpgradeReport(f, report, format); err != nil {
+		return err
+	}
+	util.PrettyPrintOk(out, "Wrote the upgrade plan to %q", file)
+	return nil
+}
@@ -0,0 +1,344 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+// roleDowntime is a rough estimate of the time a node with the given role is
+// out of service while it is upgraded, and the impact it has on the cluster
+type roleDowntime struct {
+	Role    string        `json:"role"`
+	PerNode time.Duration `json:"-"`
+	Impact  string        `json:"impact"`
+}
+
+var roleDowntimes = []roleDowntime{
+	{
+		Role:    "etcd",
+		PerNode: 2 * time.Minute,
+		Impact:  "The etcd members on the node are unavailable. The etcd clusters remain available if they have 3 or more members.",
+	},
+	{
+		Role:    "master",
+		PerNode: 3 * time.Minute,
+		Impact:  "The API server, scheduler and controller manager on the node are unavailable. The control plane remains available if there are 2 or more master nodes.",
+	},
+	{
+		Role:    "worker",
+		PerNode: 5 * time.Minute,
+		Impact:  "The node is drained during an online upgrade. Its pods are evicted and rescheduled on other nodes.",
+	},
+	{
+		Role:    "ingress",
+		PerNode: 5 * time.Minute,
+		Impact:  "The ingress controller on the node is unavailable.",
+	},
+	{
+		Role:    "storage",
+		PerNode: 5 * time.Minute,
+		Impact:  "The storage bricks on the node are unavailable.",
+	},
+}
+
+// upgradeReport describes what an upgrade would do, without doing it
+type upgradeReport struct {
+	Online            bool                        `json:"online"`
+	TargetVersion     string                      `json:"targetVersion"`
+	Blockers          []string                    `json:"blockers,omitempty"`
+	Warnings          []string                    `json:"warnings,omitempty"`
+	Batches           []upgradeReportBatch        `json:"batches"`
+	Nodes             []upgradeReportNode         `json:"nodes"`
+	Components        []componentVersionChange    `json:"components"`
+	Downtime          []upgradeReportRoleDowntime `json:"estimatedDowntime"`
+	EstimatedDuration string                      `json:"estimatedDuration"`
+}
+
+type upgradeReportBatch struct {
+	Number int      `json:"number"`
+	Phase  string   `json:"phase"`
+	Nodes  []string `json:"nodes"`
+}
+
+type upgradeReportNode struct {
+	Host           string   `json:"host"`
+	IP             string   `json:"ip"`
+	Roles          []string `json:"roles"`
+	CurrentVersion string   `json:"currentVersion"`
+	// Action is either "upgrade" or "skip"
+	Action string `json:"action"`
+	// Safety is "passed", "failed" or "not checked"
+	Safety       string   `json:"safety"`
+	SafetyErrors []string `json:"safetyErrors,omitempty"`
+	// Preflight is "passed", "failed", "skipped" or "not checked"
+	Preflight string `json:"preflight"`
+}
+
+type upgradeReportRoleDowntime struct {
+	roleDowntime
+	Nodes           int    `json:"nodes"`
+	DowntimePerNode string `json:"downtimePerNode"`
+}
+
+// componentVersionChange is the change of the version of a component in the
+// image manifest. The current versions are those of the containers running on
+// the nodes that are being upgraded.
+type componentVersionChange struct {
+	Component string   `json:"component"`
+	Image     string   `json:"image"`
+	Current   []string `json:"current"`
+	Target    string   `json:"target"`
+	Changed   bool     `json:"changed"`
+}
+
+// nodeUpgradeChecks are the results of the checks run against a node
+type nodeUpgradeChecks struct {
+	safetyChecked bool
+	safetyErrs    []error
+	// preflightErr is nil if preflight was skipped or passed
+	preflightChecked bool
+	preflightErr     error
+	preflightSkipped bool
+}
+
+func buildUpgradeReport(opts upgradeOpts, nodes []install.ListableNode, toUpgrade []install.ListableNode, checks map[string]nodeUpgradeChecks, components []componentVersionChange) upgradeReport {
+	workerOpts := workerUpgradeOptions(opts)
+	report := upgradeReport{
+		Online:        opts.online,
+		TargetVersion: install.KismaticVersion.String(),
+		Batches:       []upgradeReportBatch{},
+		Nodes:         []upgradeReportNode{},
+		Components:    components,
+		Downtime:      []upgradeReportRoleDowntime{},
+	}
+	upgrading := map[string]bool{}
+	for _, n := range toUpgrade {
+		upgrading[n.Node.Host] = true
+	}
+	for _, n := range nodes {
+		c := checks[n.Node.Host]
+		rn := upgradeReportNode{
+			Host:           n.Node.Host,
+			IP:             n.Node.IP,
+			Roles:          n.Roles,
+			CurrentVersion: n.Version.String(),
+			Action:         "skip",
+			Safety:         "not checked",
+			Preflight:      "not checked",
+		}
+		if upgrading[n.Node.Host] {
+			rn.Action = "upgrade"
+		}
+		if c.safetyChecked {
+			rn.Safety = "passed"
+			if len(c.safetyErrs) > 0 {
+				rn.Safety = "failed"
+			}
+			for _, err := range c.safetyErrs {
+				rn.SafetyErrors = append(rn.SafetyErrors, err.Error())
+			}
+		}
+		switch {
+		case c.preflightSkipped:
+			rn.Preflight = "skipped"
+		case c.preflightErr != nil:
+			rn.Preflight = "failed"
+		case c.preflightChecked:
+			rn.Preflight = "passed"
+		}
+		report.Nodes = append(report.Nodes, rn)
+	}
+
+	var duration time.Duration
+	wave := 0
+	for i, b := range install.UpgradeBatches(toUpgrade, workerOpts) {
+		rb := upgradeReportBatch{Number: i + 1, Phase: b.Phase}
+		var batchDuration time.Duration
+		for _, n := range b.Nodes {
+			rb.Nodes = append(rb.Nodes, n.Node.Host)
+			if d := nodeDowntime(n.Roles); d > batchDuration {
+				batchDuration = d
+			}
+		}
+		if b.Phase == "canary" || b.Phase == "worker" {
+			if install.PauseBeforeWave(wave, workerOpts) {
+				duration += workerOpts.WavePause
+			}
+			wave++
+		}
+		duration += batchDuration
+		report.Batches = append(report.Batches, rb)
+	}
+	report.EstimatedDuration = duration.String()
+
+	for _, rd := range roleDowntimes {
+		count := len(install.NodesWithRoles(toUpgrade, rd.Role))
+		if count == 0 {
+			continue
+		}
+		report.Downtime = append(report.Downtime, upgradeReportRoleDowntime{
+			roleDowntime:    rd,
+			Nodes:           count,
+			DowntimePerNode: rd.PerNode.String(),
+		})
+	}
+	return report
+}
+
+// nodeDowntime returns the estimated downtime of a node with the given roles.
+// All the components of the node are upgraded at the same time.
+func nodeDowntime(roles []string) time.Duration {
+	var d time.Duration
+	for _, rd := range roleDowntimes {
+		if util.Contains(rd.Role, roles) && rd.PerNode > d {
+			d = rd.PerNode
+		}
+	}
+	return d
+}
+
+// componentVersionChanges compares the versions of the images in the manifest
+// with the versions of the images running on the nodes. Images are matched by
+// name, regardless of the registry they were pulled from. Components that are
+// not running on any node are not included.
+func componentVersionChanges(manifest imageManifest, runningImages []string) []componentVersionChange {
+	changes := []componentVersionChange{}
+	names := make([]string, 0, len(manifest.OfficialImages))
+	for name := range manifest.OfficialImages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		img := manifest.OfficialImages[name]
+		current := []string{}
+		for _, running := range runningImages {
+			repo, tag := splitImage(running)
+			if repo != img.Name && !strings.HasSuffix(repo, "/"+img.Name) {
+				continue
+			}
+			if !util.Contains(tag, current) {
+				current = append(current, tag)
+			}
+		}
+		if len(current) == 0 {
+			continue
+		}
+		sort.Strings(current)
+		c := componentVersionChange{
+			Component: name,
+			Image:     img.Name,
+			Current:   current,
+			Target:    img.Version,
+		}
+		for _, v := range current {
+			if v != img.Version {
+				c.Changed = true
+			}
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// splitImage splits an image reference into the repository and the tag. The
+// tag is "latest" if the reference does not have one.
+func splitImage(image string) (string, string) {
+	image = strings.TrimSpace(image)
+	// The tag comes after the last colon, as long as it is not part of the registry's port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// listRunningImages returns the images of the containers running on the nodes.
+// The nodes that could not be inspected are returned as warnings.
+func listRunningImages(plan install.Plan, nodes []install.ListableNode) ([]string, []string) {
+	images := []string{}
+	warnings := []string{}
+	for _, n := range nodes {
+		client, err := plan.GetSSHClient(n.Node.Host)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Unable to list the containers running on %q: %v", n.Node.Host, err))
+			continue
+		}
+		out, err := client.Output(true, "sudo docker ps --format '{{.Image}}'")
+		client.Close()
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Unable to list the containers running on %q: %s", n.Node.Host, out))
+			continue
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				images = append(images, line)
+			}
+		}
+	}
+	return images, warnings
+}
+
+func printUpgradeReport(out io.Writer, report upgradeReport, format string) error {
+	if format == "json" {
+		b, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return fmt.Errorf("marshal error: %v", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+	mode := "offline"
+	if report.Online {
+		mode = "online"
+	}
+	fmt.Fprintf(out, "Upgrade Plan (%s upgrade to v%s)\n\n", mode, report.TargetVersion)
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "BATCH\tPHASE\tNODES")
+	for _, b := range report.Batches {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", b.Number, b.Phase, strings.Join(b.Nodes, ","))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "NODE\tIP\tROLES\tVERSION\tACTION\tSAFETY\tPREFLIGHT")
+	for _, n := range report.Nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\tv%s\t%s\t%s\t%s\n", n.Host, n.IP, strings.Join(n.Roles, ","), n.CurrentVersion, n.Action, n.Safety, n.Preflight)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "COMPONENT\tIMAGE\tCURRENT\tTARGET")
+	for _, c := range report.Components {
+		target := c.Target
+		if !c.Changed {
+			target += " (unchanged)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Component, c.Image, strings.Join(c.Current, ","), target)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "ROLE\tNODES\tDOWNTIME PER NODE\tIMPACT")
+	for _, d := range report.Downtime {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", d.Role, d.Nodes, d.DowntimePerNode, d.Impact)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\nEstimated duration of the node upgrades: %s\n", report.EstimatedDuration)
+
+	for _, n := range report.Nodes {
+		for _, e := range n.SafetyErrors {
+			util.PrettyPrintErr(out, "%s: %s", n.Host, e)
+		}
+	}
+	for _, warning := range report.Warnings {
+		util.PrettyPrintWarn(out, "%s", warning)
+	}
+	for _, b := range report.Blockers {
+		util.PrettyPrintErr(out, "The upgrade would be blocked: %s", b)
+	}
+	return nil
+}
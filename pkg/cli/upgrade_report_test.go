@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/blang/semver"
+)
+
+func TestSplitImage(t *testing.T) {
+	tests := []struct {
+		image string
+		repo  string
+		tag   string
+	}{
+		{image: "quay.io/coreos/etcd:v3.1.10", repo: "quay.io/coreos/etcd", tag: "v3.1.10"},
+		{image: "localhost:5000/calico/node:v2.6.5", repo: "localhost:5000/calico/node", tag: "v2.6.5"},
+		{image: "localhost:5000/busybox", repo: "localhost:5000/busybox", tag: "latest"},
+		{image: "busybox", repo: "busybox", tag: "latest"},
+	}
+	for _, test := range tests {
+		repo, tag := splitImage(test.image)
+		if repo != test.repo || tag != test.tag {
+			t.Errorf("%s: expected %q and %q, but got %q and %q", test.image, test.repo, test.tag, repo, tag)
+		}
+	}
+}
+
+func TestComponentVersionChanges(t *testing.T) {
+	manifest := imageManifest{
+		OfficialImages: map[string]image{
+			"etcd":       {Name: "quay.io/coreos/etcd", Version: "v3.1.10"},
+			"kube_proxy": {Name: "gcr.io/google-containers/kube-proxy-amd64", Version: "v1.9.0"},
+			"weave":      {Name: "weaveworks/weave-kube", Version: "2.1.3"},
+		},
+	}
+	running := []string{
+		"quay.io/coreos/etcd:v3.1.10",
+		"registry.local:5000/gcr.io/google-containers/kube-proxy-amd64:v1.8.4",
+		"gcr.io/google-containers/kube-proxy-amd64:v1.9.0",
+		"gcr.io/google-containers/kube-proxy-amd64:v1.8.4",
+		"nginx:latest",
+	}
+	expected := []componentVersionChange{
+		{Component: "etcd", Image: "quay.io/coreos/etcd", Current: []string{"v3.1.10"}, Target: "v3.1.10", Changed: false},
+		{Component: "kube_proxy", Image: "gcr.io/google-containers/kube-proxy-amd64", Current: []string{"v1.8.4", "v1.9.0"}, Target: "v1.9.0", Changed: true},
+	}
+	changes := componentVersionChanges(manifest, running)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %+v, but got %+v", expected, changes)
+	}
+}
+
+func TestBuildUpgradeReport(t *testing.T) {
+	install.SetVersion("v1.7.0")
+	old := semver.MustParse("1.6.0")
+	nodes := []install.ListableNode{
+		{Node: install.Node{Host: "etcd01", IP: "10.0.0.1"}, Roles: []string{"etcd"}, Version: old},
+		{Node: install.Node{Host: "master01", IP: "10.0.0.2"}, Roles: []string{"master"}, Version: old},
+		{Node: install.Node{Host: "worker01", IP: "10.0.0.3"}, Roles: []string{"worker"}, Version: old},
+		{Node: install.Node{Host: "worker02", IP: "10.0.0.4"}, Roles: []string{"worker"}, Version: old},
+		{Node: install.Node{Host: "worker03", IP: "10.0.0.5"}, Roles: []string{"worker"}, Version: old},
+	}
+	toUpgrade := []install.ListableNode{nodes[0], nodes[1], nodes[2], nodes[3]}
+	checks := map[string]nodeUpgradeChecks{
+		"etcd01":   {safetyChecked: true, preflightChecked: true},
+		"master01": {safetyChecked: true, preflightChecked: true},
+		"worker01": {safetyChecked: true, preflightChecked: true},
+		"worker02": {safetyChecked: true, preflightChecked: true},
+		"worker03": {safetyChecked: true, safetyErrs: []error{errors.New("unsafe")}},
+	}
+	opts := upgradeOpts{online: true, maxParallelWorkers: 1, canary: true, wavePause: time.Minute}
+	report := buildUpgradeReport(opts, nodes, toUpgrade, checks, nil)
+
+	if len(report.Batches) != 4 {
+		t.Fatalf("expected 4 batches, but got %+v", report.Batches)
+	}
+	phases := []string{}
+	for _, b := range report.Batches {
+		phases = append(phases, b.Phase)
+	}
+	if !reflect.DeepEqual(phases, []string{"etcd", "master", "canary", "worker"}) {
+		t.Errorf("unexpected batch phases %v", phases)
+	}
+	// etcd (2m) + master (3m) + canary (5m) + worker (5m), without a pause after the canary
+	if report.EstimatedDuration != (15 * time.Minute).String() {
+		t.Errorf("expected estimated duration of 15m, but got %s", report.EstimatedDuration)
+	}
+	if report.Nodes[2].Preflight != "passed" {
+		t.Errorf("expected worker01 to pass the preflight checks, but got %+v", report.Nodes[2])
+	}
+	last := report.Nodes[4]
+	if last.Action != "skip" || last.Safety != "failed" || len(last.SafetyErrors) != 1 || last.Preflight != "not checked" {
+		t.Errorf("expected worker03 to be skipped after failing the safety checks, but got %+v", last)
+	}
+	if len(report.Downtime) != 3 {
+		t.Errorf("expected downtime estimates for 3 roles, but got %+v", report.Downtime)
+	} else if report.Downtime[2].Role != "worker" || report.Downtime[2].Nodes != 2 {
+		t.Errorf("expected downtime estimate for 2 worker nodes, but got %+v", report.Downtime[2])
+	}
+
+	// The JSON report can be read back
+	var b bytes.Buffer
+	if err := printUpgradeReport(&b, report, "json"); err != nil {
+		t.Fatalf("unexpected error printing report: %v", err)
+	}
+	var read upgradeReport
+	if err := json.Unmarshal(b.Bytes(), &read); err != nil {
+		t.Fatalf("error unmarshaling report: %v", err)
+	}
+	if len(read.Nodes) != len(report.Nodes) || read.Downtime[0].Role != "etcd" {
+		t.Errorf("JSON report does not match: %s", b.String())
+	}
+	b.Reset()
+	if err := printUpgradeReport(&b, report, "table"); err != nil {
+		t.Fatalf("unexpected error printing report: %v", err)
+	}
+	if !strings.Contains(b.String(), "canary") {
+		t.Errorf("expected the table report to include the canary batch, but got:\n%s", b.String())
+	}
+}
+
+func TestBuildUpgradeReportWavePauses(t *testing.T) {
+	install.SetVersion("v1.7.0")
+	old := semver.MustParse("1.6.0")
+	nodes := []install.ListableNode{
+		{Node: install.Node{Host: "master01", IP: "10.0.0.1"}, Roles: []string{"etcd", "master"}, Version: old},
+		{Node: install.Node{Host: "worker01", IP: "10.0.0.2"}, Roles: []string{"worker"}, Version: old},
+		{Node: install.Node{Host: "worker02", IP: "10.0.0.3"}, Roles: []string{"worker"}, Version: old},
+	}
+	opts := upgradeOpts{online: true, maxParallelWorkers: 1, wavePause: time.Minute}
+	report := buildUpgradeReport(opts, nodes, nodes, map[string]nodeUpgradeChecks{}, nil)
+
+	// master (3m) + worker (5m) + pause (1m) + worker (5m), without a pause before the first wave
+	if report.EstimatedDuration != (14 * time.Minute).String() {
+		t.Errorf("expected estimated duration of 14m, but got %s", report.EstimatedDuration)
+	}
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type upgradeRollbackOpts struct {
+	force bool
+}
+
+// NewCmdUpgradeRollback returns the command for rolling back upgraded nodes
+func NewCmdUpgradeRollback(in io.Reader, out io.Writer, opts *upgradeOpts) *cobra.Command {
+	rollbackOpts := upgradeRollbackOpts{}
+	cmd := &cobra.Command{
+		Use:   "rollback [HOST...]",
+		Short: "Roll back nodes to the Kismatic version they had before their last upgrade",
+		Long: `Roll back nodes to the Kismatic version they had before their last upgrade.
+
+Before changing a node, the upgrade takes a snapshot of the configuration of the
+Kubernetes components, and of the versions of the Kubernetes packages installed on it.
+Rolling back a node restores the snapshot, and restarts the components.
+
+The given nodes are rolled back, or all the nodes that have a snapshot if none are given.
+Nodes are rolled back one at a time, in the same order in which they are upgraded:
+
+1. Etcd nodes
+2. Master nodes
+3. Worker nodes (regardless of specialization)
+
+The data stored in etcd is not rolled back. Etcd nodes are only rolled back if their
+snapshot runs the same etcd version as the node, as an older etcd cannot safely run on
+the data written by a newer one. Otherwise, use 'kismatic backup restore' to restore
+the cluster instead.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			planner := &install.FilePlanner{File: opts.planFile}
+			execOpts := install.ExecutorOptions{
+				GeneratedAssetsDirectory: opts.generatedAssetsDir,
+				OutputFormat:             opts.outputFormat,
+				Verbose:                  opts.verbose,
+				DryRun:                   opts.dryRun,
+				EventSinks:               opts.eventSinks,
+			}
+			executor, err := install.NewExecutor(out, os.Stderr, execOpts)
+			if err != nil {
+				return err
+			}
+			return doUpgradeRollback(in, messagesOut(out, opts.outputFormat), planner, executor, opts.planFile, args, rollbackOpts)
+		},
+	}
+	cmd.Flags().BoolVar(&rollbackOpts.force, "force", false, "do not prompt")
+	return cmd
+}
+
+func doUpgradeRollback(in io.Reader, out io.Writer, planner install.Planner, executor install.Executor, planFile string, hosts []string, opts upgradeRollbackOpts) error {
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	if err = validateSSHConnectivity(out, plan); err != nil {
+		return err
+	}
+	cv, err := install.ListVersions(plan)
+	if err != nil {
+		return fmt.Errorf("error listing cluster versions: %v", err)
+	}
+	nodes, err := selectRollbackNodes(cv.Nodes, hosts)
+	if err != nil {
+		return err
+	}
+
+	util.PrintHeader(out, "Nodes to roll back", '=')
+	for _, n := range nodes {
+		fmt.Fprintf(out, "- %q %v from v%s to v%s\n", n.Node.Host, n.Roles, n.Version, n.RollbackVersion)
+	}
+	fmt.Fprintln(out)
+	if !opts.force {
+		ans, err := util.PromptForString(in, out, "Are you sure you want to roll back these nodes?", "N", []string{"N", "y"})
+		if err != nil {
+			return fmt.Errorf("error getting user response: %v", err)
+		}
+		if strings.ToLower(ans) != "y" {
+			return nil
+		}
+	}
+	if err = executor.RollbackNodes(*plan, nodes); err != nil {
+		return fmt.Errorf("Failed to roll back nodes: %v", err)
+	}
+	fmt.Fprintln(out)
+	util.PrintColor(out, util.Green, "The nodes were rolled back successfully!\n")
+	return nil
+}
+
+// selectRollbackNodes returns the nodes with the given hosts, or all the nodes
+// that can be rolled back if no hosts are given
+func selectRollbackNodes(nodes []install.ListableNode, hosts []string) ([]install.ListableNode, error) {
+	selected := []install.ListableNode{}
+	if len(hosts) == 0 {
+		for _, n := range nodes {
+			if n.RollbackVersion != nil {
+				selected = append(selected, n)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, errors.New("none of the nodes can be rolled back, as they have not been upgraded")
+		}
+		return selected, nil
+	}
+	for _, h := range hosts {
+		found := false
+		for _, n := range nodes {
+			if n.Node.Host != h {
+				continue
+			}
+			if n.RollbackVersion == nil {
+				return nil, fmt.Errorf("node %q cannot be rolled back, as it has not been upgraded", h)
+			}
+			selected = append(selected, n)
+			found = true
+			break
+		}
+		if !found {
+			return nil, fmt.Errorf("node %q is not in the plan file", h)
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/blang/semver"
+)
+
+func TestSelectRollbackNodes(t *testing.T) {
+	previous := semver.MustParse("1.6.0")
+	nodes := []install.ListableNode{
+		{Node: install.Node{Host: "etcd01"}, Roles: []string{"etcd"}, RollbackVersion: &previous},
+		{Node: install.Node{Host: "master01"}, Roles: []string{"master"}},
+		{Node: install.Node{Host: "worker01"}, Roles: []string{"worker"}, RollbackVersion: &previous},
+	}
+	tests := []struct {
+		hosts    []string
+		expected []string
+		valid    bool
+	}{
+		{
+			hosts:    []string{},
+			expected: []string{"etcd01", "worker01"},
+			valid:    true,
+		},
+		{
+			hosts:    []string{"worker01"},
+			expected: []string{"worker01"},
+			valid:    true,
+		},
+		{
+			hosts: []string{"master01"},
+			valid: false,
+		},
+		{
+			hosts: []string{"notInPlan"},
+			valid: false,
+		},
+	}
+	for _, test := range tests {
+		selected, err := selectRollbackNodes(nodes, test.hosts)
+		if (err == nil) != test.valid {
+			t.Errorf("%v: expected valid = %v, but got error: %v", test.hosts, test.valid, err)
+			continue
+		}
+		if len(selected) != len(test.expected) {
+			t.Errorf("%v: expected %d nodes, but got %d", test.hosts, len(test.expected), len(selected))
+			continue
+		}
+		for i, n := range selected {
+			if n.Node.Host != test.expected[i] {
+				t.Errorf("%v: expected node %q, but got %q", test.hosts, test.expected[i], n.Node.Host)
+			}
+		}
+	}
+}
+
+func TestSelectRollbackNodesNoneUpgraded(t *testing.T) {
+	nodes := []install.ListableNode{
+		{Node: install.Node{Host: "etcd01"}, Roles: []string{"etcd"}},
+	}
+	if _, err := selectRollbackNodes(nodes, nil); err == nil {
+		t.Errorf("expected an error when no nodes can be rolled back")
+	}
+}
@@ -18,6 +18,8 @@ type validateOpts struct {
 	verbose            bool
 	outputFormat       string
 	skipPreFlight      bool
+	strict             bool
+	eventSinks         []string
 }
 
 // NewCmdValidate creates a new install validate command
@@ -32,17 +34,20 @@ func NewCmdValidate(out io.Writer, installOpts *installOpts) *cobra.Command {
 			}
 			planner := &install.FilePlanner{File: installOpts.planFilename}
 			opts.planFile = installOpts.planFilename
+			opts.eventSinks = installOpts.eventSinks
 			return doValidate(out, planner, opts)
 		},
 	}
 	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options simple|raw)")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options simple|raw|json)")
 	cmd.Flags().BoolVar(&opts.skipPreFlight, "skip-preflight", false, "skip pre-flight checks")
+	cmd.Flags().BoolVar(&opts.strict, "strict", false, "reject fields that are not part of the plan, and report the line and column of each validation error")
 	return cmd
 }
 
-func doValidate(out io.Writer, planner install.Planner, opts *validateOpts) error {
+func doValidate(stdout io.Writer, planner install.Planner, opts *validateOpts) error {
+	out := messagesOut(stdout, opts.outputFormat)
 	util.PrintHeader(out, "Validating", '=')
 	// Check if plan file exists
 	if !planner.PlanExists() {
@@ -58,6 +63,11 @@ func doValidate(out io.Writer, planner install.Planner, opts *validateOpts) erro
 	util.PrettyPrintOk(out, "Reading installation plan file %q", opts.planFile)
 
 	// Validate plan file
+	if opts.strict {
+		if err := validatePlanFile(out, opts.planFile); err != nil {
+			return err
+		}
+	}
 	if err := validatePlan(out, plan); err != nil {
 		return err
 	}
@@ -85,10 +95,12 @@ func doValidate(out io.Writer, planner install.Planner, opts *validateOpts) erro
 	}
 	// Run pre-flight
 	options := install.ExecutorOptions{
-		OutputFormat: opts.outputFormat,
-		Verbose:      opts.verbose,
+		GeneratedAssetsDirectory: opts.generatedAssetsDir,
+		OutputFormat:             opts.outputFormat,
+		Verbose:                  opts.verbose,
+		EventSinks:               opts.eventSinks,
 	}
-	e, err := install.NewPreFlightExecutor(out, os.Stderr, options)
+	e, err := install.NewPreFlightExecutor(stdout, os.Stderr, options)
 	if err != nil {
 		return err
 	}
@@ -121,6 +133,17 @@ func validatePlan(out io.Writer, plan *install.Plan) error {
 	return nil
 }
 
+func validatePlanFile(out io.Writer, planFile string) error {
+	ok, errs := install.ValidatePlanFile(planFile)
+	if !ok {
+		util.PrettyPrintErr(out, "Validating installation plan file strictly")
+		util.PrintValidationErrors(out, errs)
+		return fmt.Errorf("Plan file validation error prevents installation from proceeding")
+	}
+	util.PrettyPrintOk(out, "Validating installation plan file strictly")
+	return nil
+}
+
 func validateSSHConnectivity(out io.Writer, plan *install.Plan) error {
 	ok, errs := install.ValidatePlanSSHConnections(plan)
 	if !ok {
@@ -2,6 +2,10 @@ package cli
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/apprenda/kismatic/pkg/install"
@@ -47,3 +51,31 @@ func TestValidateCmdPlanInvalid(t *testing.T) {
 		t.Errorf("did not read the plan file")
 	}
 }
+
+func TestValidateCmdStrictPlanUnknownField(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "validate-strict")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	planFile := filepath.Join(tmp, "kismatic-cluster.yaml")
+	if err = ioutil.WriteFile(planFile, []byte("cluster:\n  name: test\n  kube_apiservr: {}\n"), 0644); err != nil {
+		t.Fatalf("error writing plan file: %v", err)
+	}
+	out := &bytes.Buffer{}
+	fp := &fakePlanner{
+		exists: true,
+		plan:   &install.Plan{},
+	}
+	opts := &validateOpts{
+		planFile:     planFile,
+		outputFormat: "simple",
+		strict:       true,
+	}
+	if err = doValidate(out, fp, opts); err == nil {
+		t.Errorf("did not return an error with an unknown field in the plan")
+	}
+	if !strings.Contains(out.String(), `line 3, column 3 (cluster.kube_apiservr): field "kube_apiservr" is not part of the plan`) {
+		t.Errorf("the unknown field was not reported, output was:\n%s", out.String())
+	}
+}
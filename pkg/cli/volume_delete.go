@@ -29,7 +29,7 @@ func NewCmdVolumeDelete(in io.Reader, out io.Writer, planFile *string) *cobra.Co
 WARNING all data in the volume will be lost.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if opts.force == false {
-				ans, err := util.PromptForString(in, out, "Are you sure you want to delete this volume? All data will be lost", "N", []string{"N", "y"})
+				ans, err := util.PromptForString(in, messagesOut(out, opts.outputFormat), "Are you sure you want to delete this volume? All data will be lost", "N", []string{"N", "y"})
 				if err != nil {
 					return fmt.Errorf("error getting user response: %v", err)
 				}
@@ -41,13 +41,14 @@ WARNING all data in the volume will be lost.`,
 		},
 	}
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging")
-	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options simple|raw)`)
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options simple|raw|json)`)
 	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&opts.force, "force", false, `do not prompt`)
 	return cmd
 }
 
-func doVolumeDelete(out io.Writer, opts volumeDeleteOptions, planFile string, args []string) error {
+func doVolumeDelete(stdout io.Writer, opts volumeDeleteOptions, planFile string, args []string) error {
+	out := messagesOut(stdout, opts.outputFormat)
 	// get volume name and size from arguments
 	var volumeName string
 	switch len(args) {
@@ -68,7 +69,7 @@ func doVolumeDelete(out io.Writer, opts volumeDeleteOptions, planFile string, ar
 		// Need to refactor executor code... this will do for now as we don't need the generated assets dir in this command
 		GeneratedAssetsDirectory: opts.generatedAssetsDir,
 	}
-	exec, err := install.NewExecutor(out, out, execOpts)
+	exec, err := install.NewExecutor(stdout, os.Stderr, execOpts)
 	if err != nil {
 		return err
 	}
@@ -85,7 +86,7 @@ func doVolumeDelete(out io.Writer, opts volumeDeleteOptions, planFile string, ar
 		skipPreFlight:      true,
 		generatedAssetsDir: opts.generatedAssetsDir,
 	}
-	if err := doValidate(out, planner, vopts); err != nil {
+	if err := doValidate(stdout, planner, vopts); err != nil {
 		return err
 	}
 
@@ -48,6 +48,11 @@ type StatefulSetGetter interface {
 	GetStatefulSet(namespace, name string) (*StatefulSet, error)
 }
 
+// PodDisruptionBudgetLister lists the pod disruption budgets on a Kubernetes cluster
+type PodDisruptionBudgetLister interface {
+	ListPodDisruptionBudgets() (*PodDisruptionBudgetList, error)
+}
+
 type KubernetesClient interface {
 	PodLister
 	PVLister
@@ -102,6 +107,22 @@ func UnmarshalPods(raw string) (*PodList, error) {
 	return &pods, nil
 }
 
+// ListPodDisruptionBudgets returns the PodDisruptionBudgets in all namespaces
+func (k RemoteKubectl) ListPodDisruptionBudgets() (*PodDisruptionBudgetList, error) {
+	raw, err := k.SSHClient.Output(true, "sudo kubectl get pdb --all-namespaces=true -o json")
+	if err != nil {
+		return nil, fmt.Errorf("error getting PodDisruptionBudgets: %v", err)
+	}
+	if isNoResourcesResponse(raw) {
+		return &PodDisruptionBudgetList{}, nil
+	}
+	var pdbs PodDisruptionBudgetList
+	if err := json.Unmarshal([]byte(raw), &pdbs); err != nil {
+		return nil, fmt.Errorf("error unmarshalling PodDisruptionBudgets: %v", err)
+	}
+	return &pdbs, nil
+}
+
 // GetDaemonSet returns the DaemonSet with the given namespace and name. If not found,
 // returns an error.
 func (k RemoteKubectl) GetDaemonSet(namespace, name string) (*DaemonSet, error) {
@@ -6,14 +6,38 @@ type PodList struct {
 
 type Pod struct {
 	ObjectMeta `json:"metadata,omitempty"`
-	Spec       PodSpec `json:"spec,omitempty"`
+	Spec       PodSpec   `json:"spec,omitempty"`
+	Status     PodStatus `json:"status,omitempty"`
 }
 
 type ObjectMeta struct {
-	Annotations map[string]string `json:"annotations,omitempty"`
-	Name        string            `json:"name,omitempty"`
-	Namespace   string            `json:"namespace,omitempty"`
-	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+	Name            string            `json:"name,omitempty"`
+	Namespace       string            `json:"namespace,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	OwnerReferences []OwnerReference  `json:"ownerReferences,omitempty"`
+}
+
+// OwnerReference contains enough information to let you identify an owning
+// object. The owning object is in the same namespace as the dependent.
+type OwnerReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+	// Controller is true if the owner is the managing controller
+	Controller *bool `json:"controller,omitempty"`
+}
+
+// ControllerRef returns the owner reference that points to the managing
+// controller of the object, or nil if the object is not managed by a controller
+func (m ObjectMeta) ControllerRef() *OwnerReference {
+	for i, r := range m.OwnerReferences {
+		if r.Controller != nil && *r.Controller {
+			return &m.OwnerReferences[i]
+		}
+	}
+	return nil
 }
 
 // ObjectReference contains enough information to let you inspect or modify the referred object.
@@ -35,6 +59,22 @@ type PodSpec struct {
 	Containers []Container `json:"containers"`
 }
 
+type PodPhase string
+
+const (
+	// PodSucceeded means that all containers in the pod have voluntarily terminated
+	// with a container exit code of 0, and the system is not going to restart any of these containers.
+	PodSucceeded PodPhase = "Succeeded"
+	// PodFailed means that all containers in the pod have terminated, and at least one container has
+	// terminated in a failure (exited with a non-zero exit code or was stopped by the system).
+	PodFailed PodPhase = "Failed"
+)
+
+// PodStatus represents information about the status of a pod.
+type PodStatus struct {
+	Phase PodPhase `json:"phase,omitempty"`
+}
+
 // Volume represents a named volume in a pod that may be accessed by any container in the pod.
 type Volume struct {
 	Name         string `json:"name"`
@@ -234,3 +274,55 @@ type StatefulSetStatus struct {
 	// Replicas is the number of actual replicas.
 	Replicas int32
 }
+
+// PodDisruptionBudgetList is a collection of PodDisruptionBudgets.
+type PodDisruptionBudgetList struct {
+	TypeMeta `json:",inline"`
+	ListMeta `json:"metadata,omitempty"`
+	Items    []PodDisruptionBudget `json:"items"`
+}
+
+// PodDisruptionBudget is an object to define the max disruption that can be
+// caused to a collection of pods
+type PodDisruptionBudget struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata,omitempty"`
+	Spec       PodDisruptionBudgetSpec   `json:"spec,omitempty"`
+	Status     PodDisruptionBudgetStatus `json:"status,omitempty"`
+}
+
+// PodDisruptionBudgetSpec is a description of a PodDisruptionBudget.
+type PodDisruptionBudgetSpec struct {
+	// Selector is the label query over the pods whose evictions are managed by
+	// the disruption budget.
+	Selector *LabelSelector `json:"selector,omitempty"`
+}
+
+// PodDisruptionBudgetStatus represents information about the status of a
+// PodDisruptionBudget. Status may trail the actual state of a system.
+type PodDisruptionBudgetStatus struct {
+	// PodDisruptionsAllowed is the number of pod disruptions that are currently allowed.
+	PodDisruptionsAllowed int32 `json:"disruptionsAllowed"`
+	// CurrentHealthy is the current number of healthy pods
+	CurrentHealthy int32 `json:"currentHealthy"`
+	// DesiredHealthy is the minimum desired number of healthy pods
+	DesiredHealthy int32 `json:"desiredHealthy"`
+	// ExpectedPods is the total number of pods counted by this disruption budget
+	ExpectedPods int32 `json:"expectedPods"`
+}
+
+// LabelSelector is a label query over a set of resources. The result of
+// matchLabels and matchExpressions are ANDed.
+type LabelSelector struct {
+	MatchLabels      map[string]string          `json:"matchLabels,omitempty"`
+	MatchExpressions []LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+// LabelSelectorRequirement is a selector that contains values, a key, and an
+// operator that relates the key and values.
+type LabelSelectorRequirement struct {
+	Key string `json:"key"`
+	// Operator is one of In, NotIn, Exists and DoesNotExist.
+	Operator string   `json:"operator"`
+	Values   []string `json:"values,omitempty"`
+}
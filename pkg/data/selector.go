@@ -0,0 +1,55 @@
+package data
+
+import "github.com/apprenda/kismatic/pkg/util"
+
+// Matches returns true if the labels satisfy all the requirements of the
+// selector. An empty selector matches all labels.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for k, v := range s.MatchLabels {
+		if lv, ok := labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+	for _, r := range s.MatchExpressions {
+		if !r.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty returns true if the selector has no requirements
+func (s LabelSelector) Empty() bool {
+	return len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0
+}
+
+func (r LabelSelectorRequirement) matches(labels map[string]string) bool {
+	v, ok := labels[r.Key]
+	switch r.Operator {
+	case "In":
+		return ok && util.Contains(v, r.Values)
+	case "NotIn":
+		return !ok || !util.Contains(v, r.Values)
+	case "Exists":
+		return ok
+	case "DoesNotExist":
+		return !ok
+	default:
+		// Unknown operators don't match anything, in the same way the API server
+		// would reject them
+		return false
+	}
+}
+
+// Selects returns true if the pod's evictions are managed by the disruption
+// budget. Like the eviction API, a budget with an empty selector does not
+// select any pods.
+func (pdb PodDisruptionBudget) Selects(pod Pod) bool {
+	if pdb.Namespace != pod.Namespace {
+		return false
+	}
+	if pdb.Spec.Selector == nil || pdb.Spec.Selector.Empty() {
+		return false
+	}
+	return pdb.Spec.Selector.Matches(pod.Labels)
+}
@@ -0,0 +1,92 @@
+package data
+
+import "testing"
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"app": "web", "tier": "frontend"}
+	tests := []struct {
+		selector LabelSelector
+		matches  bool
+	}{
+		{
+			selector: LabelSelector{},
+			matches:  true,
+		},
+		{
+			selector: LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			matches:  true,
+		},
+		{
+			selector: LabelSelector{MatchLabels: map[string]string{"app": "web", "tier": "backend"}},
+			matches:  false,
+		},
+		{
+			selector: LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "tier", Operator: "In", Values: []string{"frontend", "backend"}}}},
+			matches:  true,
+		},
+		{
+			selector: LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "tier", Operator: "NotIn", Values: []string{"frontend"}}}},
+			matches:  false,
+		},
+		{
+			selector: LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "env", Operator: "NotIn", Values: []string{"prod"}}}},
+			matches:  true,
+		},
+		{
+			selector: LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "app", Operator: "Exists"}}},
+			matches:  true,
+		},
+		{
+			selector: LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "app", Operator: "DoesNotExist"}}},
+			matches:  false,
+		},
+		{
+			selector: LabelSelector{MatchExpressions: []LabelSelectorRequirement{{Key: "app", Operator: "Unknown"}}},
+			matches:  false,
+		},
+	}
+	for i, test := range tests {
+		if m := test.selector.Matches(labels); m != test.matches {
+			t.Errorf("test %d: expected matches = %v, but got %v", i, test.matches, m)
+		}
+	}
+}
+
+func TestPodDisruptionBudgetSelects(t *testing.T) {
+	pod := Pod{ObjectMeta: ObjectMeta{Namespace: "default", Labels: map[string]string{"app": "web"}}}
+	tests := []struct {
+		pdb     PodDisruptionBudget
+		selects bool
+	}{
+		{
+			pdb: PodDisruptionBudget{
+				ObjectMeta: ObjectMeta{Namespace: "default"},
+				Spec:       PodDisruptionBudgetSpec{Selector: &LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+			},
+			selects: true,
+		},
+		{
+			pdb: PodDisruptionBudget{
+				ObjectMeta: ObjectMeta{Namespace: "other"},
+				Spec:       PodDisruptionBudgetSpec{Selector: &LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+			},
+			selects: false,
+		},
+		{
+			pdb: PodDisruptionBudget{
+				ObjectMeta: ObjectMeta{Namespace: "default"},
+				Spec:       PodDisruptionBudgetSpec{Selector: &LabelSelector{}},
+			},
+			selects: false,
+		},
+		{
+			pdb:     PodDisruptionBudget{ObjectMeta: ObjectMeta{Namespace: "default"}},
+			selects: false,
+		},
+	}
+	for i, test := range tests {
+		if s := test.pdb.Selects(pod); s != test.selects {
+			t.Errorf("test %d: expected selects = %v, but got %v", i, test.selects, s)
+		}
+	}
+}
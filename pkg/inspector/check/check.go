@@ -13,3 +13,11 @@ type ClosableCheck interface {
 	Check
 	Close() error
 }
+
+// An ExclusiveCheck uses a resource of the node that can only be used by one
+// process at a time, such as the package manager. Exclusive checks must not
+// run at the same time as each other.
+type ExclusiveCheck interface {
+	Check
+	IsExclusive() bool
+}
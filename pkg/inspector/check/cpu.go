@@ -0,0 +1,14 @@
+package check
+
+import "runtime"
+
+// CPUCheck checks the number of CPU cores that are available on the node
+type CPUCheck struct {
+	MinimumCores int
+}
+
+// Check returns true if the node has at least the minimum number of cores.
+// Otherwise returns false.
+func (c CPUCheck) Check() (bool, error) {
+	return runtime.NumCPU() >= c.MinimumCores, nil
+}
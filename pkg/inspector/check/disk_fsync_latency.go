@@ -0,0 +1,88 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// etcd's guidance is to measure writes of 2300 bytes, which is close
+	// to the size of the entries that etcd writes to its log
+	fsyncWriteSize = 2300
+	fsyncWrites    = 200
+)
+
+// DiskFsyncLatencyCheck measures the latency of writes that are followed by
+// an fsync on the disk that backs the path. If the path does not exist yet,
+// the closest parent directory that exists is used.
+type DiskFsyncLatencyCheck struct {
+	Path           string
+	MaximumLatency time.Duration
+	// Writes is the number of writes to measure. Defaults to 200
+	Writes int
+}
+
+// Check returns true if the 99th percentile of the write latency is below the
+// maximum latency. Otherwise returns false.
+func (c DiskFsyncLatencyCheck) Check() (bool, error) {
+	dir, err := closestExistingDir(c.Path)
+	if err != nil {
+		return false, err
+	}
+	f, err := ioutil.TempFile(dir, "kismatic-inspector-fsync")
+	if err != nil {
+		return false, fmt.Errorf("failed to create file in %q: %v", dir, err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	writes := c.Writes
+	if writes <= 0 {
+		writes = fsyncWrites
+	}
+	data := make([]byte, fsyncWriteSize)
+	latencies := make([]time.Duration, writes)
+	for i := range latencies {
+		start := time.Now()
+		if _, err := f.Write(data); err != nil {
+			return false, fmt.Errorf("failed to write to %q: %v", f.Name(), err)
+		}
+		if err := f.Sync(); err != nil {
+			return false, fmt.Errorf("failed to sync %q: %v", f.Name(), err)
+		}
+		latencies[i] = time.Since(start)
+	}
+	return percentile(latencies, 99) < c.MaximumLatency, nil
+}
+
+// returns the given percentile of the durations
+func percentile(durations []time.Duration, p int) time.Duration {
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := (len(sorted)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func closestExistingDir(path string) (string, error) {
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		fi, err := os.Stat(dir)
+		if err == nil {
+			if !fi.IsDir() {
+				return "", fmt.Errorf("%q is not a directory", dir)
+			}
+			return dir, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to check %q: %v", dir, err)
+		}
+		if dir == filepath.Dir(dir) {
+			return "", fmt.Errorf("no directory of %q exists", path)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskFsyncLatencyCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fsync-check")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	// the data directory does not exist yet, the parent is used
+	c := DiskFsyncLatencyCheck{Path: filepath.Join(dir, "etcd"), MaximumLatency: time.Hour, Writes: 10}
+	if ok, err := c.Check(); !ok || err != nil {
+		t.Errorf("expected fsync latency to be below an hour, but got %v, %v", ok, err)
+	}
+	c.MaximumLatency = time.Nanosecond
+	if ok, err := c.Check(); ok || err != nil {
+		t.Errorf("expected fsync latency not to be below a nanosecond, but got %v, %v", ok, err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("error reading dir: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected the check to clean up after itself, but found %d files", len(files))
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i > 0; i-- {
+		durations = append(durations, time.Duration(i))
+	}
+	if p := percentile(durations, 99); p != 99 {
+		t.Errorf("expected the 99th percentile to be 99, but got %d", p)
+	}
+	if p := percentile(durations[:1], 99); p != 100 {
+		t.Errorf("expected the 99th percentile of a single sample to be the sample, but got %d", p)
+	}
+}
@@ -12,15 +12,54 @@ import (
 
 const (
 	Ubuntu      Distro = "ubuntu"
+	Debian      Distro = "debian"
 	RHEL        Distro = "rhel"
 	CentOS      Distro = "centos"
+	OracleLinux Distro = "ol"
 	Darwin      Distro = "darwin"
 	Unsupported Distro = ""
 )
 
+const (
+	// RHELFamily contains RHEL, and the distributions that are built from it
+	RHELFamily Family = "rhel-family"
+	// DebianFamily contains Debian, and the distributions that are based on it
+	DebianFamily Family = "debian-family"
+)
+
 // Distro is a Linux distribution that the inspector supports
 type Distro string
 
+// Family is a group of distributions that share the package manager and
+// the names of the packages
+type Family string
+
+// Family returns the family of the distribution, or the empty string if it
+// does not belong to one
+func (d Distro) Family() Family {
+	switch d {
+	case RHEL, CentOS, OracleLinux:
+		return RHELFamily
+	case Ubuntu, Debian:
+		return DebianFamily
+	default:
+		return ""
+	}
+}
+
+// Facts returns the facts that apply to nodes that run the distribution,
+// which are the distribution and its family
+func (d Distro) Facts() []string {
+	if d == Unsupported {
+		return nil
+	}
+	facts := []string{string(d)}
+	if f := d.Family(); f != "" {
+		facts = append(facts, string(f))
+	}
+	return facts
+}
+
 // DetectDistro uses the /etc/os-release file to get distro information.
 func DetectDistro() (Distro, error) {
 	if runtime.GOOS == "darwin" {
@@ -34,33 +73,44 @@ func DetectDistro() (Distro, error) {
 	return detectDistroFromOSRelease(f)
 }
 
+// detectDistroFromOSRelease returns the distribution identified by the ID
+// field. If the ID is unknown, the distribution is identified by the first
+// known ID in the ID_LIKE field. For example, a RHEL rebuild is treated as RHEL.
 func detectDistroFromOSRelease(r io.Reader) (Distro, error) {
-	idLine := ""
+	fields := map[string]string{}
 	s := bufio.NewScanner(r)
 	for s.Scan() {
-		l := s.Text()
-		if strings.HasPrefix(l, "ID=") {
-			idLine = l
-			break
+		l := strings.TrimSpace(s.Text())
+		if l == "" || strings.HasPrefix(l, "#") {
+			continue
 		}
+		kv := strings.SplitN(l, "=", 2)
+		if len(kv) != 2 {
+			return Unsupported, fmt.Errorf("Unknown format of /etc/os-release file. Line was: %s", l)
+		}
+		// Remove quotes from field value
+		fields[kv[0]] = strings.Trim(kv[1], "\"'")
 	}
-	if idLine == "" {
+	id, ok := fields["ID"]
+	if !ok {
 		return Unsupported, errors.New("/etc/os-release file does not contain ID= field")
 	}
-	fields := strings.Split(idLine, "=")
-	if len(fields) != 2 {
-		return Unsupported, fmt.Errorf("Unknown format of /etc/os-release file. ID line was: %s", idLine)
+	if d, ok := knownDistro(id); ok {
+		return d, nil
+	}
+	for _, like := range strings.Fields(fields["ID_LIKE"]) {
+		if d, ok := knownDistro(like); ok {
+			return d, nil
+		}
 	}
+	return Unsupported, fmt.Errorf("Unsupported distribution detected: %s", id)
+}
 
-	// Remove double-quotes from field value
-	switch strings.Replace(fields[1], "\"", "", -1) {
-	case "centos":
-		return CentOS, nil
-	case "rhel":
-		return RHEL, nil
-	case "ubuntu":
-		return Ubuntu, nil
+func knownDistro(id string) (Distro, bool) {
+	switch Distro(id) {
+	case Ubuntu, Debian, RHEL, CentOS, OracleLinux:
+		return Distro(id), true
 	default:
-		return Unsupported, fmt.Errorf("Unsupported distribution detected: %s", fields[1])
+		return Unsupported, false
 	}
 }
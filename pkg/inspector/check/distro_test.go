@@ -1,10 +1,31 @@
 package check
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
 
+func TestDistroFacts(t *testing.T) {
+	tests := []struct {
+		distro   Distro
+		expected []string
+	}{
+		{distro: Ubuntu, expected: []string{"ubuntu", "debian-family"}},
+		{distro: Debian, expected: []string{"debian", "debian-family"}},
+		{distro: CentOS, expected: []string{"centos", "rhel-family"}},
+		{distro: RHEL, expected: []string{"rhel", "rhel-family"}},
+		{distro: OracleLinux, expected: []string{"ol", "rhel-family"}},
+		{distro: Darwin, expected: []string{"darwin"}},
+		{distro: Unsupported, expected: nil},
+	}
+	for _, test := range tests {
+		if facts := test.distro.Facts(); !reflect.DeepEqual(facts, test.expected) {
+			t.Errorf("expected facts %v for %q, but got %v", test.expected, test.distro, facts)
+		}
+	}
+}
+
 func TestDetectDistroFromOSRelease(t *testing.T) {
 	tests := []struct {
 		osReleaseFile  string
@@ -26,6 +47,31 @@ func TestDetectDistroFromOSRelease(t *testing.T) {
 			expectedDistro: Ubuntu,
 			expectErr:      false,
 		},
+		{
+			osReleaseFile:  debian9ReleaseFile,
+			expectedDistro: Debian,
+			expectErr:      false,
+		},
+		{
+			osReleaseFile:  oracleLinux7ReleaseFile,
+			expectedDistro: OracleLinux,
+			expectErr:      false,
+		},
+		{
+			osReleaseFile:  rocky8ReleaseFile,
+			expectedDistro: RHEL,
+			expectErr:      false,
+		},
+		{
+			osReleaseFile:  linuxMint18ReleaseFile,
+			expectedDistro: Ubuntu,
+			expectErr:      false,
+		},
+		{
+			osReleaseFile:  archReleaseFile,
+			expectedDistro: Unsupported,
+			expectErr:      true,
+		},
 		{
 			osReleaseFile:  "",
 			expectedDistro: Unsupported,
@@ -106,3 +152,53 @@ HOME_URL="http://www.ubuntu.com/"
 SUPPORT_URL="http://help.ubuntu.com/"
 BUG_REPORT_URL="http://bugs.launchpad.net/ubuntu/"
 UBUNTU_CODENAME=xenial`
+
+var debian9ReleaseFile = `PRETTY_NAME="Debian GNU/Linux 9 (stretch)"
+NAME="Debian GNU/Linux"
+VERSION_ID="9"
+VERSION="9 (stretch)"
+ID=debian
+HOME_URL="https://www.debian.org/"
+SUPPORT_URL="https://www.debian.org/support"
+BUG_REPORT_URL="https://bugs.debian.org/"`
+
+var oracleLinux7ReleaseFile = `NAME="Oracle Linux Server"
+VERSION="7.4"
+ID="ol"
+VERSION_ID="7.4"
+PRETTY_NAME="Oracle Linux Server 7.4"
+ANSI_COLOR="0;31"
+CPE_NAME="cpe:/o:oracle:linux:7:4:server"
+HOME_URL="https://linux.oracle.com/"
+BUG_REPORT_URL="https://bugzilla.oracle.com/"`
+
+var rocky8ReleaseFile = `NAME="Rocky Linux"
+VERSION="8.4 (Green Obsidian)"
+ID="rocky"
+ID_LIKE="rhel centos fedora"
+VERSION_ID="8.4"
+PLATFORM_ID="platform:el8"
+PRETTY_NAME="Rocky Linux 8.4 (Green Obsidian)"
+ANSI_COLOR="0;32"
+CPE_NAME="cpe:/o:rocky:rocky:8.4:GA"
+HOME_URL="https://rockylinux.org/"
+BUG_REPORT_URL="https://bugs.rockylinux.org/"`
+
+var linuxMint18ReleaseFile = `NAME="Linux Mint"
+VERSION="18.3 (Sylvia)"
+ID=linuxmint
+ID_LIKE="ubuntu debian"
+PRETTY_NAME="Linux Mint 18.3"
+VERSION_ID="18.3"
+HOME_URL="http://www.linuxmint.com/"
+SUPPORT_URL="http://forums.linuxmint.com/"
+BUG_REPORT_URL="http://bugs.launchpad.net/linuxmint/"
+VERSION_CODENAME=sylvia
+UBUNTU_CODENAME=xenial`
+
+var archReleaseFile = `NAME="Arch Linux"
+PRETTY_NAME="Arch Linux"
+ID=arch
+ID_LIKE=archlinux
+ANSI_COLOR="0;36"
+HOME_URL="https://www.archlinux.org/"`
@@ -0,0 +1,145 @@
+package check
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPEndpointCheck verifies that an HTTP endpoint can be reached from the
+// node, and that it responds with the expected status code
+type HTTPEndpointCheck struct {
+	// URL of the endpoint
+	URL string
+	// HTTPProxy is the proxy used for reaching http endpoints
+	HTTPProxy string
+	// HTTPSProxy is the proxy used for reaching https endpoints
+	HTTPSProxy string
+	// NoProxy is a comma-separated list of hosts, domains and CIDR blocks
+	// that are reached without going through the proxy
+	NoProxy string
+	// CACert is the PEM encoded CA certificate that is trusted when verifying
+	// the endpoint's certificate. The system's CAs are trusted if empty.
+	CACert string
+	// ExpectedStatusCodes are the status codes the endpoint may respond with.
+	// Any 2xx status code is accepted if empty.
+	ExpectedStatusCodes []int
+	// Timeout is the maximum amount of time the check will wait for the
+	// endpoint to respond
+	Timeout time.Duration
+}
+
+// Check returns true if the endpoint responds with the expected status code
+func (c HTTPEndpointCheck) Check() (bool, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return false, fmt.Errorf("invalid URL %q: %v", c.URL, err)
+	}
+	tlsConfig := &tls.Config{}
+	if c.CACert != "" {
+		pool := x509.NewCertPool()
+		if ok := pool.AppendCertsFromPEM([]byte(c.CACert)); !ok {
+			return false, errors.New("no certificates were found in the CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	proxy, err := c.proxyURL(u)
+	if err != nil {
+		return false, err
+	}
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyURL(proxy),
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	resp, err := client.Get(c.URL)
+	if err != nil {
+		return false, fmt.Errorf("%s is unreachable. Error was: %v", c.URL, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if len(c.ExpectedStatusCodes) == 0 {
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return true, nil
+		}
+		return false, fmt.Errorf("%s responded with status %q, but a 2xx status was expected", c.URL, resp.Status)
+	}
+	for _, code := range c.ExpectedStatusCodes {
+		if resp.StatusCode == code {
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("%s responded with status %q, but one of %s was expected", c.URL, resp.Status, StatusCodes(c.ExpectedStatusCodes))
+}
+
+// StatusCodes returns the status codes as a comma-separated list
+func StatusCodes(codes []int) string {
+	s := make([]string, len(codes))
+	for i, c := range codes {
+		s[i] = strconv.Itoa(c)
+	}
+	return strings.Join(s, ", ")
+}
+
+// proxyURL returns the proxy to use for reaching the endpoint, or nil if the
+// endpoint is to be reached directly
+func (c HTTPEndpointCheck) proxyURL(u *url.URL) (*url.URL, error) {
+	proxy := c.HTTPProxy
+	if u.Scheme == "https" {
+		proxy = c.HTTPSProxy
+	}
+	if proxy == "" || bypassProxy(u.Hostname(), c.NoProxy) {
+		return nil, nil
+	}
+	p, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %v", proxy, err)
+	}
+	return p, nil
+}
+
+// bypassProxy returns true if the host matches one of the hosts, domains or
+// CIDR blocks in the comma-separated noProxy list
+func bypassProxy(host string, noProxy string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		// Strip the port, if any, as the proxy is bypassed for all ports
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		domain := strings.TrimPrefix(entry, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
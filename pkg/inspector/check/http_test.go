@@ -0,0 +1,90 @@
+package check
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPEndpointCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	tests := []struct {
+		path                string
+		expectedStatusCodes []int
+		ok                  bool
+	}{
+		{path: "/", ok: true},
+		{path: "/", expectedStatusCodes: []int{200}, ok: true},
+		{path: "/v2/", ok: false},
+		{path: "/v2/", expectedStatusCodes: []int{401}, ok: true},
+		{path: "/", expectedStatusCodes: []int{401}, ok: false},
+		{path: "/", expectedStatusCodes: []int{200, 401}, ok: true},
+		{path: "/v2/", expectedStatusCodes: []int{200, 401}, ok: true},
+	}
+	for _, test := range tests {
+		c := HTTPEndpointCheck{URL: server.URL + test.path, ExpectedStatusCodes: test.expectedStatusCodes}
+		ok, err := c.Check()
+		if ok != test.ok {
+			t.Errorf("%s with expected status %v: expected %v, but got %v. Error: %v", test.path, test.expectedStatusCodes, test.ok, ok, err)
+		}
+	}
+}
+
+func TestHTTPEndpointCheckVerifiesTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	c := HTTPEndpointCheck{URL: server.URL}
+	if ok, _ := c.Check(); ok {
+		t.Errorf("expected the check to fail when the server's CA is not trusted")
+	}
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.TLS.Certificates[0].Certificate[0]})
+	c.CACert = string(ca)
+	if ok, err := c.Check(); !ok {
+		t.Errorf("expected the check to succeed when the server's CA is trusted, but got error: %v", err)
+	}
+}
+
+func TestHTTPEndpointCheckUsesProxy(t *testing.T) {
+	proxied := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = true
+	}))
+	defer proxy.Close()
+
+	c := HTTPEndpointCheck{URL: "http://registry.example.com/v2/", HTTPProxy: proxy.URL}
+	if ok, err := c.Check(); !ok {
+		t.Fatalf("expected the check to succeed through the proxy, but got error: %v", err)
+	}
+	if !proxied {
+		t.Errorf("the request did not go through the proxy")
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	noProxy := "localhost, .example.com,10.0.0.0/8,registry.local:5000"
+	tests := []struct {
+		host   string
+		bypass bool
+	}{
+		{"localhost", true},
+		{"example.com", true},
+		{"registry.example.com", true},
+		{"registry.example.org", false},
+		{"10.1.2.3", true},
+		{"192.168.1.1", false},
+		{"registry.local", true},
+		{"notexample.com", false},
+	}
+	for _, test := range tests {
+		if bypass := bypassProxy(test.host, noProxy); bypass != test.bypass {
+			t.Errorf("%s: expected bypass = %v, but got %v", test.host, test.bypass, bypass)
+		}
+	}
+}
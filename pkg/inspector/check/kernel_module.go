@@ -0,0 +1,33 @@
+package check
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KernelModuleCheck checks whether a kernel module is loaded, or built into
+// the kernel. Both loaded and built-in modules are listed in /sys/module.
+type KernelModuleCheck struct {
+	Module string
+	// SysModuleDir is the directory that lists the modules. Defaults to /sys/module
+	SysModuleDir string
+}
+
+// Check returns true if the kernel module is loaded. Otherwise returns false.
+func (c KernelModuleCheck) Check() (bool, error) {
+	dir := c.SysModuleDir
+	if dir == "" {
+		dir = "/sys/module"
+	}
+	// the kernel uses underscores in module names, but modprobe accepts dashes as well
+	name := strings.Replace(c.Module, "-", "_", -1)
+	if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check if kernel module %q is loaded: %v", c.Module, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,37 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKernelModuleCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kernel-module-check")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err = os.Mkdir(filepath.Join(dir, "br_netfilter"), 0755); err != nil {
+		t.Fatalf("error creating module dir: %v", err)
+	}
+	tests := []struct {
+		module   string
+		expected bool
+	}{
+		{module: "br_netfilter", expected: true},
+		{module: "br-netfilter", expected: true},
+		{module: "overlay", expected: false},
+	}
+	for _, test := range tests {
+		c := KernelModuleCheck{Module: test.module, SysModuleDir: dir}
+		ok, err := c.Check()
+		if err != nil {
+			t.Errorf("unexpected error checking module %q: %v", test.module, err)
+		}
+		if ok != test.expected {
+			t.Errorf("expected %v for module %q, but got %v", test.expected, test.module, ok)
+		}
+	}
+}
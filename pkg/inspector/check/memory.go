@@ -0,0 +1,47 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MemoryCheck checks the total amount of memory of the node
+type MemoryCheck struct {
+	MinimumBytes uint64
+	// MeminfoFile contains the memory information. Defaults to /proc/meminfo
+	MeminfoFile string
+}
+
+// Check returns true if the node has at least the minimum amount of memory.
+// Otherwise returns false.
+func (c MemoryCheck) Check() (bool, error) {
+	file := c.MeminfoFile
+	if file == "" {
+		file = "/proc/meminfo"
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read memory information: %v", err)
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// MemTotal:        8061604 kB
+		fields := strings.Fields(s.Text())
+		if len(fields) != 3 || fields[0] != "MemTotal:" || fields[2] != "kB" {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid total memory %q: %v", fields[1], err)
+		}
+		return kb*1024 >= c.MinimumBytes, nil
+	}
+	if err := s.Err(); err != nil {
+		return false, fmt.Errorf("failed to read memory information: %v", err)
+	}
+	return false, fmt.Errorf("total memory was not found in %q", file)
+}
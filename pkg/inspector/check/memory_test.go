@@ -0,0 +1,42 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const meminfo = `MemTotal:        2046944 kB
+MemFree:          123456 kB
+MemAvailable:    1500000 kB
+`
+
+func TestMemoryCheck(t *testing.T) {
+	f, err := ioutil.TempFile("", "memory-check")
+	if err != nil {
+		t.Fatalf("error creating temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(meminfo)
+	f.Close()
+
+	c := MemoryCheck{MinimumBytes: 1500000000, MeminfoFile: f.Name()}
+	if ok, err := c.Check(); !ok || err != nil {
+		t.Errorf("expected 2046944 kB to be enough, but got %v, %v", ok, err)
+	}
+	c.MinimumBytes = 4000000000
+	if ok, err := c.Check(); ok || err != nil {
+		t.Errorf("expected 2046944 kB not to be enough, but got %v, %v", ok, err)
+	}
+}
+
+func TestCPUCheck(t *testing.T) {
+	c := CPUCheck{MinimumCores: 1}
+	if ok, _ := c.Check(); !ok {
+		t.Errorf("check returned false for a single core")
+	}
+	c.MinimumCores = 100000
+	if ok, _ := c.Check(); ok {
+		t.Errorf("check returned true for a ludicrous number of cores")
+	}
+}
@@ -22,6 +22,9 @@ type PackageCheck struct {
 	InstallationDisabled bool
 }
 
+// IsExclusive returns true, as the package manager holds a lock while it is queried
+func (c PackageCheck) IsExclusive() bool { return true }
+
 // Check returns true if the package is installed. If pkg installation is disabled,
 // we would like to check if the package is available for install. However,
 // there is no guarantee that the node will have the kismatic package repo configured.
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"sync"
 )
 
 // PackageManager runs queries against the underlying operating system's
@@ -15,23 +16,31 @@ type PackageManager interface {
 	IsInstalled(PackageQuery) (bool, error)
 }
 
+// packageManagerLock serializes the queries to the package manager, as yum and
+// apt hold a lock that is global to the node while they run. This also applies
+// to queries that keep running after their check has timed out.
+var packageManagerLock sync.Mutex
+
 // NewPackageManager returns a package manager for the given distribution
 func NewPackageManager(distro Distro) (PackageManager, error) {
 	run := func(name string, arg ...string) ([]byte, error) {
+		packageManagerLock.Lock()
+		defer packageManagerLock.Unlock()
 		r, err := exec.Command(name, arg...).CombinedOutput()
 		return r, err
 	}
-	switch distro {
-	case RHEL, CentOS:
+	if distro == Darwin {
+		return noopManager{}, nil
+	}
+	switch distro.Family() {
+	case RHELFamily:
 		return &rpmManager{
 			run: run,
 		}, nil
-	case Ubuntu:
+	case DebianFamily:
 		return &debManager{
 			run: run,
 		}, nil
-	case Darwin:
-		return noopManager{}, nil
 	default:
 		return nil, fmt.Errorf("%s is not supported", distro)
 	}
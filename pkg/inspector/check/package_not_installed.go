@@ -9,6 +9,9 @@ type PackageNotInstalledCheck struct {
 	InstallationDisabled     bool
 }
 
+// IsExclusive returns true, as the package manager holds a lock while it is queried
+func (c PackageNotInstalledCheck) IsExclusive() bool { return true }
+
 // Check returns true if the specified package is not installed.
 // This will also return true if the version installed matches AcceptablePackageVersion.
 // When InstallationDisabled is true this check will always return true.
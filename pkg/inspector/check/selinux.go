@@ -0,0 +1,53 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SELinuxCheck checks that SELinux is in one of the acceptable modes.
+// The mode is "disabled" when SELinux is not enabled in the kernel.
+type SELinuxCheck struct {
+	AcceptableModes []string
+	// SELinuxFSDir is the mount point of the SELinux filesystem. Defaults to /sys/fs/selinux
+	SELinuxFSDir string
+}
+
+// Check returns true if SELinux is in one of the acceptable modes. Otherwise returns false.
+func (c SELinuxCheck) Check() (bool, error) {
+	mode, err := c.mode()
+	if err != nil {
+		return false, err
+	}
+	for _, m := range c.AcceptableModes {
+		if m == mode {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c SELinuxCheck) mode() (string, error) {
+	dir := c.SELinuxFSDir
+	if dir == "" {
+		dir = "/sys/fs/selinux"
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "enforce"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "disabled", nil
+		}
+		return "", fmt.Errorf("failed to read the SELinux mode: %v", err)
+	}
+	switch strings.TrimSpace(string(b)) {
+	case "1":
+		return "enforcing", nil
+	case "0":
+		return "permissive", nil
+	default:
+		return "", fmt.Errorf("unknown SELinux enforce value %q", strings.TrimSpace(string(b)))
+	}
+}
@@ -0,0 +1,38 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSELinuxCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "selinux-check")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	acceptable := []string{"permissive", "disabled"}
+
+	// SELinux is not enabled in the kernel
+	c := SELinuxCheck{AcceptableModes: acceptable, SELinuxFSDir: filepath.Join(dir, "doesnt-exist")}
+	if ok, err := c.Check(); !ok || err != nil {
+		t.Errorf("expected disabled SELinux to be acceptable, but got %v, %v", ok, err)
+	}
+
+	c.SELinuxFSDir = dir
+	if err = ioutil.WriteFile(filepath.Join(dir, "enforce"), []byte("0"), 0644); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	if ok, err := c.Check(); !ok || err != nil {
+		t.Errorf("expected permissive SELinux to be acceptable, but got %v, %v", ok, err)
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(dir, "enforce"), []byte("1"), 0644); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	if ok, err := c.Check(); ok || err != nil {
+		t.Errorf("expected enforcing SELinux not to be acceptable, but got %v, %v", ok, err)
+	}
+}
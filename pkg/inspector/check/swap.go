@@ -0,0 +1,28 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// SwapCheck checks that there is no swap enabled on the node
+type SwapCheck struct {
+	// SwapsFile lists the swap areas in use. Defaults to /proc/swaps
+	SwapsFile string
+}
+
+// Check returns true if there are no swap areas in use. Otherwise returns false.
+func (c SwapCheck) Check() (bool, error) {
+	file := c.SwapsFile
+	if file == "" {
+		file = "/proc/swaps"
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %q: %v", file, err)
+	}
+	// The first line is the header
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	return len(lines) <= 1, nil
+}
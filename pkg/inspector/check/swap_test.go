@@ -0,0 +1,40 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSwapCheck(t *testing.T) {
+	tests := []struct {
+		swaps    string
+		expected bool
+	}{
+		{
+			swaps:    "Filename\t\t\t\tType\t\tSize\tUsed\tPriority\n",
+			expected: true,
+		},
+		{
+			swaps:    "Filename\t\t\t\tType\t\tSize\tUsed\tPriority\n/dev/dm-1                               partition\t8269820\t0\t-1\n",
+			expected: false,
+		},
+	}
+	for i, test := range tests {
+		f, err := ioutil.TempFile("", "swap-check")
+		if err != nil {
+			t.Fatalf("error creating temp file: %v", err)
+		}
+		defer os.Remove(f.Name())
+		f.WriteString(test.swaps)
+		f.Close()
+		c := SwapCheck{SwapsFile: f.Name()}
+		ok, err := c.Check()
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if ok != test.expected {
+			t.Errorf("test %d: expected %v, but got %v", i, test.expected, ok)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SysctlCheck checks the value of a kernel parameter
+type SysctlCheck struct {
+	// Parameter is the name of the kernel parameter, such as net.ipv4.ip_forward
+	Parameter string
+	Value     string
+	// ProcSysDir is the directory that contains the kernel parameters. Defaults to /proc/sys
+	ProcSysDir string
+}
+
+// Check returns true if the kernel parameter is set to the value. Otherwise
+// returns false. Values that contain multiple fields, such as
+// net.ipv4.ip_local_port_range, are compared field by field.
+func (c SysctlCheck) Check() (bool, error) {
+	dir := c.ProcSysDir
+	if dir == "" {
+		dir = "/proc/sys"
+	}
+	file := filepath.Join(dir, strings.Replace(c.Parameter, ".", "/", -1))
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, fmt.Errorf("kernel parameter %q does not exist", c.Parameter)
+		}
+		return false, fmt.Errorf("failed to read kernel parameter %q: %v", c.Parameter, err)
+	}
+	actual := strings.Join(strings.Fields(string(b)), " ")
+	expected := strings.Join(strings.Fields(c.Value), " ")
+	return actual == expected, nil
+}
@@ -0,0 +1,47 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSysctlCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sysctl-check")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	ipv4 := filepath.Join(dir, "net", "ipv4")
+	if err = os.MkdirAll(ipv4, 0755); err != nil {
+		t.Fatalf("error creating dir: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(ipv4, "ip_forward"), []byte("0\n"), 0644); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(ipv4, "ip_local_port_range"), []byte("32768\t60999\n"), 0644); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	tests := []struct {
+		parameter   string
+		value       string
+		expected    bool
+		expectedErr bool
+	}{
+		{parameter: "net.ipv4.ip_forward", value: "1", expected: false},
+		{parameter: "net.ipv4.ip_forward", value: "0", expected: true},
+		{parameter: "net.ipv4.ip_local_port_range", value: "32768 60999", expected: true},
+		{parameter: "net.bridge.bridge-nf-call-iptables", value: "1", expectedErr: true},
+	}
+	for _, test := range tests {
+		c := SysctlCheck{Parameter: test.parameter, Value: test.value, ProcSysDir: dir}
+		ok, err := c.Check()
+		if (err != nil) != test.expectedErr {
+			t.Errorf("%s: expected error %v, but got %v", test.parameter, test.expectedErr, err)
+		}
+		if ok != test.expected {
+			t.Errorf("%s=%s: expected %v, but got %v", test.parameter, test.value, test.expected, ok)
+		}
+	}
+}
@@ -96,6 +96,7 @@ func (c *TCPPortServerCheck) Close() error {
 }
 
 // Returns true if the port is taken by a process with the given name.
+// Otherwise, returns an error that names the process using the port.
 func portTakenByProc(port int, procName string) (bool, error) {
 	// Use `ss` (sockstat) to find the process listening on the given port.
 	// Sample output:
@@ -120,7 +121,10 @@ func portTakenByProc(port int, procName string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	return boundProc == procName, nil
+	if boundProc != procName {
+		return false, fmt.Errorf("port %d is in use by %q", port, boundProc)
+	}
+	return true, nil
 }
 
 // given an entry returned by sockstat (ss), return the name of the process using the port.
@@ -2,11 +2,13 @@ package inspector
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 
+	"github.com/apprenda/kismatic/pkg/inspector/check"
 	"github.com/apprenda/kismatic/pkg/inspector/rule"
 )
 
@@ -17,10 +19,23 @@ type Client struct {
 	// TargetNodeRole is the role of the node we are inspecting
 	TargetNodeFacts []string
 	engine          *rule.Engine
+	server          remoteServer
 }
 
 // NewClient returns an inspector client for running checks against remote nodes.
-func NewClient(targetNode string, targetNodeFacts []string) (*Client, error) {
+// The client uses mutual TLS with the given configuration, or plain HTTP if it is nil.
+func NewClient(targetNode string, targetNodeFacts []string, tlsConfig *tls.Config) (*Client, error) {
+	distro, err := check.DetectDistro()
+	if err != nil {
+		return nil, fmt.Errorf("error building client: %v", err)
+	}
+	return newClient(targetNode, targetNodeFacts, tlsConfig, distro)
+}
+
+// newClient returns an inspector client that suggests the remediations of
+// the failed checks for the given distribution. The nodes of a cluster run
+// the same distribution, so the client's distribution is the remote node's.
+func newClient(targetNode string, targetNodeFacts []string, tlsConfig *tls.Config, distro check.Distro) (*Client, error) {
 	host, _, err := net.SplitHostPort(targetNode)
 	if err != nil {
 		return nil, err
@@ -30,60 +45,86 @@ func NewClient(targetNode string, targetNodeFacts []string) (*Client, error) {
 			PackageManager: nil, // Use a no-op pkg manager here instead
 			TargetNodeIP:   host,
 		},
+		Distro: distro,
 	}
 	return &Client{
 		TargetNode:      targetNode,
 		TargetNodeFacts: targetNodeFacts,
 		engine:          engine,
+		server:          remoteServer{address: targetNode, tlsConfig: tlsConfig},
 	}, nil
 }
 
 // ExecuteRules against the target inspector server
 func (c Client) ExecuteRules(rules []rule.Rule) ([]rule.Result, error) {
-	serverSideRules := getServerSideRules(rules)
-	d, err := json.Marshal(serverSideRules)
+	results, err := c.server.executeRules(getServerSideRules(rules))
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute the rules that should run from a remote node
+	clientSideRules := getClientSideRules(rules)
+	remoteResults, err := c.engine.ExecuteRules(clientSideRules, c.TargetNodeFacts)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, remoteResults...)
+
+	if err := c.server.closeChecks(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// executeRules runs the rules on the inspector server
+func (s remoteServer) executeRules(rules []rule.Rule) ([]rule.Result, error) {
+	d, err := json.Marshal(rules)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling check request: %v", err)
 	}
-	resp, err := http.Post(fmt.Sprintf("http://%s%s", c.TargetNode, executeEndpoint), "application/json", bytes.NewReader(d))
+	results := []rule.Result{}
+	if err := s.post(executeEndpoint, d, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// post the request to the endpoint of the inspector server, and decode the
+// response into v
+func (s remoteServer) post(endpoint string, request []byte, v interface{}) error {
+	resp, err := s.httpClient().Post(s.url(endpoint), "application/json", bytes.NewReader(request))
 	if err != nil {
-		return nil, fmt.Errorf("error posting request to server: %v", err)
+		return fmt.Errorf("error posting request to server: %v", err)
 	}
 	defer resp.Body.Close()
 	// verify response status code
 	if resp.StatusCode == http.StatusInternalServerError {
 		errMsg := &serverError{}
 		if err = json.NewDecoder(resp.Body).Decode(errMsg); err != nil {
-			return nil, fmt.Errorf("failed to decode server response: %v. Server sent %q status", err, resp.Status)
+			return fmt.Errorf("failed to decode server response: %v. Server sent %q status", err, resp.Status)
 		}
-		return nil, fmt.Errorf("server sent %q status: error from server: %s", http.StatusInternalServerError, errMsg.Error)
+		return fmt.Errorf("server sent %q status: error from server: %s", http.StatusInternalServerError, errMsg.Error)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server responded with non-successful status: %q", resp.Status)
+		return fmt.Errorf("server responded with non-successful status: %q", resp.Status)
 	}
 
 	// we got an OK - handle the response
-	results := []rule.Result{}
-	err = json.NewDecoder(resp.Body).Decode(&results)
-	if err != nil {
-		return nil, fmt.Errorf("error decoding server response: %v", err)
-	}
-
-	// Execute the rules that should run from a remote node
-	clientSideRules := getClientSideRules(rules)
-	remoteResults, err := c.engine.ExecuteRules(clientSideRules, c.TargetNodeFacts)
-	if err != nil {
-		return nil, err
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding server response: %v", err)
 	}
-	results = append(results, remoteResults...)
+	return nil
+}
 
-	endpoint := fmt.Sprintf("http://%s%s", c.TargetNode, closeEndpoint)
-	resp, err = http.Get(endpoint)
+// closeChecks closes the checks that are still open on the inspector server
+func (s remoteServer) closeChecks() error {
+	endpoint := s.url(closeEndpoint)
+	resp, err := s.httpClient().Get(endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("GET request to %q failed. You might have to restart the inspector server. Error was: %v", endpoint, err)
+		return fmt.Errorf("GET request to %q failed. You might have to restart the inspector server. Error was: %v", endpoint, err)
 	}
-
-	return results, nil
+	resp.Body.Close()
+	return nil
 }
 
 func getServerSideRules(rules []rule.Rule) []rule.Rule {
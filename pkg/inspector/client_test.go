@@ -0,0 +1,55 @@
+package inspector
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+	"github.com/apprenda/kismatic/pkg/inspector/rule"
+)
+
+func TestClientRemediationUsesDistro(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == executeEndpoint {
+			fmt.Fprint(w, "[]")
+		}
+	}))
+	defer server.Close()
+
+	// Get a port that nothing is listening on
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	tests := []struct {
+		distro      check.Distro
+		remediation string
+	}{
+		{distro: check.Ubuntu, remediation: fmt.Sprintf("ufw allow %d/tcp", port)},
+		{distro: check.CentOS, remediation: fmt.Sprintf("firewall-cmd --permanent --add-port=%d/tcp", port)},
+		{distro: check.Unsupported, remediation: fmt.Sprintf("allows TCP traffic to port %d", port)},
+	}
+	for _, test := range tests {
+		c, err := newClient(strings.TrimPrefix(server.URL, "http://"), []string{"worker"}, nil, test.distro)
+		if err != nil {
+			t.Fatalf("%s: unexpected error creating client: %v", test.distro, err)
+		}
+		results, err := c.ExecuteRules([]rule.Rule{rule.TCPPortAccessible{Port: port, Timeout: "1s"}})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.distro, err)
+		}
+		if len(results) != 1 || results[0].Success {
+			t.Fatalf("%s: expected the port check to fail, but got %+v", test.distro, results)
+		}
+		if !strings.Contains(results[0].Remediation, test.remediation) {
+			t.Errorf("%s: expected the remediation to contain %q, but got %q", test.distro, test.remediation, results[0].Remediation)
+		}
+	}
+}
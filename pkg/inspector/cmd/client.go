@@ -15,16 +15,20 @@ type clientOpts struct {
 	rulesFile          string
 	targetNode         string
 	useUpgradeDefaults bool
+	tls                tlsOpts
 }
 
 var clientExample = `# Run the inspector against an etcd node
-kismatic-inspector client 10.0.1.24:9090 --node-roles etcd
+kismatic-inspector client 10.0.1.24:9090 --node-roles etcd --tls-ca-file ca.pem --tls-cert-file inspector.pem --tls-key-file inspector-key.pem
 
 # Run the inspector against a remote node, and ask for JSON output
-kismatic-inspector client 10.0.1.24:9090 --node-roles etcd -o json
+kismatic-inspector client 10.0.1.24:9090 --node-roles etcd -o json --tls-ca-file ca.pem --tls-cert-file inspector.pem --tls-key-file inspector-key.pem
 
 # Run the inspector against a remote node using a custom rules file
-kismatic-inspector client 10.0.1.24:9090 -f inspector-rules.yaml --node-roles etcd`
+kismatic-inspector client 10.0.1.24:9090 -f inspector-rules.yaml --node-roles etcd --tls-ca-file ca.pem --tls-cert-file inspector.pem --tls-key-file inspector-key.pem
+
+# Run the inspector against a remote node that serves plain HTTP
+kismatic-inspector client 10.0.1.24:9090 --node-roles etcd --insecure`
 
 // NewCmdClient returns the "client" command
 func NewCmdClient(out io.Writer) *cobra.Command {
@@ -46,6 +50,7 @@ func NewCmdClient(out io.Writer) *cobra.Command {
 	cmd.Flags().StringVar(&opts.nodeRoles, "node-roles", "", "comma-separated list of the node's roles. Valid roles are 'etcd', 'master', 'worker'")
 	cmd.Flags().StringVarP(&opts.rulesFile, "file", "f", "", "the path to an inspector rules file. If blank, the inspector uses the default rules")
 	cmd.Flags().BoolVarP(&opts.useUpgradeDefaults, "upgrade", "u", false, "use defaults for upgrade, rather than install")
+	addTLSFlags(cmd, &opts.tls)
 	return cmd
 }
 
@@ -53,6 +58,10 @@ func runClient(out io.Writer, opts clientOpts) error {
 	if err := validateOutputType(opts.outputType); err != nil {
 		return err
 	}
+	tlsConfig, err := getTLSConfig(opts.tls)
+	if err != nil {
+		return err
+	}
 	if opts.nodeRoles == "" {
 		return fmt.Errorf("--node-roles is required")
 	}
@@ -60,7 +69,7 @@ func runClient(out io.Writer, opts clientOpts) error {
 	if err != nil {
 		return err
 	}
-	c, err := inspector.NewClient(opts.targetNode, roles)
+	c, err := inspector.NewClient(opts.targetNode, roles, tlsConfig)
 	if err != nil {
 		return fmt.Errorf("error creating inspector client: %v", err)
 	}
@@ -25,5 +25,6 @@ func NewCmdKismaticInspector(out io.Writer) *cobra.Command {
 	cmd.AddCommand(NewCmdServer(out))
 	cmd.AddCommand(NewCmdLocal(out))
 	cmd.AddCommand(NewCmdRules(out))
+	cmd.AddCommand(NewCmdMesh(out))
 	return cmd
 }
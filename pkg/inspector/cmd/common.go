@@ -1,13 +1,47 @@
 package cmd
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
 
+	"github.com/apprenda/kismatic/pkg/inspector"
 	"github.com/apprenda/kismatic/pkg/inspector/rule"
+	"github.com/spf13/cobra"
 )
 
+type tlsOpts struct {
+	caFile   string
+	certFile string
+	keyFile  string
+	insecure bool
+}
+
+func addTLSFlags(cmd *cobra.Command, opts *tlsOpts) {
+	cmd.Flags().StringVar(&opts.caFile, "tls-ca-file", "", "the path to the CA certificate used for verifying the peer's certificate")
+	cmd.Flags().StringVar(&opts.certFile, "tls-cert-file", "", "the path to the certificate used for mutual TLS")
+	cmd.Flags().StringVar(&opts.keyFile, "tls-key-file", "", "the path to the private key of the certificate used for mutual TLS")
+	cmd.Flags().BoolVar(&opts.insecure, "insecure", false, "use plain HTTP without authentication, instead of mutual TLS")
+}
+
+// getTLSConfig returns the configuration for mutual TLS, or nil when plain
+// HTTP was explicitly requested
+func getTLSConfig(opts tlsOpts) (*tls.Config, error) {
+	tlsFlagSet := opts.caFile != "" || opts.certFile != "" || opts.keyFile != ""
+	if opts.insecure {
+		if tlsFlagSet {
+			return nil, errors.New("--insecure cannot be used with --tls-ca-file, --tls-cert-file or --tls-key-file")
+		}
+		return nil, nil
+	}
+	if opts.caFile == "" || opts.certFile == "" || opts.keyFile == "" {
+		return nil, errors.New("--tls-ca-file, --tls-cert-file and --tls-key-file are required. Use --insecure to use plain HTTP instead")
+	}
+	return inspector.TLSConfig(opts.caFile, opts.certFile, opts.keyFile)
+}
+
 func getNodeRoles(commaSepRoles string) ([]string, error) {
 	roles := strings.Split(commaSepRoles, ",")
 	for _, r := range roles {
@@ -20,14 +54,7 @@ func getNodeRoles(commaSepRoles string) ([]string, error) {
 
 func getRulesFromFileOrDefault(out io.Writer, file string, useUpgradeRules bool) ([]rule.Rule, error) {
 	if file != "" {
-		rules, err := rule.ReadFromFile(file)
-		if err != nil {
-			return nil, err
-		}
-		if ok := validateRules(out, rules); !ok {
-			return nil, fmt.Errorf("rules read from %q did not pass validation", file)
-		}
-		return rules, nil
+		return readRulesFile(out, file)
 	}
 	if useUpgradeRules {
 		return rule.UpgradeRules(), nil
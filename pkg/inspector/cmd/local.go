@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/inspector/check"
 	"github.com/apprenda/kismatic/pkg/inspector/rule"
@@ -16,6 +17,9 @@ type localOpts struct {
 	rulesFile                   string
 	packageInstallationDisabled bool
 	useUpgradeDefaults          bool
+	failSwapOn                  bool
+	concurrency                 int
+	checkTimeout                time.Duration
 }
 
 var localExample = `# Run with a custom rules file
@@ -38,6 +42,9 @@ func NewCmdLocal(out io.Writer) *cobra.Command {
 	cmd.Flags().StringVarP(&opts.rulesFile, "file", "f", "", "the path to an inspector rules file. If blank, the inspector uses the default rules")
 	cmd.Flags().BoolVar(&opts.packageInstallationDisabled, "pkg-installation-disabled", false, "when true, the inspector will ensure that the necessary packages are installed on the node")
 	cmd.Flags().BoolVarP(&opts.useUpgradeDefaults, "upgrade", "u", false, "use defaults for upgrade, rather than install")
+	cmd.Flags().BoolVar(&opts.failSwapOn, "fail-swap-on", true, "when true, the inspector will ensure that swap is disabled on the node, as required by the kubelet")
+	cmd.Flags().IntVar(&opts.concurrency, "concurrency", rule.DefaultConcurrency, "the number of checks to run at the same time")
+	cmd.Flags().DurationVar(&opts.checkTimeout, "check-timeout", rule.DefaultCheckTimeout, "the time a check can run for before it is considered failed")
 	return cmd
 }
 
@@ -73,8 +80,14 @@ func runLocal(out io.Writer, opts localOpts) error {
 			PackageManager:              pkgMgr,
 			PackageInstallationDisabled: opts.packageInstallationDisabled,
 		},
+		Concurrency:  opts.concurrency,
+		CheckTimeout: opts.checkTimeout,
+		Distro:       distro,
+	}
+	labels := append(roles, distro.Facts()...)
+	if opts.failSwapOn {
+		labels = append(labels, "fail-swap-on")
 	}
-	labels := append(roles, string(distro))
 	results, err := e.ExecuteRules(rules, labels)
 	if err != nil {
 		return fmt.Errorf("error running local rules: %v", err)
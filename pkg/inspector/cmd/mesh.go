@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apprenda/kismatic/pkg/inspector"
+	"github.com/spf13/cobra"
+)
+
+type meshOpts struct {
+	outputType         string
+	rulesFile          string
+	useUpgradeDefaults bool
+	passiveNodes       []string
+	tls                tlsOpts
+}
+
+var meshExample = `# Verify the connectivity between an etcd/master node and two worker nodes
+kismatic-inspector mesh 10.0.1.24:8888=etcd,master 10.0.1.25:8888=worker 10.0.1.26:8888=worker --tls-ca-file ca.pem --tls-cert-file inspector.pem --tls-key-file inspector-key.pem
+
+# Verify the connectivity between the nodes, and ask for JSON output
+kismatic-inspector mesh 10.0.1.24:8888=etcd,master 10.0.1.25:8888=worker -o json --tls-ca-file ca.pem --tls-cert-file inspector.pem --tls-key-file inspector-key.pem
+
+# Verify the connectivity from a new worker node to the existing nodes, which are not running the inspector server
+kismatic-inspector mesh 10.0.1.24:8888=etcd,master 10.0.1.25:8888=worker 10.0.1.26:8888=worker --passive 10.0.1.24:8888,10.0.1.25:8888 --tls-ca-file ca.pem --tls-cert-file inspector.pem --tls-key-file inspector-key.pem`
+
+// NewCmdMesh returns the "mesh" command
+func NewCmdMesh(out io.Writer) *cobra.Command {
+	opts := meshOpts{}
+	cmd := &cobra.Command{
+		Use:   "mesh HOST:PORT=ROLES...",
+		Short: "Verify the network connectivity between every pair of nodes running the inspector server.",
+		Long: `Verify the network connectivity between every pair of nodes running the inspector server.
+
+The inspector server on each node stands up the listeners for the ports in the rule set,
+and then probes the ports of every other node. The results are shown as a matrix of
+source and destination nodes.
+
+Passive nodes are not running the inspector server. They are probed by the other
+nodes, but do not probe the other nodes themselves.`,
+		Example: meshExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return fmt.Errorf("at least two nodes are required, but got %d", len(args))
+			}
+			return runMesh(out, args, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.outputType, "output", "o", "table", "set the result output type. Options are 'json', 'table'")
+	cmd.Flags().StringVarP(&opts.rulesFile, "file", "f", "", "the path to an inspector rules file. If blank, the inspector uses the default rules")
+	cmd.Flags().BoolVarP(&opts.useUpgradeDefaults, "upgrade", "u", false, "use defaults for upgrade, rather than install")
+	cmd.Flags().StringSliceVar(&opts.passiveNodes, "passive", []string{}, "the HOST:PORT of the nodes that are not running the inspector server, and are only probed")
+	addTLSFlags(cmd, &opts.tls)
+	return cmd
+}
+
+func runMesh(out io.Writer, args []string, opts meshOpts) error {
+	if err := validateOutputType(opts.outputType); err != nil {
+		return err
+	}
+	tlsConfig, err := getTLSConfig(opts.tls)
+	if err != nil {
+		return err
+	}
+	nodes, err := getMeshNodes(args, opts.passiveNodes)
+	if err != nil {
+		return err
+	}
+	rules, err := getRulesFromFileOrDefault(out, opts.rulesFile, opts.useUpgradeDefaults)
+	if err != nil {
+		return err
+	}
+	results, err := inspector.ExecuteMesh(nodes, rules, tlsConfig)
+	if err != nil {
+		return fmt.Errorf("error verifying connectivity between nodes: %v", err)
+	}
+	if err := printMeshResults(out, nodes, results, opts.outputType); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if !r.Success() {
+			return errors.New("inspector rules failed")
+		}
+	}
+	return nil
+}
+
+// getMeshNodes parses the nodes, given in the form HOST:PORT=ROLES, and marks
+// the passive nodes
+func getMeshNodes(args []string, passive []string) ([]inspector.MeshNode, error) {
+	isPassive := map[string]bool{}
+	for _, p := range passive {
+		isPassive[p] = true
+	}
+	nodes := []inspector.MeshNode{}
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("node %q is not valid. Nodes must be in the form HOST:PORT=ROLES", arg)
+		}
+		roles, err := getNodeRoles(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, inspector.MeshNode{Address: parts[0], Facts: roles, Passive: isPassive[parts[0]]})
+	}
+	for _, p := range passive {
+		found := false
+		for _, n := range nodes {
+			found = found || n.Address == p
+		}
+		if !found {
+			return nil, fmt.Errorf("passive node %q is not one of the nodes", p)
+		}
+	}
+	return nodes, nil
+}
+
+func printMeshResults(out io.Writer, nodes []inspector.MeshNode, results []inspector.MeshResult, outputType string) error {
+	switch outputType {
+	case "json":
+		if err := json.NewEncoder(out).Encode(results); err != nil {
+			return fmt.Errorf("error marshaling results as JSON: %v", err)
+		}
+		return nil
+	case "table":
+		return printMeshMatrix(out, nodes, results)
+	default:
+		return fmt.Errorf("output type %q not supported", outputType)
+	}
+}
+
+// printMeshMatrix prints a matrix with a row per source node and a column
+// per destination node. Failed cells are shown as FAILED, followed by the
+// list of failed probes.
+func printMeshMatrix(out io.Writer, nodes []inspector.MeshNode, results []inspector.MeshResult) error {
+	cells := map[string]inspector.MeshResult{}
+	for _, r := range results {
+		cells[r.Source+" "+r.Destination] = r
+	}
+	w := tabwriter.NewWriter(out, 1, 8, 4, '\t', 0)
+	fmt.Fprint(w, "SOURCE \\ DESTINATION")
+	for _, n := range nodes {
+		fmt.Fprintf(w, "\t%s", n.Address)
+	}
+	fmt.Fprintln(w)
+	for _, src := range nodes {
+		fmt.Fprint(w, src.Address)
+		for _, dst := range nodes {
+			r, ok := cells[src.Address+" "+dst.Address]
+			switch {
+			case !ok:
+				fmt.Fprint(w, "\t-")
+			case r.Success():
+				fmt.Fprint(w, "\tok")
+			default:
+				failed := 0
+				for _, res := range r.Results {
+					if !res.Success {
+						failed++
+					}
+				}
+				fmt.Fprintf(w, "\tFAILED (%d/%d)", failed, len(r.Results))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+
+	failures := []string{}
+	for _, r := range results {
+		for _, res := range r.Results {
+			if !res.Success {
+				failures = append(failures, fmt.Sprintf("%s -> %s: %s: %s", r.Source, r.Destination, res.Name, res.Error))
+			}
+		}
+	}
+	if len(failures) > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "FAILED PROBES:")
+		for _, f := range failures {
+			fmt.Fprintf(out, "- %s\n", f)
+		}
+	}
+	return nil
+}
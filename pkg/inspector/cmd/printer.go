@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"text/tabwriter"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/inspector/rule"
 )
@@ -30,9 +31,9 @@ func printResultsAsJSON(out io.Writer, results []rule.Result) error {
 
 func printResultsAsTable(out io.Writer, results []rule.Result) error {
 	w := tabwriter.NewWriter(out, 1, 8, 4, '\t', 0)
-	fmt.Fprintf(w, "CHECK\tSUCCESS\tMSG\n")
+	fmt.Fprintf(w, "CHECK\tSUCCESS\tDURATION\tMSG\tREMEDIATION\n")
 	for _, r := range results {
-		fmt.Fprintf(w, "%s\t%t\t%v\n", r.Name, r.Success, r.Error)
+		fmt.Fprintf(w, "%s\t%t\t%v\t%v\t%s\n", r.Name, r.Success, r.Duration-r.Duration%time.Millisecond, r.Error, r.Remediation)
 	}
 	w.Flush()
 	return nil
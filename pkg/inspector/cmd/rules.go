@@ -49,21 +49,15 @@ func NewCmdDumpRules(out io.Writer, file string) *cobra.Command {
 	return cmd
 }
 
+// NewCmdValidateRules returns the "validate" command
 func NewCmdValidateRules(out io.Writer, file string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "validate",
 		Short: "Validate the inspector rules",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if _, err := os.Stat(file); os.IsNotExist(err) {
-				return fmt.Errorf("%q does not exist", file)
-			}
-			rules, err := rule.ReadFromFile(file)
-			if err != nil {
+			if _, err := readRulesFile(out, file); err != nil {
 				return err
 			}
-			if !validateRules(out, rules) {
-				return fmt.Errorf("invalid rules found in %q", file)
-			}
 			fmt.Fprintf(out, "Rules are valid\n")
 			return nil
 		},
@@ -71,19 +65,20 @@ func NewCmdValidateRules(out io.Writer, file string) *cobra.Command {
 	return cmd
 }
 
-// validates rules, printing error messages to the console
-func validateRules(out io.Writer, rules []rule.Rule) bool {
-	allOK := true
-	for i, r := range rules {
-		errs := r.Validate()
-		if len(errs) > 0 {
-			allOK = false
-			fmt.Fprintf(out, "%s (Rule #%d):\n", r.GetRuleMeta().Kind, i+1)
-			for _, e := range errs {
-				fmt.Fprintf(out, "- %v\n", e)
-			}
-			fmt.Fprintln(out, "")
-		}
+// reads and validates the rules in the file, printing every problem found
+// in them to the console
+func readRulesFile(out io.Writer, file string) ([]rule.Rule, error) {
+	rules, err := rule.ReadFromFile(file)
+	if err == nil {
+		return rules, nil
+	}
+	invalidErr, ok := err.(*rule.InvalidRulesError)
+	if !ok {
+		return nil, err
+	}
+	for _, e := range invalidErr.Errs {
+		fmt.Fprintf(out, "- %v\n", e)
 	}
-	return allOK
+	fmt.Fprintln(out, "")
+	return nil, fmt.Errorf("invalid rules found in %q", file)
 }
@@ -3,16 +3,21 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/inspector"
+	"github.com/apprenda/kismatic/pkg/inspector/rule"
 	"github.com/spf13/cobra"
 )
 
 var serverExample = `# Run the inspector in server mode
-kismatic-inspector server --node-roles master,worker
+kismatic-inspector server --node-roles master,worker --tls-ca-file ca.pem --tls-cert-file inspector.pem --tls-key-file inspector-key.pem
 
 # Run the inspector in server mode, in a specific port
-kismatic-inspector server --port 9000 --node-roles master
+kismatic-inspector server --port 9000 --node-roles master --tls-ca-file ca.pem --tls-cert-file inspector.pem --tls-key-file inspector-key.pem
+
+# Run the inspector in server mode over plain HTTP, without authentication
+kismatic-inspector server --node-roles master --insecure
 `
 
 // NewCmdServer returns the "server" command
@@ -21,22 +26,30 @@ func NewCmdServer(out io.Writer) *cobra.Command {
 	var nodeRoles string
 	var packageInstallationDisabled bool
 	var disconnectedInstallation bool
+	var failSwapOn bool
+	var concurrency int
+	var checkTimeout time.Duration
+	var tlsOpts tlsOpts
 	cmd := &cobra.Command{
 		Use:     "server",
 		Short:   "Stand up the inspector server for running checks remotely",
 		Example: serverExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runServer(out, cmd.Parent().Name(), port, nodeRoles, packageInstallationDisabled, disconnectedInstallation)
+			return runServer(out, cmd.Parent().Name(), port, nodeRoles, packageInstallationDisabled, disconnectedInstallation, failSwapOn, concurrency, checkTimeout, tlsOpts)
 		},
 	}
 	cmd.Flags().IntVar(&port, "port", 9090, "the port number for standing up the Inspector server")
 	cmd.Flags().StringVar(&nodeRoles, "node-roles", "", "comma-separated list of the node's roles. Valid roles are 'etcd', 'master', 'worker', 'ingress', 'storage'")
 	cmd.Flags().BoolVar(&packageInstallationDisabled, "pkg-installation-disabled", false, "when true, the inspector will ensure that the necessary packages are installed on the node")
 	cmd.Flags().BoolVar(&disconnectedInstallation, "disconnected-installation", false, "when true will check for the required packages needed during a disconnected install")
+	cmd.Flags().BoolVar(&failSwapOn, "fail-swap-on", true, "when true, the inspector will ensure that swap is disabled on the node, as required by the kubelet")
+	cmd.Flags().IntVar(&concurrency, "concurrency", rule.DefaultConcurrency, "the number of checks to run at the same time")
+	cmd.Flags().DurationVar(&checkTimeout, "check-timeout", rule.DefaultCheckTimeout, "the time a check can run for before it is considered failed")
+	addTLSFlags(cmd, &tlsOpts)
 	return cmd
 }
 
-func runServer(out io.Writer, commandName string, port int, nodeRoles string, packageInstallationDisabled bool, disconnectedInstallation bool) error {
+func runServer(out io.Writer, commandName string, port int, nodeRoles string, packageInstallationDisabled bool, disconnectedInstallation bool, failSwapOn bool, concurrency int, checkTimeout time.Duration, tlsOpts tlsOpts) error {
 	if nodeRoles == "" {
 		return fmt.Errorf("--node-roles is required")
 	}
@@ -44,17 +57,27 @@ func runServer(out io.Writer, commandName string, port int, nodeRoles string, pa
 	if err != nil {
 		return err
 	}
+	tlsConfig, err := getTLSConfig(tlsOpts)
+	if err != nil {
+		return err
+	}
 	if disconnectedInstallation {
 		nodeFacts = append(nodeFacts, "disconnected")
 	}
-	s, err := inspector.NewServer(nodeFacts, port, packageInstallationDisabled)
+	if failSwapOn {
+		nodeFacts = append(nodeFacts, "fail-swap-on")
+	}
+	s, err := inspector.NewServer(nodeFacts, port, packageInstallationDisabled, concurrency, checkTimeout)
 	if err != nil {
 		return fmt.Errorf("error starting up inspector server: %v", err)
 	}
+	s.TLSConfig = tlsConfig
 	fmt.Fprintf(out, "Inspector is listening on port %d\n", port)
 	fmt.Fprintf(out, "Node roles: %s\n", nodeRoles)
 	fmt.Fprintf(out, "Package installation disabled: %v\n", packageInstallationDisabled)
 	fmt.Fprintf(out, "Disconnected installation: %v\n", disconnectedInstallation)
+	fmt.Fprintf(out, "Fail if swap is enabled: %v\n", failSwapOn)
+	fmt.Fprintf(out, "Mutual TLS: %v\n", tlsConfig != nil)
 	fmt.Fprintf(out, "Run %s from another node to run checks remotely: %[1]s client [NODE_IP]:%d\n", commandName, port)
 	if err := s.Start(); err != nil {
 		return err
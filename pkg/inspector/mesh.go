@@ -0,0 +1,214 @@
+package inspector
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/apprenda/kismatic/pkg/inspector/rule"
+)
+
+var probeEndpoint = "/probe"
+
+// MeshNode is a node that takes part in the full-mesh connectivity check
+type MeshNode struct {
+	// Address is the ip:port of the inspector server running on the node
+	Address string
+	// Facts are the facts that apply to the node, such as its roles
+	Facts []string
+	// Passive is true if the node is not running the inspector server. The
+	// node is probed by the other nodes, but it does not stand up listeners
+	// or probe the other nodes.
+	Passive bool
+}
+
+// MeshResult contains the results of probing the listeners of the destination
+// node from the source node
+type MeshResult struct {
+	// Source is the address of the node that ran the probes
+	Source string
+	// Destination is the address of the node that was probed
+	Destination string
+	// Results of the rules that were run against the destination
+	Results []rule.Result
+}
+
+// Success returns true if all the probes from the source to the destination
+// succeeded
+func (r MeshResult) Success() bool {
+	for _, res := range r.Results {
+		if !res.Success {
+			return false
+		}
+	}
+	return true
+}
+
+type probeTarget struct {
+	// IP of the node to probe
+	IP string
+	// Facts that apply to the node to probe
+	Facts []string
+}
+
+type probeRequest struct {
+	Targets []probeTarget
+	Rules   json.RawMessage
+}
+
+type probeResult struct {
+	IP      string
+	Results []rule.Result
+}
+
+// ExecuteMesh verifies the connectivity between every pair of nodes. The
+// inspector server on each node stands up the listeners for the ports in the
+// rule set, and then probes the listeners of every other node. Passive nodes
+// are only probed. The results are ordered by source, and then by
+// destination, in the order of the nodes.
+// The servers are reached using mutual TLS with the given configuration, or
+// plain HTTP if it is nil.
+func ExecuteMesh(nodes []MeshNode, rules []rule.Rule, tlsConfig *tls.Config) ([]MeshResult, error) {
+	if len(nodes) < 2 {
+		return nil, fmt.Errorf("at least two nodes are required, but got %d", len(nodes))
+	}
+	ips := make([]string, len(nodes))
+	servers := make([]*remoteServer, len(nodes))
+	sources := 0
+	for i, n := range nodes {
+		host, _, err := net.SplitHostPort(n.Address)
+		if err != nil {
+			return nil, err
+		}
+		ips[i] = host
+		if !n.Passive {
+			servers[i] = &remoteServer{address: n.Address, tlsConfig: tlsConfig}
+			sources++
+		}
+	}
+	if sources == 0 {
+		return nil, errors.New("at least one node must be running the inspector server")
+	}
+	accessibleRules, err := json.Marshal(getClientSideRules(rules))
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling probe request: %v", err)
+	}
+
+	// Stand up the listeners on all nodes running the inspector server, and
+	// close them regardless of the outcome of the probes
+	defer func() {
+		for _, s := range servers {
+			if s != nil {
+				s.closeChecks()
+			}
+		}
+	}()
+	listenerRules := getListenerRules(rules)
+	for _, s := range servers {
+		if s == nil {
+			continue
+		}
+		if _, err := s.executeRules(listenerRules); err != nil {
+			return nil, fmt.Errorf("error standing up listeners on %q: %v", s.address, err)
+		}
+	}
+
+	meshResults := make([][]MeshResult, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i := range nodes {
+		if servers[i] == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			meshResults[i], errs[i] = probeFromNode(*servers[i], nodes, ips, i, accessibleRules)
+		}(i)
+	}
+	wg.Wait()
+
+	results := []MeshResult{}
+	for i := range nodes {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("error probing from %q: %v", nodes[i].Address, errs[i])
+		}
+		results = append(results, meshResults[i]...)
+	}
+	return results, nil
+}
+
+// probeFromNode asks the inspector server of the source node to probe the
+// listeners of all other nodes
+func probeFromNode(server remoteServer, nodes []MeshNode, ips []string, source int, rules json.RawMessage) ([]MeshResult, error) {
+	req := probeRequest{Rules: rules}
+	dests := []MeshNode{}
+	for i, n := range nodes {
+		if i == source {
+			continue
+		}
+		req.Targets = append(req.Targets, probeTarget{IP: ips[i], Facts: n.Facts})
+		dests = append(dests, n)
+	}
+	if len(dests) == 0 {
+		return nil, nil
+	}
+	d, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling probe request: %v", err)
+	}
+	probeResults := []probeResult{}
+	if err := server.post(probeEndpoint, d, &probeResults); err != nil {
+		return nil, err
+	}
+	if len(probeResults) != len(dests) {
+		return nil, fmt.Errorf("expected results for %d nodes, but got %d", len(dests), len(probeResults))
+	}
+	results := make([]MeshResult, len(dests))
+	for i, dest := range dests {
+		results[i] = MeshResult{
+			Source:      nodes[source].Address,
+			Destination: dest.Address,
+			Results:     probeResults[i].Results,
+		}
+	}
+	return results, nil
+}
+
+// probeTargets runs the rules against each target. The targets are probed
+// concurrently, and the results are in the same order as the targets.
+func probeTargets(targets []probeTarget, rules []rule.Rule, newEngine func(targetIP string) *rule.Engine) ([]probeResult, error) {
+	results := make([]probeResult, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t probeTarget) {
+			defer wg.Done()
+			res, err := newEngine(t.IP).ExecuteRules(rules, t.Facts)
+			results[i] = probeResult{IP: t.IP, Results: res}
+			errs[i] = err
+		}(i, t)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("error probing %q: %v", targets[i].IP, err)
+		}
+	}
+	return results, nil
+}
+
+// returns the rules that stand up the listeners that are probed
+func getListenerRules(rules []rule.Rule) []rule.Rule {
+	listenerRules := []rule.Rule{}
+	for _, r := range rules {
+		if _, ok := r.(rule.TCPPortAvailable); ok {
+			listenerRules = append(listenerRules, r)
+		}
+	}
+	return listenerRules
+}
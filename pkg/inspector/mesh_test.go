@@ -0,0 +1,112 @@
+package inspector
+
+import (
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/inspector/rule"
+)
+
+func TestExecuteMesh(t *testing.T) {
+	// A port with a listener that all nodes can reach, and a port without one
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer ln.Close()
+	openPort := ln.Addr().(*net.TCPAddr).Port
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	nodes := []MeshNode{}
+	for _, facts := range [][]string{{"master"}, {"worker"}, {"worker"}} {
+		s := &Server{NodeFacts: facts, rulesEngine: &rule.Engine{}}
+		ts := httptest.NewServer(s.handler())
+		defer ts.Close()
+		nodes = append(nodes, MeshNode{Address: strings.TrimPrefix(ts.URL, "http://"), Facts: facts})
+	}
+	rules := []rule.Rule{
+		rule.TCPPortAccessible{Meta: rule.Meta{Kind: "TCPPortAccessible"}, Port: openPort, Timeout: "1s"},
+		rule.TCPPortAccessible{Meta: rule.Meta{Kind: "TCPPortAccessible", When: [][]string{{"master"}}}, Port: closedPort, Timeout: "1s"},
+	}
+
+	results, err := ExecuteMesh(nodes, rules, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 6 {
+		t.Fatalf("expected 6 results, but got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Source == r.Destination {
+			t.Errorf("node %q was probed from itself", r.Source)
+		}
+		// Only the master has a rule for the closed port
+		if r.Destination == nodes[0].Address {
+			if len(r.Results) != 2 || r.Success() {
+				t.Errorf("expected probes from %q to the master to fail, but got %+v", r.Source, r.Results)
+			}
+			continue
+		}
+		if len(r.Results) != 1 || !r.Success() {
+			t.Errorf("expected probes from %q to %q to succeed, but got %+v", r.Source, r.Destination, r.Results)
+		}
+	}
+	if results[0].Source != nodes[0].Address || results[0].Destination != nodes[1].Address {
+		t.Errorf("expected results to be ordered by source and destination, but got %+v", results[0])
+	}
+}
+
+func TestExecuteMeshPassiveNodes(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	defer ln.Close()
+	openPort := ln.Addr().(*net.TCPAddr).Port
+
+	s := &Server{NodeFacts: []string{"worker"}, rulesEngine: &rule.Engine{}}
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+	nodes := []MeshNode{
+		// The passive node is not running a server, so it cannot be reached
+		{Address: "127.0.0.1:1", Facts: []string{"master"}, Passive: true},
+		{Address: strings.TrimPrefix(ts.URL, "http://"), Facts: []string{"worker"}},
+	}
+	rules := []rule.Rule{
+		rule.TCPPortAccessible{Meta: rule.Meta{Kind: "TCPPortAccessible"}, Port: openPort, Timeout: "1s"},
+	}
+
+	results, err := ExecuteMesh(nodes, rules, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, but got %d", len(results))
+	}
+	if results[0].Source != nodes[1].Address || results[0].Destination != nodes[0].Address {
+		t.Errorf("expected the passive node to only be probed, but got %+v", results[0])
+	}
+	if !results[0].Success() {
+		t.Errorf("expected probes to succeed, but got %+v", results[0].Results)
+	}
+}
+
+func TestExecuteMeshRequiresTwoNodes(t *testing.T) {
+	if _, err := ExecuteMesh([]MeshNode{{Address: "127.0.0.1:8888", Facts: []string{"master"}}}, nil, nil); err == nil {
+		t.Errorf("expected an error with a single node, but got nil")
+	}
+	passive := []MeshNode{
+		{Address: "127.0.0.1:8888", Facts: []string{"master"}, Passive: true},
+		{Address: "127.0.0.2:8888", Facts: []string{"worker"}, Passive: true},
+	}
+	if _, err := ExecuteMesh(passive, nil, nil); err == nil {
+		t.Errorf("expected an error when all nodes are passive, but got nil")
+	}
+}
@@ -53,6 +53,36 @@ func (m DefaultCheckMapper) GetCheckForRule(rule Rule) (check.Check, error) {
 	case FreeSpace:
 		bytes, _ := r.minimumBytesAsUint64() // ignore this err, as we have already validated the rule
 		c = &check.FreeSpaceCheck{Path: r.Path, MinimumBytes: bytes}
+	case KernelModuleLoaded:
+		c = check.KernelModuleCheck{Module: r.Module}
+	case SysctlValue:
+		c = check.SysctlCheck{Parameter: r.Parameter, Value: r.Value}
+	case SwapDisabled:
+		c = check.SwapCheck{}
+	case SELinuxMode:
+		c = check.SELinuxCheck{AcceptableModes: r.AcceptableModes}
+	case MinimumCPU:
+		c = check.CPUCheck{MinimumCores: r.Cores}
+	case MinimumMemory:
+		bytes, _ := r.minimumBytesAsUint64() // ignore this err, as we have already validated the rule
+		c = check.MemoryCheck{MinimumBytes: bytes}
+	case DiskFsyncLatency:
+		latency, _ := time.ParseDuration(r.MaximumLatency) // ignore this err, as we have already validated the rule
+		c = check.DiskFsyncLatencyCheck{Path: r.Path, MaximumLatency: latency}
+	case HTTPEndpointReachable:
+		var timeout time.Duration
+		if r.Timeout != "" {
+			timeout, _ = time.ParseDuration(r.Timeout) // ignore this err, as we have already validated the rule
+		}
+		c = check.HTTPEndpointCheck{
+			URL:                 r.URL,
+			HTTPProxy:           r.HTTPProxy,
+			HTTPSProxy:          r.HTTPSProxy,
+			NoProxy:             r.NoProxy,
+			CACert:              r.CACert,
+			ExpectedStatusCodes: r.ExpectedStatusCodes,
+			Timeout:             timeout,
+		}
 	}
 	return c, nil
 }
@@ -0,0 +1,55 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+)
+
+// DiskFsyncLatency is a rule that ensures that the disk backing the given
+// path is fast enough to persist small writes. Following etcd's guidance,
+// the 99th percentile of the latency of writes that are followed by an
+// fsync must be below the maximum latency.
+type DiskFsyncLatency struct {
+	Meta
+	Path string
+	// MaximumLatency is the maximum latency of the 99th percentile, such as "10ms"
+	MaximumLatency string
+}
+
+// Name is the name of the rule
+func (d DiskFsyncLatency) Name() string {
+	return fmt.Sprintf("Fsync latency of %s is below %s", d.Path, d.MaximumLatency)
+}
+
+// IsRemoteRule returns true if the rule is to be run from outside of the node
+func (d DiskFsyncLatency) IsRemoteRule() bool { return false }
+
+// Validate the rule
+func (d DiskFsyncLatency) Validate() []error {
+	errs := []error{}
+	if d.Path == "" {
+		errs = append(errs, errors.New("Path cannot be empty"))
+	} else if !strings.HasPrefix(d.Path, "/") {
+		errs = append(errs, errors.New("Path must start with /"))
+	}
+	if d.MaximumLatency == "" {
+		errs = append(errs, errors.New("MaximumLatency cannot be empty"))
+	} else if l, err := time.ParseDuration(d.MaximumLatency); err != nil {
+		errs = append(errs, fmt.Errorf("MaximumLatency contains an invalid duration: %v", err))
+	} else if l <= 0 {
+		errs = append(errs, errors.New("MaximumLatency must be greater than zero"))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Remediation returns the steps to satisfy the rule
+func (d DiskFsyncLatency) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Place %s on a faster disk, such as an SSD, that is not shared with other I/O intensive workloads", d.Path)
+}
@@ -0,0 +1,24 @@
+package rule
+
+import "testing"
+
+func TestDiskFsyncLatencyRuleValidation(t *testing.T) {
+	d := DiskFsyncLatency{}
+	if errs := d.Validate(); len(errs) != 2 {
+		t.Errorf("expected 2 errors, but got %d", len(errs))
+	}
+	d.Path = "var/lib/etcd"
+	d.MaximumLatency = "10"
+	if errs := d.Validate(); len(errs) != 2 {
+		t.Errorf("expected 2 errors, but got %d", len(errs))
+	}
+	d.Path = "/var/lib/etcd"
+	d.MaximumLatency = "-10ms"
+	if errs := d.Validate(); len(errs) != 1 {
+		t.Errorf("expected 1 error, but got %d", len(errs))
+	}
+	d.MaximumLatency = "10ms"
+	if errs := d.Validate(); len(errs) != 0 {
+		t.Errorf("expected 0 errors, but got %d", len(errs))
+	}
+}
@@ -2,15 +2,19 @@ package rule
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"regexp"
 	"strings"
 
 	yaml "gopkg.in/yaml.v2"
 )
 
-// ReadFromFile returns the list of rules contained in the specified file
+// ReadFromFile returns the list of rules contained in the specified file.
+// The rules are validated, and an InvalidRulesError that lists every problem
+// is returned if any of them is not valid.
 func ReadFromFile(file string) ([]Rule, error) {
 	if _, err := os.Stat(file); os.IsNotExist(err) {
 		return nil, fmt.Errorf("%q does not exist", file)
@@ -19,14 +23,72 @@ func ReadFromFile(file string) ([]Rule, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error reading rules from %q: %v", file, err)
 	}
-	rules, err := UnmarshalRulesYAML(rawRules)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshaling rules from %q: %v", file, err)
+	rules, err := ParseRulesYAML(rawRules)
+	if invalidErr, ok := err.(*InvalidRulesError); ok {
+		invalidErr.File = file
+	}
+	return rules, err
+}
+
+// ParseRulesYAML unmarshals the data into a list of rules, and validates them.
+// An InvalidRulesError that lists every problem is returned if any of the
+// rules is not valid.
+func ParseRulesYAML(data []byte) ([]Rule, error) {
+	rules, errs := unmarshalRulesYAML(data, true)
+	if len(errs) > 0 {
+		return nil, &InvalidRulesError{Errs: errs}
 	}
-	// TODO: Validate the rules we just read
 	return rules, nil
 }
 
+// MergeRulesYAML merges the rule sets into a single rule set that contains
+// the rules of each set, in order.
+func MergeRulesYAML(ruleSets ...[]byte) ([]byte, error) {
+	merged := []interface{}{}
+	for i, d := range ruleSets {
+		rules := []interface{}{}
+		if err := yaml.Unmarshal(d, &rules); err != nil {
+			return nil, fmt.Errorf("error unmarshaling rule set #%d: %v", i+1, err)
+		}
+		merged = append(merged, rules...)
+	}
+	return yaml.Marshal(merged)
+}
+
+// RuleError is a problem found in a rule of a rule set
+type RuleError struct {
+	// Index of the rule in the rule set, starting at 1
+	Index int
+	// Kind of the rule, as it was written in the rule set
+	Kind string
+	Err  error
+}
+
+func (e *RuleError) Error() string {
+	if e.Kind == "" {
+		return fmt.Sprintf("Rule #%d: %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("Rule #%d (%s): %v", e.Index, e.Kind, e.Err)
+}
+
+// InvalidRulesError contains every problem found in a rule set
+type InvalidRulesError struct {
+	// File the rules were read from, if any
+	File string
+	Errs []error
+}
+
+func (e *InvalidRulesError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	if e.File == "" {
+		return fmt.Sprintf("invalid rules found: %s", strings.Join(msgs, "; "))
+	}
+	return fmt.Sprintf("invalid rules found in %q: %s", e.File, strings.Join(msgs, "; "))
+}
+
 // This catch all rule is used for unmarshaling
 // The reason for having this is that we don't know the Kind
 // of the rule we are reading before unmarshaling, so we
@@ -47,15 +109,97 @@ type catchAllRule struct {
 	SupportedVersions        []string `yaml:"supportedVersions"`
 	Path                     string   `yaml:"path"`
 	MinimumBytes             string   `yaml:"minimumBytes"`
+	Module                   string   `yaml:"module"`
+	Parameter                string   `yaml:"parameter"`
+	Value                    string   `yaml:"value"`
+	AcceptableModes          []string `yaml:"acceptableModes"`
+	Cores                    int      `yaml:"cores"`
+	MaximumLatency           string   `yaml:"maximumLatency"`
+	URL                      string   `yaml:"url"`
+	HTTPProxy                string   `yaml:"httpProxy"`
+	HTTPSProxy               string   `yaml:"httpsProxy"`
+	NoProxy                  string   `yaml:"noProxy"`
+	CACert                   string   `yaml:"caCert"`
+	ExpectedStatusCodes      []int    `yaml:"expectedStatusCodes"`
 }
 
-// UnmarshalRulesYAML unmarshals the data into a list of rules
+// UnmarshalRulesYAML unmarshals the data into a list of rules. An
+// InvalidRulesError that lists every problem is returned if the data contains
+// rules of unknown kinds, or fields that are not part of any rule.
 func UnmarshalRulesYAML(data []byte) ([]Rule, error) {
-	catchAllRules := []catchAllRule{}
-	if err := yaml.Unmarshal(data, &catchAllRules); err != nil {
-		return nil, err
+	rules, errs := unmarshalRulesYAML(data, false)
+	if len(errs) > 0 {
+		return nil, &InvalidRulesError{Errs: errs}
 	}
-	return rulesFromCatchAllRules(catchAllRules)
+	return rules, nil
+}
+
+// unmarshalRulesYAML unmarshals the data into a list of rules, and returns
+// every problem found in them. The rules are also validated if validate is true.
+func unmarshalRulesYAML(data []byte, validate bool) ([]Rule, []error) {
+	rawRules := []interface{}{}
+	if err := yaml.Unmarshal(data, &rawRules); err != nil {
+		return nil, []error{err}
+	}
+	var errs []error
+	rules := []Rule{}
+	for i, raw := range rawRules {
+		c, decodeErrs := unmarshalCatchAllRule(raw)
+		ruleErr := func(err error) error {
+			return &RuleError{Index: i + 1, Kind: c.Kind, Err: err}
+		}
+		for _, err := range decodeErrs {
+			errs = append(errs, ruleErr(err))
+		}
+		r, err := buildRule(c)
+		if err != nil {
+			errs = append(errs, ruleErr(err))
+			continue
+		}
+		if validate {
+			for _, err := range r.Validate() {
+				errs = append(errs, ruleErr(err))
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, errs
+}
+
+var (
+	decodingErrorRegexp = regexp.MustCompile(`^line \d+: (.*)$`)
+	unknownFieldRegexp  = regexp.MustCompile(`^field (.+) not found in type \S+$`)
+)
+
+// unmarshalCatchAllRule strictly unmarshals a single rule, and returns the
+// problems found in its fields. The rest of the fields are still decoded when
+// a field is unknown or has the wrong type.
+func unmarshalCatchAllRule(raw interface{}) (catchAllRule, []error) {
+	c := catchAllRule{}
+	d, err := yaml.Marshal(raw)
+	if err != nil {
+		return c, []error{err}
+	}
+	err = yaml.UnmarshalStrict(d, &c)
+	if err == nil {
+		return c, nil
+	}
+	typeErr, ok := err.(*yaml.TypeError)
+	if !ok {
+		return c, []error{err}
+	}
+	var errs []error
+	for _, msg := range typeErr.Errors {
+		// the line is that of the rule on its own, not that of the rule set
+		if m := decodingErrorRegexp.FindStringSubmatch(msg); m != nil {
+			msg = m[1]
+		}
+		if m := unknownFieldRegexp.FindStringSubmatch(msg); m != nil {
+			msg = fmt.Sprintf("unknown field %q", m[1])
+		}
+		errs = append(errs, errors.New(msg))
+	}
+	return c, errs
 }
 
 // UnmarshalRulesJSON unmarshals the JSON rules into a list of rules
@@ -143,6 +287,59 @@ func buildRule(catchAll catchAllRule) (Rule, error) {
 		}
 		r.Meta = meta
 		return r, nil
-
+	case "kernelmoduleloaded":
+		r := KernelModuleLoaded{
+			Module: catchAll.Module,
+		}
+		r.Meta = meta
+		return r, nil
+	case "sysctlvalue":
+		r := SysctlValue{
+			Parameter: catchAll.Parameter,
+			Value:     catchAll.Value,
+		}
+		r.Meta = meta
+		return r, nil
+	case "swapdisabled":
+		r := SwapDisabled{}
+		r.Meta = meta
+		return r, nil
+	case "selinuxmode":
+		r := SELinuxMode{
+			AcceptableModes: catchAll.AcceptableModes,
+		}
+		r.Meta = meta
+		return r, nil
+	case "minimumcpu":
+		r := MinimumCPU{
+			Cores: catchAll.Cores,
+		}
+		r.Meta = meta
+		return r, nil
+	case "minimummemory":
+		r := MinimumMemory{
+			MinimumBytes: catchAll.MinimumBytes,
+		}
+		r.Meta = meta
+		return r, nil
+	case "diskfsynclatency":
+		r := DiskFsyncLatency{
+			Path:           catchAll.Path,
+			MaximumLatency: catchAll.MaximumLatency,
+		}
+		r.Meta = meta
+		return r, nil
+	case "httpendpointreachable":
+		r := HTTPEndpointReachable{
+			URL:                 catchAll.URL,
+			HTTPProxy:           catchAll.HTTPProxy,
+			HTTPSProxy:          catchAll.HTTPSProxy,
+			NoProxy:             catchAll.NoProxy,
+			CACert:              catchAll.CACert,
+			ExpectedStatusCodes: catchAll.ExpectedStatusCodes,
+			Timeout:             catchAll.Timeout,
+		}
+		r.Meta = meta
+		return r, nil
 	}
 }
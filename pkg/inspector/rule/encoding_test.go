@@ -0,0 +1,174 @@
+package rule
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRulesFile(t *testing.T, contents string) (string, func()) {
+	dir, err := ioutil.TempDir("", "inspector-rules")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	file := filepath.Join(dir, "rules.yaml")
+	if err := ioutil.WriteFile(file, []byte(contents), 0644); err != nil {
+		t.Fatalf("error writing rules file: %v", err)
+	}
+	return file, func() { os.RemoveAll(dir) }
+}
+
+func TestReadFromFileValidRules(t *testing.T) {
+	file, cleanup := writeRulesFile(t, `
+- kind: FreeSpace
+  when: [["master"]]
+  path: /var
+  minimumBytes: "1000"
+- kind: TCPPortAvailable
+  port: 8080
+  procName: kube-apiserver
+`)
+	defer cleanup()
+	rules, err := ReadFromFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Errorf("expected 2 rules, got %d", len(rules))
+	}
+}
+
+func TestReadFromFileInvalidRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    string
+		expected []string
+	}{
+		{
+			name: "bad minimumBytes",
+			rules: `
+- kind: FreeSpace
+  path: /var
+  minimumBytes: "lots"
+`,
+			expected: []string{"Rule #1 (FreeSpace): MinimumBytes contains an invalid unsigned integer"},
+		},
+		{
+			name: "unknown kind",
+			rules: `
+- kind: TCPPortAvailable
+  port: 8080
+  procName: kube-apiserver
+- kind: NotARule
+`,
+			expected: []string{`Rule #2 (NotARule): rule with kind "NotARule" is not supported`},
+		},
+		{
+			name: "missing port",
+			rules: `
+- kind: TCPPortAccessible
+  timeout: 1s
+`,
+			expected: []string{"Rule #1 (TCPPortAccessible): Invalid port number 0 specified"},
+		},
+		{
+			name: "unknown field",
+			rules: `
+- kind: TCPPortAvailable
+  prot: 8080
+  procName: kube-apiserver
+`,
+			expected: []string{
+				`Rule #1 (TCPPortAvailable): unknown field "prot"`,
+				"Rule #1 (TCPPortAvailable): Invalid port number 0 specified",
+			},
+		},
+		{
+			name: "field with the wrong type",
+			rules: `
+- kind: TCPPortAvailable
+  port: 8080
+  procName: kube-apiserver
+- kind: TCPPortAvailable
+  port: eighty
+  procName: kube-apiserver
+`,
+			expected: []string{
+				"Rule #2 (TCPPortAvailable): cannot unmarshal !!str `eighty` into int",
+				"Rule #2 (TCPPortAvailable): Invalid port number 0 specified",
+			},
+		},
+		{
+			name: "multiple invalid rules",
+			rules: `
+- kind: FreeSpace
+  path: var
+  minimumBytes: "1000"
+- kind: TCPPortAvailable
+  port: 8080
+  procName: kube-apiserver
+- kind: FreeSpace
+  path: /var
+`,
+			expected: []string{
+				"Rule #1 (FreeSpace): Path must start with /",
+				"Rule #3 (FreeSpace): MinimumBytes cannot be empty",
+			},
+		},
+	}
+	for _, test := range tests {
+		file, cleanup := writeRulesFile(t, test.rules)
+		_, err := ReadFromFile(file)
+		cleanup()
+		invalidErr, ok := err.(*InvalidRulesError)
+		if !ok {
+			t.Errorf("%s: expected an InvalidRulesError, got %v", test.name, err)
+			continue
+		}
+		if len(invalidErr.Errs) != len(test.expected) {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.name, len(test.expected), len(invalidErr.Errs), invalidErr)
+			continue
+		}
+		for i, e := range invalidErr.Errs {
+			if !strings.HasPrefix(e.Error(), test.expected[i]) {
+				t.Errorf("%s: expected error %q, got %q", test.name, test.expected[i], e.Error())
+			}
+		}
+	}
+}
+
+func TestMergeRulesYAML(t *testing.T) {
+	defaults := []byte(`
+- kind: ExecutableInPath
+  executable: iptables
+`)
+	custom := []byte(`
+- kind: FileContentMatches
+  when: [["master"]]
+  file: /etc/ssh/sshd_config
+  contentRegex: PermitRootLogin no
+`)
+	merged, err := MergeRulesYAML(defaults, custom)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rules, err := ParseRulesYAML(merged)
+	if err != nil {
+		t.Fatalf("unexpected error parsing merged rules: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rules))
+	}
+	if _, ok := rules[0].(ExecutableInPath); !ok {
+		t.Errorf("expected the first rule to be ExecutableInPath, got %T", rules[0])
+	}
+	fc, ok := rules[1].(FileContentMatches)
+	if !ok {
+		t.Fatalf("expected the second rule to be FileContentMatches, got %T", rules[1])
+	}
+	if fc.ContentRegex != "PermitRootLogin no" || len(fc.When) != 1 || fc.When[0][0] != "master" {
+		t.Errorf("the custom rule was not merged as expected: %+v", fc)
+	}
+}
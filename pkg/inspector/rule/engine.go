@@ -1,56 +1,143 @@
 package rule
 
 import (
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/inspector/check"
 )
 
+const (
+	// DefaultConcurrency is the number of checks that the engine runs at the same time
+	DefaultConcurrency = 8
+	// DefaultCheckTimeout is the time a check can run for before it is considered failed
+	DefaultCheckTimeout = 2 * time.Minute
+)
+
 // The Engine executes rules and reports the results
 type Engine struct {
 	RuleCheckMapper CheckMapper
-	mu              sync.Mutex
-	closableChecks  []check.ClosableCheck
+	// Concurrency is the maximum number of checks that run at the same time.
+	// Defaults to DefaultConcurrency.
+	Concurrency int
+	// CheckTimeout is the time a check can run for before it is considered
+	// failed. Defaults to DefaultCheckTimeout.
+	CheckTimeout time.Duration
+	// Distro is the distribution of the node the checks run on. It is used
+	// to tailor the remediation of failed rules.
+	Distro         check.Distro
+	mu             sync.Mutex
+	closableChecks []check.ClosableCheck
 }
 
 // ExecuteRules runs the rules that should be executed according to the facts,
 // and returns a collection of results. The number of results is not guaranteed
-// to equal the number of rules.
+// to equal the number of rules. The checks run concurrently, but the results
+// are in the same order as the rules. Exclusive checks run one at a time,
+// alongside the rest of the checks.
 func (e *Engine) ExecuteRules(rules []Rule, facts []string) ([]Result, error) {
-	results := []Result{}
+	// Map the rules to checks before running any of them
+	toRun := []Rule{}
+	checks := []check.Check{}
 	for _, rule := range rules {
 		if !shouldExecuteRule(rule, facts) {
 			continue
 		}
-
-		// Map the rule to a check
 		c, err := e.RuleCheckMapper.GetCheckForRule(rule)
 		if err != nil {
 			return nil, err
 		}
+		toRun = append(toRun, rule)
+		checks = append(checks, c)
+	}
 
-		// Run the check and report result
-		ok, err := c.Check()
-		res := Result{
-			Name:        rule.Name(),
-			Success:     ok,
-			Remediation: "",
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	results := make([]Result, len(checks))
+	var exclusive []int
+	for i, c := range checks {
+		if ec, ok := c.(check.ExclusiveCheck); ok && ec.IsExclusive() {
+			exclusive = append(exclusive, i)
 		}
-		if err != nil {
-			res.Error = err.Error()
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, i := range exclusive {
+			results[i] = e.runCheck(toRun[i], checks[i])
+		}
+	}()
+	sem := make(chan struct{}, concurrency)
+	for i, c := range checks {
+		if ec, ok := c.(check.ExclusiveCheck); ok && ec.IsExclusive() {
+			continue
 		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = e.runCheck(toRun[i], checks[i])
+		}(i)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// runCheck runs the check of the rule, and reports the result. If the check
+// does not complete within the timeout, it is reported as failed.
+func (e *Engine) runCheck(rule Rule, c check.Check) Result {
+	type checkResult struct {
+		ok  bool
+		err error
+	}
+	timeout := e.CheckTimeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	done := make(chan checkResult, 1)
+	start := time.Now()
+	go func() {
+		ok, err := c.Check()
+		done <- checkResult{ok: ok, err: err}
+	}()
 
-		// We update the closables as we go to avoid leaking closables
-		// in the event where we have to return an error from within the loop.
+	res := Result{
+		Name: rule.Name(),
+	}
+	select {
+	case r := <-done:
+		res.Duration = time.Since(start)
+		res.Success = r.ok
+		if r.err != nil {
+			res.Error = r.err.Error()
+		}
 		if closeable, ok := c.(check.ClosableCheck); ok && res.Success {
 			e.mu.Lock()
 			e.closableChecks = append(e.closableChecks, closeable)
 			e.mu.Unlock()
 		}
-
-		results = append(results, res)
+	case <-time.After(timeout):
+		res.Duration = time.Since(start)
+		res.Error = fmt.Sprintf("check did not complete within %v", timeout)
+		// The check keeps running in the background. Close it if it
+		// eventually succeeds, so that it does not hold on to resources.
+		go func() {
+			if r := <-done; r.ok {
+				if closeable, ok := c.(check.ClosableCheck); ok {
+					closeable.Close()
+				}
+			}
+		}()
 	}
-	return results, nil
+	if r, ok := rule.(Remediable); ok && !res.Success {
+		res.Remediation = r.Remediation(e.Distro)
+	}
+	return res
 }
 
 // CloseChecks that need to be closed
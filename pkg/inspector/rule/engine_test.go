@@ -2,8 +2,12 @@ package rule
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/inspector/check"
 )
@@ -189,6 +193,13 @@ func TestEngine(t *testing.T) {
 			continue
 		}
 
+		// The duration of the checks is not deterministic
+		for i := range result {
+			if result[i].Duration < 0 {
+				t.Errorf("got a negative duration for rule %q", result[i].Name)
+			}
+			result[i].Duration = 0
+		}
 		if !reflect.DeepEqual(test.expectedResults, result) {
 			t.Errorf("expected %+v, but got %+v", test.expectedResults, result)
 		}
@@ -252,3 +263,192 @@ func TestEngineClosableCheckFail(t *testing.T) {
 		t.Errorf("The check failed, and close was called on it")
 	}
 }
+
+type slowCheck struct {
+	delay   time.Duration
+	running *int32
+	max     *int32
+}
+
+func (c slowCheck) Check() (bool, error) {
+	n := atomic.AddInt32(c.running, 1)
+	for {
+		max := atomic.LoadInt32(c.max)
+		if n <= max || atomic.CompareAndSwapInt32(c.max, max, n) {
+			break
+		}
+	}
+	time.Sleep(c.delay)
+	atomic.AddInt32(c.running, -1)
+	return true, nil
+}
+
+type namedCheckMapper struct {
+	checks map[string]check.Check
+}
+
+func (m namedCheckMapper) GetCheckForRule(r Rule) (check.Check, error) {
+	return m.checks[r.Name()], nil
+}
+
+func TestEngineRunsChecksConcurrently(t *testing.T) {
+	var running, max int32
+	mapper := namedCheckMapper{checks: map[string]check.Check{}}
+	rules := []Rule{}
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("rule-%d", i)
+		mapper.checks[name] = slowCheck{delay: 20 * time.Millisecond, running: &running, max: &max}
+		rules = append(rules, fakeRule{name: name})
+	}
+	e := Engine{
+		RuleCheckMapper: mapper,
+		Concurrency:     3,
+	}
+	results, err := e.ExecuteRules(rules, []string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if max > 3 {
+		t.Errorf("expected at most 3 checks to run at the same time, but %d did", max)
+	}
+	if max < 2 {
+		t.Errorf("expected the checks to run concurrently, but at most %d ran at the same time", max)
+	}
+	if len(results) != len(rules) {
+		t.Fatalf("expected %d results, but got %d", len(rules), len(results))
+	}
+	for i, r := range results {
+		if r.Name != rules[i].Name() {
+			t.Errorf("expected result %d to be for rule %q, but got %q", i, rules[i].Name(), r.Name)
+		}
+		if !r.Success {
+			t.Errorf("expected rule %q to succeed", r.Name)
+		}
+		if r.Duration < 20*time.Millisecond {
+			t.Errorf("expected rule %q to take at least 20ms, but got %v", r.Name, r.Duration)
+		}
+	}
+}
+
+type hangingCheck struct {
+	release chan struct{}
+}
+
+func (c hangingCheck) Check() (bool, error) {
+	<-c.release
+	return true, nil
+}
+
+func TestEngineCheckTimeout(t *testing.T) {
+	hung := hangingCheck{release: make(chan struct{})}
+	defer close(hung.release)
+	mapper := namedCheckMapper{checks: map[string]check.Check{
+		"hung": hung,
+		"ok":   fakeCheck{ok: true},
+	}}
+	e := Engine{
+		RuleCheckMapper: mapper,
+		Concurrency:     1,
+		CheckTimeout:    50 * time.Millisecond,
+	}
+	results, err := e.ExecuteRules([]Rule{fakeRule{name: "hung"}, fakeRule{name: "ok"}}, []string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, but got %d", len(results))
+	}
+	if results[0].Success || !strings.Contains(results[0].Error, "did not complete within 50ms") {
+		t.Errorf("expected the hung check to fail with a timeout, but got %+v", results[0])
+	}
+	if !results[1].Success {
+		t.Errorf("expected the check after the hung check to succeed, but got %+v", results[1])
+	}
+}
+
+type remediableRule struct {
+	fakeRule
+}
+
+func (r remediableRule) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("fix it on %s", distro)
+}
+
+func TestEngineRemediation(t *testing.T) {
+	tests := []struct {
+		check               check.Check
+		expectedRemediation string
+	}{
+		{
+			check:               fakeCheck{ok: true},
+			expectedRemediation: "",
+		},
+		{
+			check:               fakeCheck{ok: false},
+			expectedRemediation: "fix it on ubuntu",
+		},
+		{
+			check:               fakeCheck{ok: false, err: errors.New("some error")},
+			expectedRemediation: "fix it on ubuntu",
+		},
+	}
+	for i, test := range tests {
+		e := Engine{
+			RuleCheckMapper: fakeRuleCheckMapper{check: test.check},
+			Distro:          check.Ubuntu,
+		}
+		results, err := e.ExecuteRules([]Rule{remediableRule{}}, []string{})
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		if results[0].Remediation != test.expectedRemediation {
+			t.Errorf("test %d: expected remediation %q, but got %q", i, test.expectedRemediation, results[0].Remediation)
+		}
+	}
+}
+
+type slowPackageManager struct {
+	running *int32
+	max     *int32
+}
+
+func (m slowPackageManager) IsInstalled(check.PackageQuery) (bool, error) {
+	return slowCheck{delay: 20 * time.Millisecond, running: m.running, max: m.max}.Check()
+}
+
+func (m slowPackageManager) IsAvailable(check.PackageQuery) (bool, error) {
+	return slowCheck{delay: 20 * time.Millisecond, running: m.running, max: m.max}.Check()
+}
+
+func TestEngineRunsPackageChecksOneAtATime(t *testing.T) {
+	var running, max int32
+	rules := []Rule{}
+	for i := 0; i < 4; i++ {
+		rules = append(rules, PackageDependency{PackageName: fmt.Sprintf("package-%d", i), PackageVersion: "1.0"})
+	}
+	e := Engine{
+		RuleCheckMapper: DefaultCheckMapper{
+			PackageManager:              slowPackageManager{running: &running, max: &max},
+			PackageInstallationDisabled: true,
+		},
+		Concurrency: 8,
+	}
+	results, err := e.ExecuteRules(rules, []string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if max != 1 {
+		t.Errorf("expected the package checks to run one at a time, but %d ran at the same time", max)
+	}
+	if len(results) != len(rules) {
+		t.Fatalf("expected %d results, but got %d", len(rules), len(results))
+	}
+	for i, r := range results {
+		if r.Name != rules[i].Name() {
+			t.Errorf("expected result %d to be for rule %q, but got %q", i, rules[i].Name(), r.Name)
+		}
+		if !r.Success {
+			t.Errorf("expected rule %q to succeed, but got error %q", r.Name, r.Error)
+		}
+	}
+}
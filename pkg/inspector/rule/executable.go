@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
 )
 
 // ExecutableInPath is a rule that ensures the given executable is in
@@ -32,3 +34,8 @@ func (e ExecutableInPath) Validate() []error {
 	}
 	return nil
 }
+
+// Remediation returns the steps to make the executable available
+func (e ExecutableInPath) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Install %s, and make sure it is in a directory listed in the PATH of the root user", e.Executable)
+}
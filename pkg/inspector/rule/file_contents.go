@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
 )
 
 // FileContentMatches is a rule that verifies that the contents of a file
@@ -41,3 +43,8 @@ func (f FileContentMatches) Validate() []error {
 	}
 	return nil
 }
+
+// Remediation returns the steps to satisfy the rule
+func (f FileContentMatches) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Update %s so that its contents match the regular expression %q", f.File, f.ContentRegex)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
 )
 
 // The FreeSpace rule declares that the given path must have enough free space
@@ -47,3 +49,8 @@ func (f FreeSpace) Validate() []error {
 func (f FreeSpace) minimumBytesAsUint64() (uint64, error) {
 	return strconv.ParseUint(f.MinimumBytes, 10, 0)
 }
+
+// Remediation returns the steps to satisfy the rule
+func (f FreeSpace) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Free up space in the filesystem of %s, or increase its size, so that at least %s bytes are available", f.Path, f.MinimumBytes)
+}
@@ -0,0 +1,81 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+)
+
+// HTTPEndpointReachable is a rule that ensures that an HTTP endpoint, such as
+// a container image registry or a package mirror, can be reached from the node
+type HTTPEndpointReachable struct {
+	Meta
+	URL string
+	// HTTPProxy is the proxy used for reaching http endpoints
+	HTTPProxy string
+	// HTTPSProxy is the proxy used for reaching https endpoints
+	HTTPSProxy string
+	// NoProxy is a comma-separated list of hosts, domains and CIDR blocks
+	// that are reached without going through the proxy
+	NoProxy string
+	// CACert is the PEM encoded CA certificate that is trusted when verifying
+	// the endpoint's certificate
+	CACert string
+	// ExpectedStatusCodes are the status codes the endpoint may respond with.
+	// Any 2xx status code is accepted if empty.
+	ExpectedStatusCodes []int
+	Timeout             string
+}
+
+// Name is the name of the rule
+func (h HTTPEndpointReachable) Name() string {
+	if len(h.ExpectedStatusCodes) == 0 {
+		return fmt.Sprintf("HTTP endpoint reachable: %s", h.URL)
+	}
+	return fmt.Sprintf("HTTP endpoint reachable: %s responds with %s", h.URL, check.StatusCodes(h.ExpectedStatusCodes))
+}
+
+// IsRemoteRule returns true if the rule is to be run from outside of the node
+func (h HTTPEndpointReachable) IsRemoteRule() bool { return false }
+
+// Validate the rule
+func (h HTTPEndpointReachable) Validate() []error {
+	errs := []error{}
+	if h.URL == "" {
+		errs = append(errs, errors.New("URL cannot be empty"))
+	} else if u, err := url.Parse(h.URL); err != nil {
+		errs = append(errs, fmt.Errorf("URL is invalid: %v", err))
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		errs = append(errs, errors.New("URL must start with http:// or https://"))
+	}
+	for _, p := range []string{h.HTTPProxy, h.HTTPSProxy} {
+		if p == "" {
+			continue
+		}
+		if _, err := url.Parse(p); err != nil {
+			errs = append(errs, fmt.Errorf("Proxy URL %q is invalid: %v", p, err))
+		}
+	}
+	for _, code := range h.ExpectedStatusCodes {
+		if code < 100 || code > 599 {
+			errs = append(errs, fmt.Errorf("Invalid status code %d specified", code))
+		}
+	}
+	if h.Timeout != "" {
+		if _, err := time.ParseDuration(h.Timeout); err != nil {
+			errs = append(errs, fmt.Errorf("Invalid duration provided %q", h.Timeout))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Remediation returns the steps to satisfy the rule
+func (h HTTPEndpointReachable) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Verify that %s is up, that the node can resolve its host name, and that the firewall and proxy settings allow the node to reach it. Verify the CA certificate if the error is about TLS", h.URL)
+}
@@ -0,0 +1,23 @@
+package rule
+
+import "testing"
+
+func TestHTTPEndpointReachableRuleValidation(t *testing.T) {
+	h := HTTPEndpointReachable{}
+	if errs := h.Validate(); len(errs) != 1 {
+		t.Errorf("expected 1 error, but got %d", len(errs))
+	}
+	h.URL = "registry.example.com:5000"
+	h.ExpectedStatusCodes = []int{200, 1000}
+	h.Timeout = "5"
+	if errs := h.Validate(); len(errs) != 3 {
+		t.Errorf("expected 3 errors, but got %d", len(errs))
+	}
+	h.URL = "https://registry.example.com:5000/v2/"
+	h.ExpectedStatusCodes = []int{200, 401}
+	h.Timeout = "5s"
+	h.HTTPSProxy = "http://proxy.example.com:3128"
+	if errs := h.Validate(); len(errs) != 0 {
+		t.Errorf("expected 0 errors, but got %d", len(errs))
+	}
+}
@@ -0,0 +1,41 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+)
+
+// KernelModuleLoaded is a rule that ensures that the kernel module
+// is loaded, or built into the kernel
+type KernelModuleLoaded struct {
+	Meta
+	Module string
+}
+
+// Name is the name of the rule
+func (k KernelModuleLoaded) Name() string {
+	return fmt.Sprintf("Kernel module %s is loaded", k.Module)
+}
+
+// IsRemoteRule returns true if the rule is to be run from outside of the node
+func (k KernelModuleLoaded) IsRemoteRule() bool { return false }
+
+// Validate the rule
+func (k KernelModuleLoaded) Validate() []error {
+	if k.Module == "" {
+		return []error{errors.New("Module cannot be empty")}
+	}
+	r := regexp.MustCompile("^[a-zA-Z0-9_-]+$")
+	if !r.MatchString(k.Module) {
+		return []error{fmt.Errorf("Module %q is not a valid kernel module name", k.Module)}
+	}
+	return nil
+}
+
+// Remediation returns the steps to load the module
+func (k KernelModuleLoaded) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Load the module by running \"modprobe %s\", and add it to /etc/modules-load.d/%s.conf so that it is loaded on boot", k.Module, k.Module)
+}
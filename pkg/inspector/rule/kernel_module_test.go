@@ -0,0 +1,18 @@
+package rule
+
+import "testing"
+
+func TestKernelModuleLoadedRuleValidation(t *testing.T) {
+	k := KernelModuleLoaded{}
+	if errs := k.Validate(); len(errs) != 1 {
+		t.Errorf("expected 1 error, but got %d", len(errs))
+	}
+	k.Module = "br_netfilter; rm -rf /"
+	if errs := k.Validate(); len(errs) != 1 {
+		t.Errorf("expected 1 error, but got %d", len(errs))
+	}
+	k.Module = "br_netfilter"
+	if errs := k.Validate(); len(errs) != 0 {
+		t.Errorf("expected 0 errors, but got %d", len(errs))
+	}
+}
@@ -0,0 +1,35 @@
+package rule
+
+import (
+	"fmt"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+)
+
+// MinimumCPU is a rule that ensures that the node has at least
+// the given number of CPU cores
+type MinimumCPU struct {
+	Meta
+	Cores int
+}
+
+// Name is the name of the rule
+func (m MinimumCPU) Name() string {
+	return fmt.Sprintf("Node has at least %d CPU cores", m.Cores)
+}
+
+// IsRemoteRule returns true if the rule is to be run from outside of the node
+func (m MinimumCPU) IsRemoteRule() bool { return false }
+
+// Validate the rule
+func (m MinimumCPU) Validate() []error {
+	if m.Cores < 1 {
+		return []error{fmt.Errorf("Cores must be greater than 0, but got %d", m.Cores)}
+	}
+	return nil
+}
+
+// Remediation returns the steps to satisfy the rule
+func (m MinimumCPU) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Use a node with at least %d CPU cores", m.Cores)
+}
@@ -0,0 +1,44 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+)
+
+// MinimumMemory is a rule that ensures that the node has at least
+// the given amount of memory
+type MinimumMemory struct {
+	Meta
+	MinimumBytes string
+}
+
+// Name is the name of the rule
+func (m MinimumMemory) Name() string {
+	return fmt.Sprintf("Node has at least %s bytes of memory", m.MinimumBytes)
+}
+
+// IsRemoteRule returns true if the rule is to be run from outside of the node
+func (m MinimumMemory) IsRemoteRule() bool { return false }
+
+// Validate the rule
+func (m MinimumMemory) Validate() []error {
+	if m.MinimumBytes == "" {
+		return []error{errors.New("MinimumBytes cannot be empty")}
+	}
+	if _, err := m.minimumBytesAsUint64(); err != nil {
+		return []error{fmt.Errorf("MinimumBytes contains an invalid unsigned integer: %v", err)}
+	}
+	return nil
+}
+
+func (m MinimumMemory) minimumBytesAsUint64() (uint64, error) {
+	return strconv.ParseUint(m.MinimumBytes, 10, 0)
+}
+
+// Remediation returns the steps to satisfy the rule
+func (m MinimumMemory) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Use a node with at least %s bytes of memory", m.MinimumBytes)
+}
@@ -0,0 +1,29 @@
+package rule
+
+import "testing"
+
+func TestMinimumMemoryRuleValidation(t *testing.T) {
+	m := MinimumMemory{}
+	if errs := m.Validate(); len(errs) != 1 {
+		t.Errorf("expected 1 error, but got %d", len(errs))
+	}
+	m.MinimumBytes = "2GB"
+	if errs := m.Validate(); len(errs) != 1 {
+		t.Errorf("expected 1 error, but got %d", len(errs))
+	}
+	m.MinimumBytes = "2000000000"
+	if errs := m.Validate(); len(errs) != 0 {
+		t.Errorf("expected 0 errors, but got %d", len(errs))
+	}
+}
+
+func TestMinimumCPURuleValidation(t *testing.T) {
+	m := MinimumCPU{}
+	if errs := m.Validate(); len(errs) != 1 {
+		t.Errorf("expected 1 error, but got %d", len(errs))
+	}
+	m.Cores = 2
+	if errs := m.Validate(); len(errs) != 0 {
+		t.Errorf("expected 0 errors, but got %d", len(errs))
+	}
+}
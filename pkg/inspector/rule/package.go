@@ -3,6 +3,8 @@ package rule
 import (
 	"errors"
 	"fmt"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
 )
 
 // The PackageDependency rule declares a dependency on a software package
@@ -36,3 +38,11 @@ func (p PackageDependency) Validate() []error {
 	}
 	return nil
 }
+
+// Remediation returns the command that installs the package
+func (p PackageDependency) Remediation(distro check.Distro) string {
+	if cmd := installPackageCommand(distro, p.PackageName, p.PackageVersion); cmd != "" {
+		return fmt.Sprintf("Install the package by running %q", cmd)
+	}
+	return fmt.Sprintf("Install %s using the package manager of the node", p.Name())
+}
@@ -3,6 +3,8 @@ package rule
 import (
 	"errors"
 	"fmt"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
 )
 
 // The PackageNotInstalled validates that a specified package in not installed.
@@ -36,3 +38,18 @@ func (p PackageNotInstalled) Validate() []error {
 	}
 	return nil
 }
+
+// Remediation returns the command that removes the package, or installs the
+// acceptable version of the package
+func (p PackageNotInstalled) Remediation(distro check.Distro) string {
+	if p.AcceptablePackageVersion != "" {
+		if cmd := installPackageCommand(distro, p.PackageName, p.AcceptablePackageVersion); cmd != "" {
+			return fmt.Sprintf("Remove the package by running %q, or install the acceptable version by running %q", removePackageCommand(distro, p.PackageName), cmd)
+		}
+		return fmt.Sprintf("Remove package %q, or install version %q, using the package manager of the node", p.PackageName, p.AcceptablePackageVersion)
+	}
+	if cmd := removePackageCommand(distro, p.PackageName); cmd != "" {
+		return fmt.Sprintf("Remove the package by running %q", cmd)
+	}
+	return fmt.Sprintf("Remove package %q using the package manager of the node", p.PackageName)
+}
@@ -1,6 +1,10 @@
 package rule
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+)
 
 func TestPackageDependencyRuleValidation(t *testing.T) {
 	p := PackageDependency{}
@@ -21,3 +25,76 @@ func TestPackageDependencyRuleValidation(t *testing.T) {
 		t.Errorf("expected to be valid, but got %d", len(errs))
 	}
 }
+
+func TestPackageDependencyRemediation(t *testing.T) {
+	tests := []struct {
+		rule     PackageDependency
+		distro   check.Distro
+		expected string
+	}{
+		{
+			rule:     PackageDependency{PackageName: "docker-engine", PackageVersion: "1.12.6-1.el7.centos"},
+			distro:   check.CentOS,
+			expected: `Install the package by running "yum install -y docker-engine-1.12.6-1.el7.centos"`,
+		},
+		{
+			rule:     PackageDependency{PackageName: "docker-engine", PackageVersion: "1.12.6-1.el7.centos"},
+			distro:   check.RHEL,
+			expected: `Install the package by running "yum install -y docker-engine-1.12.6-1.el7.centos"`,
+		},
+		{
+			rule:     PackageDependency{PackageName: "docker-engine", PackageVersion: "1.12.6-0~ubuntu-xenial"},
+			distro:   check.Ubuntu,
+			expected: `Install the package by running "apt-get install -y docker-engine=1.12.6-0~ubuntu-xenial"`,
+		},
+		{
+			rule:     PackageDependency{PackageName: "nfs-utils"},
+			distro:   check.CentOS,
+			expected: `Install the package by running "yum install -y nfs-utils"`,
+		},
+		{
+			rule:     PackageDependency{PackageName: "nfs-utils"},
+			distro:   check.Unsupported,
+			expected: `Install Package "nfs-utils" using the package manager of the node`,
+		},
+	}
+	for i, test := range tests {
+		if got := test.rule.Remediation(test.distro); got != test.expected {
+			t.Errorf("test %d: expected %q, but got %q", i, test.expected, got)
+		}
+	}
+}
+
+func TestPackageNotInstalledRemediation(t *testing.T) {
+	tests := []struct {
+		rule     PackageNotInstalled
+		distro   check.Distro
+		expected string
+	}{
+		{
+			rule:     PackageNotInstalled{PackageName: "docker"},
+			distro:   check.CentOS,
+			expected: `Remove the package by running "yum remove -y docker"`,
+		},
+		{
+			rule:     PackageNotInstalled{PackageName: "docker.io"},
+			distro:   check.Ubuntu,
+			expected: `Remove the package by running "apt-get remove -y docker.io"`,
+		},
+		{
+			rule:     PackageNotInstalled{PackageName: "docker-ce", AcceptablePackageVersion: "17.03.2~ce-0~ubuntu-xenial"},
+			distro:   check.Ubuntu,
+			expected: `Remove the package by running "apt-get remove -y docker-ce", or install the acceptable version by running "apt-get install -y docker-ce=17.03.2~ce-0~ubuntu-xenial"`,
+		},
+		{
+			rule:     PackageNotInstalled{PackageName: "docker"},
+			distro:   check.Unsupported,
+			expected: `Remove package "docker" using the package manager of the node`,
+		},
+	}
+	for i, test := range tests {
+		if got := test.rule.Remediation(test.distro); got != test.expected {
+			t.Errorf("test %d: expected %q, but got %q", i, test.expected, got)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package rule
 import (
 	"errors"
 	"fmt"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
 )
 
 // PythonVersion rule for checking the host's python version
@@ -23,3 +25,11 @@ func (p Python2Version) Validate() []error {
 	}
 	return nil
 }
+
+// Remediation returns the steps to install a supported version of python
+func (p Python2Version) Remediation(distro check.Distro) string {
+	if cmd := installPackageCommand(distro, "python", ""); cmd != "" {
+		return fmt.Sprintf("Install python 2 by running %q, and make sure the version is one of %v", cmd, p.SupportedVersions)
+	}
+	return fmt.Sprintf("Install python 2 with one of the versions %v", p.SupportedVersions)
+}
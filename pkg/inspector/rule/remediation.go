@@ -0,0 +1,45 @@
+package rule
+
+import (
+	"fmt"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+)
+
+// Remediable is implemented by rules that can suggest how to fix the node
+// when the rule is not satisfied
+type Remediable interface {
+	// Remediation returns the steps that can be taken to satisfy the rule on
+	// a node running the given distribution
+	Remediation(distro check.Distro) string
+}
+
+// returns the command that installs the package on the distro, or the empty
+// string if the distro's package manager is unknown
+func installPackageCommand(distro check.Distro, name, version string) string {
+	switch distro.Family() {
+	case check.RHELFamily:
+		if version != "" {
+			return fmt.Sprintf("yum install -y %s-%s", name, version)
+		}
+		return fmt.Sprintf("yum install -y %s", name)
+	case check.DebianFamily:
+		if version != "" {
+			return fmt.Sprintf("apt-get install -y %s=%s", name, version)
+		}
+		return fmt.Sprintf("apt-get install -y %s", name)
+	}
+	return ""
+}
+
+// returns the command that removes the package on the distro, or the empty
+// string if the distro's package manager is unknown
+func removePackageCommand(distro check.Distro, name string) string {
+	switch distro.Family() {
+	case check.RHELFamily:
+		return fmt.Sprintf("yum remove -y %s", name)
+	case check.DebianFamily:
+		return fmt.Sprintf("apt-get remove -y %s", name)
+	}
+	return ""
+}
@@ -14,6 +14,11 @@ import (
 
 This rule will be executed when the node has these facts:
   ("etcd" OR "master" OR "worker" OR "ingress" OR "storage") AND ("rhel" OR "centos")
+
+Besides its roles, a node has a fact for its distribution, such as "ubuntu",
+"debian", "rhel", "centos" or "ol", and a fact for the family of the
+distribution, which is either "debian-family" or "rhel-family". Rules that apply
+to all the distributions in a family use the family fact.
 */
 
 // DefaultRuleSet is the list of rules that are built into the inspector
@@ -22,6 +27,27 @@ const defaultRuleSet = `---
   path: /
   minimumBytes: 1000000000
 
+# Hardware capacity of the nodes
+- kind: MinimumCPU
+  when:
+  - ["master"]
+  cores: 2
+- kind: MinimumMemory
+  when:
+  - ["etcd", "master"]
+  minimumBytes: 1500000000
+- kind: MinimumMemory
+  when:
+  - ["worker", "ingress", "storage"]
+  minimumBytes: 900000000
+
+# etcd requires the 99th percentile of fsync latency to be below 10ms
+- kind: DiskFsyncLatency
+  when:
+  - ["etcd"]
+  path: /var/lib/etcd_k8s
+  maximumLatency: 10ms
+
 # Python 2.5+ is installed on all nodes
 # This is required by ansible
 - kind: Python2Version
@@ -45,6 +71,32 @@ const defaultRuleSet = `---
   - ["master", "worker", "ingress", "storage"]
   executable: iptables-restore
 
+# Kernel modules and parameters required for pod networking
+- kind: KernelModuleLoaded
+  when:
+  - ["master", "worker", "ingress", "storage"]
+  module: br_netfilter
+- kind: SysctlValue
+  when:
+  - ["master", "worker", "ingress", "storage"]
+  parameter: net.ipv4.ip_forward
+  value: "1"
+
+# The kubelet fails to start when swap is enabled, unless --fail-swap-on=false is set
+- kind: SwapDisabled
+  when:
+  - ["master", "worker", "ingress", "storage"]
+  - ["fail-swap-on"]
+
+# SELinux must not be enforcing
+- kind: SELinuxMode
+  when:
+  - ["master", "worker", "ingress", "storage"]
+  - ["rhel-family"]
+  acceptableModes:
+  - permissive
+  - disabled
+
 # Ports used by etcd are available
 - kind: TCPPortAvailable
   when: 
@@ -288,193 +340,136 @@ const defaultRuleSet = `---
   port: 38467
   timeout: 5s
 
+# Packages on the Debian family of distributions. Docker is only checked on Ubuntu,
+# as the version of the package is specific to the distribution.
 - kind: PackageDependency
-  when: 
+  when:
   - ["etcd", "master", "worker", "ingress", "storage"]
   - ["ubuntu"]
   packageName: docker-ce
   packageVersion: 17.03.2~ce-0~ubuntu-xenial
 - kind: PackageDependency
-  when: 
+  when:
   - ["master", "worker", "ingress", "storage"]
-  - ["ubuntu"]
+  - ["debian-family"]
   packageName: kubelet
   packageVersion: 1.9.0-00
 - kind: PackageDependency
-  when: 
+  when:
   - ["master", "worker", "ingress", "storage"]
-  - ["ubuntu"]
+  - ["debian-family"]
   packageName: nfs-common
 - kind: PackageDependency
-  when: 
+  when:
   - ["master", "worker", "ingress", "storage"]
-  - ["ubuntu"]
+  - ["debian-family"]
   packageName: kubectl
   packageVersion: 1.9.0-00
 # https://docs.docker.com/engine/installation/linux/docker-ee/ubuntu/#uninstall-old-versions
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["ubuntu"]
+#   - ["debian-family"]
 #   packageName: docker
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["ubuntu"]
+#   - ["debian-family"]
 #   packageName: docker-engine
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
 #   - ["ubuntu"]
 #   packageName: docker-ce
 #   acceptablePackageVersion: 17.03.2~ce-0~ubuntu-xenial
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["ubuntu"]
+#   - ["debian-family"]
 #   packageName: docker-ee
 
+# Packages on the RHEL family of distributions, such as CentOS and Oracle Linux.
+# Docker is only checked on RHEL and CentOS, as the version of the package is
+# specific to the distribution.
 - kind: PackageDependency
-  when: 
+  when:
   - ["etcd", "master", "worker", "ingress", "storage"]
-  - ["centos"]
+  - ["rhel", "centos"]
   packageName: docker-ce
   packageVersion: 17.03.2.ce-1.el7.centos
 - kind: PackageDependency
-  when: 
+  when:
   - ["master", "worker", "ingress", "storage"]
-  - ["centos"]
+  - ["rhel-family"]
   packageName: kubelet
   packageVersion: 1.9.0-0
 - kind: PackageDependency
-  when: 
+  when:
   - ["master", "worker", "ingress", "storage"]
-  - ["centos"]
+  - ["rhel-family"]
   packageName: nfs-utils
 - kind: PackageDependency
-  when: 
+  when:
   - ["master", "worker", "ingress", "storage"]
-  - ["centos"]
+  - ["rhel-family"]
   packageName: kubectl
   packageVersion: 1.9.0-0
 # https://docs.docker.com/engine/installation/linux/docker-ee/centos/
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["centos"]
+#   - ["rhel-family"]
 #   packageName: docker
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["centos"]
+#   - ["rhel-family"]
 #   packageName: docker-common
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["centos"]
+#   - ["rhel-family"]
 #   packageName: docker-selinux
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["centos"]
+#   - ["rhel-family"]
 #   packageName: docker-engine-selinux
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["centos"]
+#   - ["rhel-family"]
 #   packageName: docker-engine
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["centos"]
+#   - ["rhel-family"]
 #   packageName: docker-ce
 #   acceptablePackageVersion: 17.03.2.ce-1.el7.centos
 # - kind: PackageNotInstalled
-#   when: 
+#   when:
 #   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["centos"]
-#   packageName: docker-ee
-
-- kind: PackageDependency
-  when: 
-  - ["etcd", "master", "worker", "ingress", "storage"]
-  - ["rhel"]
-  packageName: docker-ce
-  packageVersion: 17.03.2.ce-1.el7.centos
-- kind: PackageDependency
-  when: 
-  - [master", "worker", "ingress", "storage"]
-  - ["rhel"]
-  packageName: kubelet
-  packageVersion: 1.9.0-0
-- kind: PackageDependency
-  when: 
-  - [master", "worker", "ingress", "storage"]
-  - ["rhel"]
-  packageName: nfs-utils
-- kind: PackageDependency
-  when: 
-  - ["master", "worker", "ingress", "storage"]
-  - ["rhel"]
-  packageName: kubectl
-  packageVersion: 1.9.0-0
-# https://docs.docker.com/engine/installation/linux/docker-ee/rhel/#os-requirements
-# - kind: PackageNotInstalled
-#   when: 
-#   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["rhel"]
-#   packageName: docker
-# - kind: PackageNotInstalled
-#   when: 
-#   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["rhel"]
-#   packageName: docker-common
-# - kind: PackageNotInstalled
-#   when: 
-#   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["rhel"]
-#   packageName: docker-selinux
-# - kind: PackageNotInstalled
-#   when: 
-#   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["rhel"]
-#   packageName: docker-engine-selinux
-# - kind: PackageNotInstalled
-#   when: 
-#   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["rhel"]
-#   packageName: docker-engine
-# - kind: PackageNotInstalled
-#   when: 
-#   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["rhel"]
-#   packageName: docker-ce
-#   acceptablePackageVersion: 17.03.2.ce-1.el7.centos
-# - kind: PackageNotInstalled
-#   when: 
-#   - ["etcd", "master", "worker", "ingress", "storage"]
-#   - ["rhel"]
+#   - ["rhel-family"]
 #   packageName: docker-ee
 
 # Gluster packages
 - kind: PackageDependency
-  when: 
+  when:
   - ["storage"]
-  - ["centos"]
+  - ["rhel-family"]
   packageName: glusterfs-server
   packageVersion: 3.8.15-2.el7
 - kind: PackageDependency
-  when: 
+  when:
   - ["storage"]
-  - ["rhel"]
-  packageName: glusterfs-server
-  packageVersion: 3.8.15-2.el7
-- kind: PackageDependency
-  when: 
-  - ["storage"] 
   - ["ubuntu"]
   packageName: glusterfs-server
   packageVersion: 3.8.15-ubuntu1~xenial1
+- kind: PackageDependency
+  when:
+  - ["storage"]
+  - ["debian"]
+  packageName: glusterfs-server
 `
 
 const upgradeRuleSet = `---
@@ -482,97 +477,77 @@ const upgradeRuleSet = `---
   path: /
   minimumBytes: 1000000000
 
+# Packages on the Debian family of distributions. Docker is only checked on Ubuntu,
+# as the version of the package is specific to the distribution.
 - kind: PackageDependency
-  when: 
+  when:
   - ["etcd", "master", "worker", "ingress", "storage"]
   - ["ubuntu"]
   packageName: docker-ce
   packageVersion: 17.03.2~ce-0~ubuntu-xenial
 - kind: PackageDependency
-  when: 
+  when:
   - ["master", "worker", "ingress", "storage"]
-  - ["ubuntu"]
+  - ["debian-family"]
   packageName: kubelet
   packageVersion: 1.9.0-00
 - kind: PackageDependency
-  when: 
+  when:
   - ["master", "worker", "ingress", "storage"]
-  - ["ubuntu"]
+  - ["debian-family"]
   packageName: nfs-common
 - kind: PackageDependency
-  when: 
+  when:
   - ["master", "worker", "ingress", "storage"]
-  - ["ubuntu"]
+  - ["debian-family"]
   packageName: kubectl
   packageVersion: 1.9.0-00
 
+# Packages on the RHEL family of distributions, such as CentOS and Oracle Linux.
+# Docker is only checked on RHEL and CentOS, as the version of the package is
+# specific to the distribution.
 - kind: PackageDependency
-  when: 
-  - ["etcd", "master", "worker", "ingress", "storage"]
-  - ["centos"]
-  packageName: docker-ce
-  packageVersion: 17.03.2.ce-1.el7.centos
-- kind: PackageDependency
-  when: 
-  - ["master", "worker", "ingress, storage"]
-  - ["centos"]
-  packageName: kubelet
-  packageVersion: 1.9.0-0
-- kind: PackageDependency
-  when: 
-  - ["master", "worker", "ingress, storage"]
-  - ["centos"]
-  packageName: nfs-utils
-- kind: PackageDependency
-  when: 
-  - ["master", "worker", "ingress, storage"]
-  - ["centos"]
-  packageName: kubectl
-  packageVersion: 1.9.0-0
-
-- kind: PackageDependency
-  when: 
+  when:
   - ["etcd", "master", "worker", "ingress", "storage"]
-  - ["rhel"]
+  - ["rhel", "centos"]
   packageName: docker-ce
   packageVersion: 17.03.2.ce-1.el7.centos
 - kind: PackageDependency
-  when: 
-  - ["master", "worker", "ingress, storage"]
-  - ["rhel"]
+  when:
+  - ["master", "worker", "ingress", "storage"]
+  - ["rhel-family"]
   packageName: kubelet
   packageVersion: 1.9.0-0
 - kind: PackageDependency
-  when: 
-  - ["master", "worker", "ingress, storage"]
-  - ["rhel"]
+  when:
+  - ["master", "worker", "ingress", "storage"]
+  - ["rhel-family"]
   packageName: nfs-utils
 - kind: PackageDependency
-  when: 
-  - ["master", "worker", "ingress, storage"]
-  - ["rhel"]
+  when:
+  - ["master", "worker", "ingress", "storage"]
+  - ["rhel-family"]
   packageName: kubectl
   packageVersion: 1.9.0-0
 
 # Gluster packages
 - kind: PackageDependency
-  when: 
-  - ["storage"] 
-  - ["ubuntu"]
+  when:
+  - ["storage"]
+  - ["rhel-family"]
   packageName: glusterfs-server
-  packageVersion: 3.8.15-ubuntu1~xenial1
+  packageVersion: 3.8.15-2.el7
 - kind: PackageDependency
-  when: 
-  - ["storage"] 
-  - ["centos"]
+  when:
+  - ["storage"]
+  - ["ubuntu"]
   packageName: glusterfs-server
-  packageVersion: 3.8.15-2.el7
+  packageVersion: 3.8.15-ubuntu1~xenial1
 - kind: PackageDependency
-  when: 
-  - ["storage"] 
-  - ["rhel"]
+  when:
+  - ["storage"]
+  - ["debian"]
   packageName: glusterfs-server
-  packageVersion: 3.8.15-2.el7
 `
 
 // DefaultRules returns the list of rules that are built into the inspector
@@ -595,6 +570,7 @@ func DumpDefaultRules(writer io.Writer) error {
 	return nil
 }
 
+// UpgradeRules returns the list of rules that are run before an upgrade
 func UpgradeRules() []Rule {
 	rules, err := UnmarshalRulesYAML([]byte(upgradeRuleSet))
 	if err != nil {
@@ -604,3 +580,9 @@ func UpgradeRules() []Rule {
 	}
 	return rules
 }
+
+// DumpUpgradeRules writes the upgrade rule set to a file
+func DumpUpgradeRules(writer io.Writer) error {
+	_, err := io.Copy(writer, strings.NewReader(upgradeRuleSet))
+	return err
+}
@@ -1,6 +1,10 @@
 package rule
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+)
 
 func TestDefaultRules(t *testing.T) {
 	// This will panic if there are errors in the default rule
@@ -21,3 +25,84 @@ func TestUpgradeRules(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultRulesAreRemediable(t *testing.T) {
+	rules := append(DefaultRules(), UpgradeRules()...)
+	for _, r := range rules {
+		if _, ok := r.(Remediable); !ok {
+			t.Errorf("rule %T does not provide a remediation", r)
+		}
+	}
+}
+
+func TestDefaultRulesSelectPackagesPerDistro(t *testing.T) {
+	tests := []struct {
+		distro   check.Distro
+		expected map[string]int
+	}{
+		{distro: check.Ubuntu, expected: map[string]int{"docker-ce": 1, "kubelet": 1, "kubectl": 1}},
+		{distro: check.RHEL, expected: map[string]int{"docker-ce": 1, "kubelet": 1, "kubectl": 1}},
+		{distro: check.CentOS, expected: map[string]int{"docker-ce": 1, "kubelet": 1, "kubectl": 1}},
+		// The version of the docker package is not known on these distributions
+		{distro: check.Debian, expected: map[string]int{"docker-ce": 0, "kubelet": 1, "kubectl": 1}},
+		{distro: check.OracleLinux, expected: map[string]int{"docker-ce": 0, "kubelet": 1, "kubectl": 1}},
+	}
+	for _, rules := range [][]Rule{DefaultRules(), UpgradeRules()} {
+		for _, test := range tests {
+			facts := append([]string{"worker"}, test.distro.Facts()...)
+			count := map[string]int{}
+			for _, r := range rules {
+				if p, ok := r.(PackageDependency); ok && shouldExecuteRule(r, facts) {
+					count[p.PackageName]++
+				}
+			}
+			for pkg, expected := range test.expected {
+				if count[pkg] != expected {
+					t.Errorf("expected %d rule(s) for package %q on %s, but got %d", expected, pkg, test.distro, count[pkg])
+				}
+			}
+		}
+	}
+}
+
+func TestDefaultRulesUseKnownFacts(t *testing.T) {
+	known := map[string]bool{"etcd": true, "master": true, "worker": true, "ingress": true, "storage": true, "disconnected": true, "fail-swap-on": true}
+	for _, d := range []check.Distro{check.Ubuntu, check.Debian, check.RHEL, check.CentOS, check.OracleLinux} {
+		for _, f := range d.Facts() {
+			known[f] = true
+		}
+	}
+	for _, rules := range [][]Rule{DefaultRules(), UpgradeRules()} {
+		for _, r := range rules {
+			for _, whenSlice := range r.GetRuleMeta().When {
+				for _, fact := range whenSlice {
+					if !known[fact] {
+						t.Errorf("rule %s depends on the unknown fact %q", r.Name(), fact)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDefaultRulesCheckSwapWhenKubeletFailsOnSwap(t *testing.T) {
+	tests := []struct {
+		facts    []string
+		expected int
+	}{
+		{facts: []string{"worker", "fail-swap-on"}, expected: 1},
+		{facts: []string{"worker"}, expected: 0},
+		{facts: []string{"etcd", "fail-swap-on"}, expected: 0},
+	}
+	for _, test := range tests {
+		count := 0
+		for _, r := range DefaultRules() {
+			if _, ok := r.(SwapDisabled); ok && shouldExecuteRule(r, test.facts) {
+				count++
+			}
+		}
+		if count != test.expected {
+			t.Errorf("%v: expected %d swap rule(s), but got %d", test.facts, test.expected, count)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+)
+
+var selinuxModes = []string{"enforcing", "permissive", "disabled"}
+
+// SELinuxMode is a rule that ensures that SELinux is in one of the
+// acceptable modes. A node without SELinux is considered to be in
+// the disabled mode.
+type SELinuxMode struct {
+	Meta
+	AcceptableModes []string
+}
+
+// Name is the name of the rule
+func (s SELinuxMode) Name() string {
+	return fmt.Sprintf("SELinux mode is one of %v", s.AcceptableModes)
+}
+
+// IsRemoteRule returns true if the rule is to be run from outside of the node
+func (s SELinuxMode) IsRemoteRule() bool { return false }
+
+// Validate the rule
+func (s SELinuxMode) Validate() []error {
+	if len(s.AcceptableModes) == 0 {
+		return []error{errors.New("List of acceptable modes is empty")}
+	}
+	errs := []error{}
+	for _, m := range s.AcceptableModes {
+		if !isSELinuxMode(m) {
+			errs = append(errs, fmt.Errorf("%q is not a valid SELinux mode. Options are %v", m, selinuxModes))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func isSELinuxMode(mode string) bool {
+	for _, m := range selinuxModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Remediation returns the steps to change the SELinux mode
+func (s SELinuxMode) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Set SELinux to permissive by running \"setenforce 0\", and set SELINUX to one of %v in /etc/selinux/config so that it persists on boot", s.AcceptableModes)
+}
@@ -0,0 +1,18 @@
+package rule
+
+import "testing"
+
+func TestSELinuxModeRuleValidation(t *testing.T) {
+	s := SELinuxMode{}
+	if errs := s.Validate(); len(errs) != 1 {
+		t.Errorf("expected 1 error, but got %d", len(errs))
+	}
+	s.AcceptableModes = []string{"permissive", "off"}
+	if errs := s.Validate(); len(errs) != 1 {
+		t.Errorf("expected 1 error, but got %d", len(errs))
+	}
+	s.AcceptableModes = []string{"permissive", "disabled"}
+	if errs := s.Validate(); len(errs) != 0 {
+		t.Errorf("expected 0 errors, but got %d", len(errs))
+	}
+}
@@ -0,0 +1,24 @@
+package rule
+
+import "github.com/apprenda/kismatic/pkg/inspector/check"
+
+// SwapDisabled is a rule that ensures that there is no swap enabled on the node
+type SwapDisabled struct {
+	Meta
+}
+
+// Name is the name of the rule
+func (s SwapDisabled) Name() string {
+	return "Swap is disabled"
+}
+
+// IsRemoteRule returns true if the rule is to be run from outside of the node
+func (s SwapDisabled) IsRemoteRule() bool { return false }
+
+// Validate the rule
+func (s SwapDisabled) Validate() []error { return nil }
+
+// Remediation returns the steps to disable swap
+func (s SwapDisabled) Remediation(distro check.Distro) string {
+	return "Disable swap by running \"swapoff -a\", and remove the swap entries from /etc/fstab so that it stays disabled on boot. Alternatively, set the kubelet option fail-swap-on to false"
+}
@@ -0,0 +1,48 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
+)
+
+// SysctlValue is a rule that ensures that the kernel parameter
+// is set to the given value
+type SysctlValue struct {
+	Meta
+	// Parameter is the name of the kernel parameter, such as net.ipv4.ip_forward
+	Parameter string
+	Value     string
+}
+
+// Name is the name of the rule
+func (s SysctlValue) Name() string {
+	return fmt.Sprintf("Sysctl %s is set to %s", s.Parameter, s.Value)
+}
+
+// IsRemoteRule returns true if the rule is to be run from outside of the node
+func (s SysctlValue) IsRemoteRule() bool { return false }
+
+// Validate the rule
+func (s SysctlValue) Validate() []error {
+	errs := []error{}
+	if s.Parameter == "" {
+		errs = append(errs, errors.New("Parameter cannot be empty"))
+	} else if r := regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`); !r.MatchString(s.Parameter) {
+		errs = append(errs, fmt.Errorf("Parameter %q is not a valid kernel parameter name", s.Parameter))
+	}
+	if s.Value == "" {
+		errs = append(errs, errors.New("Value cannot be empty"))
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Remediation returns the steps to set the kernel parameter
+func (s SysctlValue) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Set the parameter by running \"sysctl -w %s=%s\", and add \"%s = %s\" to a file in /etc/sysctl.d so that it is set on boot", s.Parameter, s.Value, s.Parameter, s.Value)
+}
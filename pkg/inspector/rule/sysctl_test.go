@@ -0,0 +1,22 @@
+package rule
+
+import "testing"
+
+func TestSysctlValueRuleValidation(t *testing.T) {
+	s := SysctlValue{}
+	if errs := s.Validate(); len(errs) != 2 {
+		t.Errorf("expected 2 errors, but got %d", len(errs))
+	}
+	s.Parameter = "../../etc/passwd"
+	if errs := s.Validate(); len(errs) != 2 {
+		t.Errorf("expected 2 errors, but got %d", len(errs))
+	}
+	s.Parameter = "net.bridge.bridge-nf-call-iptables"
+	if errs := s.Validate(); len(errs) != 1 {
+		t.Errorf("expected 1 error, but got %d", len(errs))
+	}
+	s.Value = "1"
+	if errs := s.Validate(); len(errs) != 0 {
+		t.Errorf("expected 0 errors, but got %d", len(errs))
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/apprenda/kismatic/pkg/inspector/check"
 )
 
 // TCPPortAvailable is a rule that ensures that a given port is available
@@ -73,3 +75,19 @@ func (p TCPPortAccessible) Validate() []error {
 	}
 	return nil
 }
+
+// Remediation returns the steps to free up the port
+func (p TCPPortAvailable) Remediation(distro check.Distro) string {
+	return fmt.Sprintf("Find the process listening on the port by running \"ss -tlnp 'sport = :%d'\", and stop it. Only %s may listen on port %d", p.Port, p.ProcName, p.Port)
+}
+
+// Remediation returns the steps to open up the port in the firewall
+func (p TCPPortAccessible) Remediation(distro check.Distro) string {
+	switch distro.Family() {
+	case check.RHELFamily:
+		return fmt.Sprintf("Allow traffic to the port on the remote node by running \"firewall-cmd --permanent --add-port=%d/tcp && firewall-cmd --reload\", and make sure no other firewall between the nodes blocks it", p.Port)
+	case check.DebianFamily:
+		return fmt.Sprintf("Allow traffic to the port on the remote node by running \"ufw allow %d/tcp\", and make sure no other firewall between the nodes blocks it", p.Port)
+	}
+	return fmt.Sprintf("Make sure the firewall of the remote node, and any firewall between the nodes, allows TCP traffic to port %d", p.Port)
+}
@@ -1,5 +1,7 @@
 package rule
 
+import "time"
+
 // Meta contains the rule's metadata
 type Meta struct {
 	Kind string
@@ -29,4 +31,6 @@ type Result struct {
 	Error string
 	// Remediation contains potential remediation steps for the rule
 	Remediation string
+	// Duration is how long the check of the rule took
+	Duration time.Duration
 }
@@ -1,11 +1,13 @@
 package inspector
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/inspector/check"
 	"github.com/apprenda/kismatic/pkg/inspector/rule"
@@ -17,6 +19,9 @@ type Server struct {
 	Port int
 	// NodeFacts are the facts that apply to the node where the server is running
 	NodeFacts []string
+	// TLSConfig for serving requests with mutual TLS. If nil, the server
+	// serves plain HTTP, and does not authenticate requests.
+	TLSConfig *tls.Config
 	// RulesEngine for running inspector rules
 	rulesEngine *rule.Engine
 }
@@ -29,8 +34,9 @@ var executeEndpoint = "/execute"
 var closeEndpoint = "/close"
 
 // NewServer returns an inspector server that has been initialized
-// with the default rules engine
-func NewServer(nodeFacts []string, port int, packageInstallationDisabled bool) (*Server, error) {
+// with the default rules engine. The engine runs up to concurrency checks
+// at the same time, and fails the checks that take longer than the timeout.
+func NewServer(nodeFacts []string, port int, packageInstallationDisabled bool, concurrency int, checkTimeout time.Duration) (*Server, error) {
 	s := &Server{
 		Port: port,
 	}
@@ -38,7 +44,7 @@ func NewServer(nodeFacts []string, port int, packageInstallationDisabled bool) (
 	if err != nil {
 		return nil, fmt.Errorf("error building server: %v", err)
 	}
-	s.NodeFacts = append(nodeFacts, string(distro))
+	s.NodeFacts = append(nodeFacts, distro.Facts()...)
 	pkgMgr, err := check.NewPackageManager(distro)
 	if err != nil {
 		return nil, fmt.Errorf("error building server: %v", err)
@@ -48,6 +54,9 @@ func NewServer(nodeFacts []string, port int, packageInstallationDisabled bool) (
 			PackageManager:              pkgMgr,
 			PackageInstallationDisabled: packageInstallationDisabled,
 		},
+		Concurrency:  concurrency,
+		CheckTimeout: checkTimeout,
+		Distro:       distro,
 	}
 	s.rulesEngine = engine
 	return s, nil
@@ -55,6 +64,19 @@ func NewServer(nodeFacts []string, port int, packageInstallationDisabled bool) (
 
 // Start the server
 func (s *Server) Start() error {
+	srv := &http.Server{
+		Addr:      fmt.Sprintf(":%d", s.Port),
+		Handler:   s.handler(),
+		TLSConfig: s.TLSConfig,
+	}
+	if s.TLSConfig == nil {
+		return srv.ListenAndServe()
+	}
+	// The certificate and key are in the TLS configuration
+	return srv.ListenAndServeTLS("", "")
+}
+
+func (s *Server) handler() http.Handler {
 	mux := http.NewServeMux()
 	// Execute endpoint
 	mux.HandleFunc(executeEndpoint, func(w http.ResponseWriter, req *http.Request) {
@@ -101,5 +123,49 @@ func (s *Server) Start() error {
 		}
 		w.WriteHeader(http.StatusOK)
 	})
-	return http.ListenAndServe(fmt.Sprintf(":%d", s.Port), mux)
+	// Probe endpoint
+	mux.HandleFunc(probeEndpoint, func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		defer req.Body.Close()
+		probeReq := probeRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&probeReq); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Printf("error decoding probe request: %v", err)
+			return
+		}
+		rules, err := rule.UnmarshalRulesJSON(probeReq.Rules)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Printf("error unmarshaling rules from JSON: %v", err)
+			return
+		}
+		results, err := probeTargets(probeReq.Targets, rules, s.probeEngine)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			if err = json.NewEncoder(w).Encode(serverError{Error: err.Error()}); err != nil {
+				log.Printf("error writing server response: %v\n", err)
+			}
+			return
+		}
+		if err = json.NewEncoder(w).Encode(results); err != nil {
+			log.Printf("error writing server response: %v\n", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	return mux
+}
+
+// probeEngine returns an engine for running the rules that probe the
+// target node from this node
+func (s *Server) probeEngine(targetIP string) *rule.Engine {
+	return &rule.Engine{
+		RuleCheckMapper: rule.DefaultCheckMapper{
+			TargetNodeIP: targetIP,
+		},
+		Concurrency:  s.rulesEngine.Concurrency,
+		CheckTimeout: s.rulesEngine.CheckTimeout,
+	}
 }
@@ -0,0 +1,86 @@
+package inspector
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// CertificateOrganization is the organization of the certificates that are
+// issued to inspector servers and clients. Peers that present another
+// certificate signed by the same CA, such as the certificate of a cluster
+// component, are rejected.
+const CertificateOrganization = "kismatic:inspector"
+
+// TLSConfig returns the configuration for mutual TLS between inspector servers
+// and clients. The certificate is used both for serving requests and for
+// making them, and the peer must present a certificate signed by the CA that
+// was issued to the inspector.
+func TLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	if caFile == "" || certFile == "" || keyFile == "" {
+		return nil, errors.New("the CA, certificate and key files are required")
+	}
+	caCert, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA file: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(caCert); !ok {
+		return nil, fmt.Errorf("no certificates were found in CA file %q", caFile)
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading certificate and key: %v", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+		// Runs on both ends of the connection, after the chain was verified
+		VerifyPeerCertificate: verifyInspectorPeer,
+	}, nil
+}
+
+// verifyInspectorPeer rejects peers whose certificate was not issued to the
+// inspector
+func verifyInspectorPeer(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	for _, chain := range verifiedChains {
+		if len(chain) == 0 {
+			continue
+		}
+		for _, o := range chain[0].Subject.Organization {
+			if o == CertificateOrganization {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("the peer's certificate was not issued to the inspector: the organization %q is required", CertificateOrganization)
+}
+
+// remoteServer is an inspector server running on a remote node
+type remoteServer struct {
+	// address is the ip:port of the server
+	address string
+	// tlsConfig is nil when the server is reached over plain HTTP
+	tlsConfig *tls.Config
+}
+
+func (s remoteServer) url(endpoint string) string {
+	scheme := "http"
+	if s.tlsConfig != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, s.address, endpoint)
+}
+
+func (s remoteServer) httpClient() *http.Client {
+	if s.tlsConfig == nil {
+		return http.DefaultClient
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: s.tlsConfig}}
+}
@@ -0,0 +1,135 @@
+package inspector
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/inspector/rule"
+	ktls "github.com/apprenda/kismatic/pkg/tls"
+	"github.com/cloudflare/cfssl/csr"
+)
+
+// writes a CA, and an inspector certificate signed by it, to the directory.
+// Returns the paths to the CA, certificate and key files.
+func writeTestCerts(t *testing.T, dir, name string) (string, string, string) {
+	caKey, caCert, err := ktls.NewCACert("../tls/test/ca-csr.json", name+"-ca", "1h")
+	if err != nil {
+		t.Fatalf("error creating CA: %v", err)
+	}
+	if err := ktls.WriteCert(caKey, caCert, name+"-ca", dir); err != nil {
+		t.Fatalf("error writing CA: %v", err)
+	}
+	ca := &ktls.CA{Key: caKey, Cert: caCert}
+	certFile, keyFile := writeTestCert(t, ca, dir, name, CertificateOrganization)
+	return filepath.Join(dir, name+"-ca.pem"), certFile, keyFile
+}
+
+// writes a certificate signed by the CA to the directory. Returns the paths
+// to the certificate and key files.
+func writeTestCert(t *testing.T, ca *ktls.CA, dir, name, organization string) (string, string) {
+	req := csr.CertificateRequest{
+		CN:         name,
+		KeyRequest: &csr.BasicKeyRequest{A: "rsa", S: 2048},
+		Hosts:      []string{"127.0.0.1"},
+	}
+	if organization != "" {
+		req.Names = []csr.Name{{O: organization}}
+	}
+	key, cert, err := ktls.NewCert(ca, req, time.Hour)
+	if err != nil {
+		t.Fatalf("error creating certificate: %v", err)
+	}
+	if err := ktls.WriteCert(key, cert, name, dir); err != nil {
+		t.Fatalf("error writing certificate: %v", err)
+	}
+	return filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspector-tls")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	caFile, certFile, keyFile := writeTestCerts(t, dir, "inspector")
+	serverTLS, err := TLSConfig(caFile, certFile, keyFile)
+	if err != nil {
+		t.Fatalf("unexpected error building TLS config: %v", err)
+	}
+	// a certificate signed by the same CA, that was issued to a cluster component
+	caKey, caCert, err := ktls.ReadCACert("inspector-ca", dir)
+	if err != nil {
+		t.Fatalf("error reading CA: %v", err)
+	}
+	kubeletCert, kubeletKey := writeTestCert(t, &ktls.CA{Key: caKey, Cert: caCert}, dir, "kubelet", "system:nodes")
+	kubeletTLS, err := TLSConfig(caFile, kubeletCert, kubeletKey)
+	if err != nil {
+		t.Fatalf("unexpected error building TLS config: %v", err)
+	}
+	otherTLS, err := TLSConfig(writeTestCerts(t, dir, "other"))
+	if err != nil {
+		t.Fatalf("unexpected error building TLS config: %v", err)
+	}
+
+	s := &Server{rulesEngine: &rule.Engine{}}
+	ts := httptest.NewUnstartedServer(s.handler())
+	ts.TLS = serverTLS
+	ts.StartTLS()
+	defer ts.Close()
+	address := strings.TrimPrefix(ts.URL, "https://")
+
+	tests := []struct {
+		name      string
+		tlsConfig *tls.Config
+		expectErr bool
+	}{
+		{
+			name:      "client certificate signed by the CA",
+			tlsConfig: serverTLS,
+		},
+		{
+			name:      "no client certificate",
+			tlsConfig: &tls.Config{RootCAs: serverTLS.RootCAs},
+			expectErr: true,
+		},
+		{
+			name:      "client certificate signed by another CA",
+			tlsConfig: &tls.Config{RootCAs: serverTLS.RootCAs, Certificates: otherTLS.Certificates},
+			expectErr: true,
+		},
+		{
+			name:      "client certificate signed by the CA that was not issued to the inspector",
+			tlsConfig: kubeletTLS,
+			expectErr: true,
+		},
+		{
+			name:      "plain HTTP",
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		c, err := NewClient(address, []string{"worker"}, test.tlsConfig)
+		if err != nil {
+			t.Fatalf("%s: unexpected error creating client: %v", test.name, err)
+		}
+		_, err = c.ExecuteRules([]rule.Rule{})
+		if err != nil && !test.expectErr {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if err == nil && test.expectErr {
+			t.Errorf("%s: expected an error, but didn't get one", test.name)
+		}
+	}
+}
+
+func TestTLSConfigRequiresAllFiles(t *testing.T) {
+	if _, err := TLSConfig("ca.pem", "", "inspector-key.pem"); err == nil {
+		t.Errorf("expected an error when the certificate file is missing")
+	}
+}
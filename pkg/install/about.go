@@ -2,6 +2,7 @@ package install
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/apprenda/kismatic/pkg/ssh"
 	"github.com/apprenda/kismatic/pkg/util"
@@ -21,6 +22,10 @@ type ListableNode struct {
 	Node    Node
 	Roles   []string
 	Version semver.Version
+	// RollbackVersion is the version the node can be rolled back to, which is
+	// the version it had before its last upgrade. Nil if the node has not
+	// been upgraded.
+	RollbackVersion *semver.Version `json:",omitempty"`
 }
 
 // KismaticVersion contains the version information of the currently running binary
@@ -92,7 +97,16 @@ func ListVersions(plan *Plan) (ClusterVersion, error) {
 			return cv, fmt.Errorf("invalid version %q found in version file %q of node %s", output, verFile, node.Host)
 		}
 
-		cv.Nodes = append(cv.Nodes, ListableNode{node, plan.GetRolesForIP(node.IP), thisVersion})
+		listNode := ListableNode{Node: node, Roles: plan.GetRolesForIP(node.IP), Version: thisVersion}
+		// The snapshot taken before the last upgrade records the previous version
+		if output, err := client.Output(false, fmt.Sprintf("cat %s", rollbackVersionFile)); err == nil && strings.TrimSpace(output) != "" {
+			rollbackVersion, err := parseVersion(strings.TrimSpace(output))
+			if err != nil {
+				return cv, fmt.Errorf("invalid version %q found in version file %q of node %s", output, rollbackVersionFile, node.Host)
+			}
+			listNode.RollbackVersion = &rollbackVersion
+		}
+		cv.Nodes = append(cv.Nodes, listNode)
 
 		// If looking at the first node, set the versions and move on
 		if i == 0 {
@@ -0,0 +1,215 @@
+package install
+
+import (
+	"fmt"
+
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+// The roles that can be given to a node that is added to an existing cluster
+var addNodeRoles = []string{"etcd", "master", "worker", "ingress", "storage"}
+
+// AddNode adds a node with the given roles to the original cluster described
+// in the plan. If successful, the updated plan is returned.
+func (ae *ansibleExecutor) AddNode(originalPlan *Plan, newNode Node, roles []string) (*Plan, error) {
+	if err := checkAddNodePrereqs(ae.pki, *originalPlan, newNode, roles); err != nil {
+		return nil, err
+	}
+	updatedPlan := addNodeToPlan(*originalPlan, newNode, roles)
+
+	// Generate node certificates
+	util.PrintHeader(ae.stdout, "Generating Certificates For New Node", '=')
+	ca, err := ae.pki.GetClusterCA()
+	if err != nil {
+		return nil, err
+	}
+	if err = ae.pki.GenerateNodeCertificate(&updatedPlan, newNode, ca); err != nil {
+		return nil, fmt.Errorf("error generating certificate for new node: %v", err)
+	}
+	// The certificates of the existing masters are regenerated if the SANs
+	// required by the API server have changed
+	var mastersToUpdate []string
+	for _, n := range originalPlan.Master.Nodes {
+		regenerated, err := ae.pki.RegenerateNodeCertificate(&updatedPlan, n, ca)
+		if err != nil {
+			return nil, fmt.Errorf("error regenerating certificates for master %q: %v", n.Host, err)
+		}
+		if regenerated {
+			mastersToUpdate = append(mastersToUpdate, n.Host)
+		}
+	}
+
+	// Run the playbook to add the node
+	inventory := buildInventoryFromPlan(&updatedPlan)
+	cc, err := ae.buildClusterCatalog(&updatedPlan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ansible vars: %v", err)
+	}
+	util.PrintHeader(ae.stdout, fmt.Sprintf("Adding Node to Cluster: %s %s", newNode.Host, roles), '=')
+	t := task{
+		name:           "add-node",
+		playbook:       "add-node.yaml",
+		plan:           updatedPlan,
+		inventory:      inventory,
+		clusterCatalog: *cc,
+		explainer:      ae.defaultExplainer(),
+		limit:          []string{newNode.Host},
+	}
+	if err = ae.execute(t); err != nil {
+		return nil, fmt.Errorf("error running playbook: %v", err)
+	}
+
+	// The API servers have to be reconfigured to use the new etcd member
+	if contains("etcd", roles) {
+		util.PrintHeader(ae.stdout, "Reconfiguring Etcd Clients", '=')
+		t = task{
+			name:           "add-node-reconfigure-etcd-clients",
+			playbook:       "reconfigure-etcd-clients.yaml",
+			plan:           updatedPlan,
+			inventory:      inventory,
+			clusterCatalog: *cc,
+			explainer:      ae.defaultExplainer(),
+		}
+		if err = ae.execute(t); err != nil {
+			return nil, fmt.Errorf("error reconfiguring etcd clients: %v", err)
+		}
+	}
+
+	if len(mastersToUpdate) > 0 {
+		util.PrintHeader(ae.stdout, "Updating Certificates On Master Nodes", '=')
+		t = task{
+			name:           "add-node-update-master-certs",
+			playbook:       "update-master-certificates.yaml",
+			plan:           updatedPlan,
+			inventory:      inventory,
+			clusterCatalog: *cc,
+			explainer:      ae.defaultExplainer(),
+			limit:          mastersToUpdate,
+		}
+		if err = ae.execute(t); err != nil {
+			return nil, fmt.Errorf("error updating certificates on master nodes: %v", err)
+		}
+	}
+
+	// We need to run ansible against all hosts to update the hosts files
+	if updatedPlan.Cluster.Networking.UpdateHostsFiles {
+		util.PrintHeader(ae.stdout, "Updating Hosts Files On All Nodes", '=')
+		t = task{
+			name:           "add-node-update-hosts",
+			playbook:       "_hosts.yaml",
+			plan:           updatedPlan,
+			inventory:      inventory,
+			clusterCatalog: *cc,
+			explainer:      ae.defaultExplainer(),
+		}
+		if err = ae.execute(t); err != nil {
+			return nil, fmt.Errorf("error updating hosts files on all nodes: %v", err)
+		}
+	}
+
+	// Nodes that only run etcd do not register with the API server
+	if !containsAny([]string{"master", "worker", "ingress", "storage"}, roles) {
+		return &updatedPlan, nil
+	}
+
+	// Verify that the node registered with API server
+	util.PrintHeader(ae.stdout, "Running New Node Smoke Test", '=')
+	cc.WorkerNode = newNode.Host
+	t = task{
+		name:           "add-node-smoke-test",
+		playbook:       "_worker-smoke-test.yaml",
+		plan:           updatedPlan,
+		inventory:      inventory,
+		clusterCatalog: *cc,
+		explainer:      ae.defaultExplainer(),
+		limit:          []string{newNode.Host},
+	}
+	if err = ae.execute(t); err != nil {
+		return nil, fmt.Errorf("error running new node smoke test: %v", err)
+	}
+
+	// Allow access to new node to any storage volumes defined
+	if len(originalPlan.Storage.Nodes) > 0 {
+		util.PrintHeader(ae.stdout, "Updating Allowed IPs On Storage Volumes", '=')
+		t = task{
+			name:           "add-node-update-volumes",
+			playbook:       "_volume-update-allowed.yaml",
+			plan:           updatedPlan,
+			inventory:      inventory,
+			clusterCatalog: *cc,
+			explainer:      ae.defaultExplainer(),
+		}
+		if err = ae.execute(t); err != nil {
+			return nil, fmt.Errorf("error adding new node to volume allow list: %v", err)
+		}
+	}
+	return &updatedPlan, nil
+}
+
+func addNodeToPlan(plan Plan, node Node, roles []string) Plan {
+	for _, r := range roles {
+		switch r {
+		case "etcd":
+			plan.Etcd.ExpectedCount++
+			plan.Etcd.Nodes = append(plan.Etcd.Nodes, node)
+		case "master":
+			plan.Master.ExpectedCount++
+			plan.Master.Nodes = append(plan.Master.Nodes, node)
+		case "worker":
+			plan.Worker.ExpectedCount++
+			plan.Worker.Nodes = append(plan.Worker.Nodes, node)
+		case "ingress":
+			plan.Ingress.ExpectedCount++
+			plan.Ingress.Nodes = append(plan.Ingress.Nodes, node)
+		case "storage":
+			plan.Storage.ExpectedCount++
+			plan.Storage.Nodes = append(plan.Storage.Nodes, node)
+		}
+	}
+	return plan
+}
+
+// ensure the assumptions we are making are solid
+func checkAddNodePrereqs(pki PKI, plan Plan, newNode Node, roles []string) error {
+	if len(roles) == 0 {
+		return fmt.Errorf("at least one role is required for the new node")
+	}
+	seen := map[string]bool{}
+	for _, r := range roles {
+		if !contains(r, addNodeRoles) {
+			return fmt.Errorf("%q is not a valid role, must be one of %v", r, addNodeRoles)
+		}
+		if seen[r] {
+			return fmt.Errorf("role %q was provided more than once", r)
+		}
+		seen[r] = true
+	}
+	if seen["etcd"] && len(plan.Etcd.Nodes) == 0 {
+		return fmt.Errorf("the cluster does not have an etcd cluster to add the new node to")
+	}
+	// The new node can be an existing node that is getting new roles, as long
+	// as it is the same node
+	for _, n := range plan.GetUniqueNodes() {
+		if n.Host != newNode.Host && n.IP != newNode.IP {
+			continue
+		}
+		if !n.Equal(newNode) {
+			return fmt.Errorf("according to the plan file, the host name or IP of the new node is already being used by node %q", n.Host)
+		}
+		existingRoles := plan.GetRolesForIP(n.IP)
+		for _, r := range roles {
+			if contains(r, existingRoles) {
+				return fmt.Errorf("according to the plan file, %q is already a %s node", n.Host, r)
+			}
+		}
+	}
+	// The CA is required for generating the new node's certificates
+	caExists, err := pki.CertificateAuthorityExists()
+	if err != nil {
+		return fmt.Errorf("error while checking if cluster CA exists: %v", err)
+	}
+	if !caExists {
+		return errMissingClusterCA
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package install
+
+import (
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+func addNodeTestPlan() *Plan {
+	return &Plan{
+		Etcd: NodeGroup{
+			ExpectedCount: 1,
+			Nodes:         []Node{{Host: "etcd1", IP: "10.10.2.10"}},
+		},
+		Master: MasterNodeGroup{
+			ExpectedCount: 1,
+			Nodes:         []Node{{Host: "master1", IP: "10.10.2.20"}},
+		},
+		Worker: NodeGroup{
+			ExpectedCount: 1,
+			Nodes:         []Node{{Host: "worker1", IP: "10.10.2.30"}},
+		},
+		Cluster: Cluster{
+			Networking: NetworkConfig{
+				ServiceCIDRBlock: "10.0.0.0/16",
+			},
+		},
+	}
+}
+
+func TestAddNodePlanIsUpdated(t *testing.T) {
+	fakeRunner := fakeRunner{}
+	pki := &fakePKI{caExists: true}
+	e := ansibleExecutor{
+		options:             ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:              ioutil.Discard,
+		consoleOutputFormat: ansible.RawFormat,
+		pki:                 pki,
+		runnerExplainerFactory: func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+			return &fakeRunner, &explain.AnsibleEventStreamExplainer{}, nil
+		},
+		certsDir: mustGetTempDir(t),
+	}
+	originalPlan := addNodeTestPlan()
+	newNode := Node{Host: "node2", IP: "10.10.2.40"}
+	updatedPlan, err := e.AddNode(originalPlan, newNode, []string{"etcd", "master"})
+	if err != nil {
+		t.Fatalf("unexpected error while adding node: %v", err)
+	}
+	if updatedPlan.Etcd.ExpectedCount != 2 || len(updatedPlan.Etcd.Nodes) != 2 {
+		t.Errorf("node was not added to the etcd nodes")
+	}
+	if updatedPlan.Master.ExpectedCount != 2 || len(updatedPlan.Master.Nodes) != 2 {
+		t.Errorf("node was not added to the master nodes")
+	}
+	if updatedPlan.Worker.ExpectedCount != 1 || len(updatedPlan.Worker.Nodes) != 1 {
+		t.Errorf("node was unexpectedly added to the worker nodes")
+	}
+	if !pki.generateNodeCertCalled {
+		t.Errorf("node certificate was not generated")
+	}
+	if !reflect.DeepEqual(pki.regenerateNodeCerts, []string{"master1"}) {
+		t.Errorf("expected certificates of the existing masters to be checked, but got %v", pki.regenerateNodeCerts)
+	}
+	expectedNodePlaybooks := []string{"add-node.yaml", "_worker-smoke-test.yaml"}
+	if !reflect.DeepEqual(fakeRunner.nodePlaybooks, expectedNodePlaybooks) {
+		t.Errorf("expected playbooks %v to run on the new node, but got %v", expectedNodePlaybooks, fakeRunner.nodePlaybooks)
+	}
+	if !reflect.DeepEqual(fakeRunner.allNodesPlaybooks, []string{"reconfigure-etcd-clients.yaml"}) {
+		t.Errorf("expected the etcd clients to be reconfigured, but got %v", fakeRunner.allNodesPlaybooks)
+	}
+}
+
+func TestAddNodeMasterCertificatesUpdated(t *testing.T) {
+	fakeRunner := fakeRunner{}
+	e := ansibleExecutor{
+		options:             ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:              ioutil.Discard,
+		consoleOutputFormat: ansible.RawFormat,
+		pki:                 &fakePKI{caExists: true, regenerated: true},
+		runnerExplainerFactory: func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+			return &fakeRunner, &explain.AnsibleEventStreamExplainer{}, nil
+		},
+		certsDir: mustGetTempDir(t),
+	}
+	if _, err := e.AddNode(addNodeTestPlan(), Node{Host: "node2", IP: "10.10.2.40"}, []string{"worker"}); err != nil {
+		t.Fatalf("unexpected error while adding node: %v", err)
+	}
+	if len(fakeRunner.nodePlaybooks) < 2 || fakeRunner.nodePlaybooks[1] != "update-master-certificates.yaml" {
+		t.Fatalf("expected certificates to be updated on masters, but got %v", fakeRunner.nodePlaybooks)
+	}
+	if !reflect.DeepEqual(fakeRunner.limits[1], []string{"master1"}) {
+		t.Errorf("expected certificates to be updated on master1, but got %v", fakeRunner.limits[1])
+	}
+}
+
+func TestAddNodePrereqs(t *testing.T) {
+	tests := []struct {
+		name  string
+		node  Node
+		roles []string
+		valid bool
+	}{
+		{
+			name:  "new worker",
+			node:  Node{Host: "node2", IP: "10.10.2.40"},
+			roles: []string{"worker"},
+			valid: true,
+		},
+		{
+			name:  "existing worker becomes ingress",
+			node:  Node{Host: "worker1", IP: "10.10.2.30"},
+			roles: []string{"ingress"},
+			valid: true,
+		},
+		{
+			name: "no roles",
+			node: Node{Host: "node2", IP: "10.10.2.40"},
+		},
+		{
+			name:  "invalid role",
+			node:  Node{Host: "node2", IP: "10.10.2.40"},
+			roles: []string{"foo"},
+		},
+		{
+			name:  "duplicate role",
+			node:  Node{Host: "node2", IP: "10.10.2.40"},
+			roles: []string{"master", "master"},
+		},
+		{
+			name:  "existing worker is already a worker",
+			node:  Node{Host: "worker1", IP: "10.10.2.30"},
+			roles: []string{"worker"},
+		},
+		{
+			name:  "host name is used by another node",
+			node:  Node{Host: "worker1", IP: "10.10.2.40"},
+			roles: []string{"master"},
+		},
+		{
+			name:  "IP is used by another node",
+			node:  Node{Host: "node2", IP: "10.10.2.30"},
+			roles: []string{"master"},
+		},
+	}
+	pki := &fakePKI{caExists: true}
+	for _, test := range tests {
+		err := checkAddNodePrereqs(pki, *addNodeTestPlan(), test.node, test.roles)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected an error, but didn't get one", test.name)
+		}
+	}
+}
+
+func TestAddNodePrereqsCAMissing(t *testing.T) {
+	err := checkAddNodePrereqs(&fakePKI{}, *addNodeTestPlan(), Node{Host: "node2", IP: "10.10.2.40"}, []string{"worker"})
+	if err != errMissingClusterCA {
+		t.Errorf("expected %v, but got %v", errMissingClusterCA, err)
+	}
+}
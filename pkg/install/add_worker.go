@@ -3,99 +3,293 @@ package install
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
 	"github.com/apprenda/kismatic/pkg/util"
 )
 
 var errMissingClusterCA = errors.New("The Certificate Authority's private key and certificate used to install " +
-	"the cluster are required for adding worker nodes.")
+	"the cluster are required for adding nodes.")
+
+// AddWorkerResult is the outcome of adding a worker node to the cluster
+type AddWorkerResult struct {
+	Node Node
+	// Error is nil if the worker was added successfully
+	Error error
+}
 
 // AddWorker adds a worker node to the original cluster described in the plan.
 // If successful, the updated plan is returned.
 func (ae *ansibleExecutor) AddWorker(originalPlan *Plan, newWorker Node) (*Plan, error) {
-	if err := checkAddWorkerPrereqs(ae.pki, newWorker); err != nil {
-		return nil, err
-	}
-	updatedPlan := addWorkerToPlan(*originalPlan, newWorker)
-
-	// Generate node certificates
-	util.PrintHeader(ae.stdout, "Generating Certificate For Worker Node", '=')
-	ca, err := ae.pki.GetClusterCA()
+	updatedPlan, results, err := ae.AddWorkers(originalPlan, []Node{newWorker})
 	if err != nil {
 		return nil, err
 	}
-	if err = ae.pki.GenerateNodeCertificate(&updatedPlan, newWorker, ca); err != nil {
-		return nil, fmt.Errorf("error generating certificate for new worker: %v", err)
+	if results[0].Error != nil {
+		return nil, results[0].Error
 	}
+	return updatedPlan, nil
+}
 
-	// Run the playbook to add the worker
-	inventory := buildInventoryFromPlan(&updatedPlan)
-	cc, err := ae.buildClusterCatalog(&updatedPlan)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate ansible vars: %v", err)
+// AddWorkers adds the worker nodes to the original cluster described in the plan.
+// The playbooks are run against all the new workers at once. When a worker fails,
+// it is left out and the playbook is run again against the remaining workers.
+// The result of adding each worker is returned, along with the updated plan
+// that only includes the workers that were added successfully.
+func (ae *ansibleExecutor) AddWorkers(originalPlan *Plan, newWorkers []Node) (*Plan, []AddWorkerResult, error) {
+	errs := map[string]error{}
+	var pending []Node
+	for _, w := range newWorkers {
+		if err := checkAddWorkerPrereqs(ae.pki, w); err != nil {
+			errs[w.Host] = err
+			continue
+		}
+		pending = append(pending, w)
 	}
-	util.PrintHeader(ae.stdout, "Adding Worker Node to Cluster", '=')
-	t := task{
-		name:           "add-worker",
-		playbook:       "kubernetes-worker.yaml",
-		plan:           updatedPlan,
-		inventory:      inventory,
-		clusterCatalog: *cc,
-		explainer:      ae.defaultExplainer(),
-		limit:          []string{newWorker.Host},
+
+	// Generate node certificates
+	if len(pending) > 0 {
+		util.PrintHeader(ae.stdout, "Generating Certificate For Worker Node", '=')
+		ca, err := ae.pki.GetClusterCA()
+		if err != nil {
+			return nil, nil, err
+		}
+		plan := addWorkersToPlan(*originalPlan, pending)
+		for _, w := range pending {
+			if err = ae.pki.GenerateNodeCertificate(&plan, w, ca); err != nil {
+				errs[w.Host] = fmt.Errorf("error generating certificate for new worker: %v", err)
+			}
+		}
+		pending = nodesWithoutErrors(pending, errs)
 	}
-	if err = ae.execute(t); err != nil {
-		return nil, fmt.Errorf("error running playbook: %v", err)
+
+	// Run the playbook to add the workers
+	if len(pending) > 0 {
+		plan := addWorkersToPlan(*originalPlan, pending)
+		cc, err := ae.buildClusterCatalog(&plan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate ansible vars: %v", err)
+		}
+		util.PrintHeader(ae.stdout, "Adding Worker Node to Cluster", '=')
+		t := task{
+			name:           "add-worker",
+			playbook:       "kubernetes-worker.yaml",
+			plan:           plan,
+			inventory:      buildInventoryFromPlan(&plan),
+			clusterCatalog: *cc,
+			explainer:      ae.defaultExplainer(),
+		}
+		pending = ae.executeOnNodes(t, pending, errs, "error running playbook")
 	}
 
 	// We need to run ansible against all hosts to update the hosts files
-	if updatedPlan.Cluster.Networking.UpdateHostsFiles {
+	if len(pending) > 0 && originalPlan.Cluster.Networking.UpdateHostsFiles {
 		util.PrintHeader(ae.stdout, "Updating Hosts Files On All Nodes", '=')
-		t = task{
-			name:           "add-worker-update-hosts",
-			playbook:       "_hosts.yaml",
-			plan:           updatedPlan,
-			inventory:      inventory,
+		var err error
+		if pending, err = ae.updateHostsFiles(*originalPlan, pending, errs); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Verify that the nodes registered with API server
+	if len(pending) > 0 {
+		plan := addWorkersToPlan(*originalPlan, pending)
+		cc, err := ae.buildClusterCatalog(&plan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate ansible vars: %v", err)
+		}
+		util.PrintHeader(ae.stdout, "Running New Worker Smoke Test", '=')
+		t := task{
+			name:           "add-worker-smoke-test",
+			playbook:       "_worker-smoke-test.yaml",
+			plan:           plan,
+			inventory:      buildInventoryFromPlan(&plan),
 			clusterCatalog: *cc,
 			explainer:      ae.defaultExplainer(),
 		}
-		if err = ae.execute(t); err != nil {
-			return nil, fmt.Errorf("error updating hosts files on all nodes: %v", err)
-		}
+		pending = ae.executeOnNodes(t, pending, errs, "error running worker smoke test")
 	}
 
-	// Verify that the node registered with API server
-	util.PrintHeader(ae.stdout, "Running New Worker Smoke Test", '=')
-	cc.WorkerNode = newWorker.Host
-	t = task{
-		name:           "add-worker-smoke-test",
-		playbook:       "_worker-smoke-test.yaml",
-		plan:           updatedPlan,
-		inventory:      inventory,
-		clusterCatalog: *cc,
-		explainer:      ae.defaultExplainer(),
-		limit:          []string{newWorker.Host},
+	// Allow access to new workers to any storage volumes defined
+	if len(pending) > 0 && len(originalPlan.Storage.Nodes) > 0 {
+		plan := addWorkersToPlan(*originalPlan, pending)
+		cc, err := ae.buildClusterCatalog(&plan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate ansible vars: %v", err)
+		}
+		util.PrintHeader(ae.stdout, "Updating Allowed IPs On Storage Volumes", '=')
+		// The volumes are updated once per worker, as the playbook runs on the
+		// storage nodes, and its failures can't be attributed to a worker
+		for _, w := range pending {
+			cc.WorkerNode = w.Host
+			t := task{
+				name:           "add-worker-update-volumes",
+				playbook:       "_volume-update-allowed.yaml",
+				plan:           plan,
+				inventory:      buildInventoryFromPlan(&plan),
+				clusterCatalog: *cc,
+				explainer:      ae.defaultExplainer(),
+			}
+			if err = ae.execute(t); err != nil {
+				errs[w.Host] = fmt.Errorf("error adding new worker to volume allow list: %v", err)
+			}
+		}
+		pending = nodesWithoutErrors(pending, errs)
 	}
-	if err = ae.execute(t); err != nil {
-		return nil, fmt.Errorf("error running worker smoke test: %v", err)
+
+	results := make([]AddWorkerResult, len(newWorkers))
+	for i, w := range newWorkers {
+		results[i] = AddWorkerResult{Node: w, Error: errs[w.Host]}
 	}
+	updatedPlan := addWorkersToPlan(*originalPlan, pending)
+	return &updatedPlan, results, nil
+}
 
-	// Allow access to new worker to any storage volumes defined
-	if len(originalPlan.Storage.Nodes) > 0 {
-		util.PrintHeader(ae.stdout, "Updating Allowed IPs On Storage Volumes", '=')
-		t = task{
-			name:           "add-worker-update-volumes",
-			playbook:       "_volume-update-allowed.yaml",
-			plan:           updatedPlan,
-			inventory:      inventory,
+// updateHostsFiles updates the hosts files of all the nodes to include the new
+// workers. The new workers that fail are left out, and the hosts files are
+// updated again. The existing nodes that fail are reported and skipped, as they
+// do not prevent the new workers from joining the cluster.
+// Returns the new workers that were added to the hosts files.
+func (ae *ansibleExecutor) updateHostsFiles(originalPlan Plan, pending []Node, errs map[string]error) ([]Node, error) {
+	skipped := map[string]bool{}
+	for len(pending) > 0 {
+		plan := addWorkersToPlan(originalPlan, pending)
+		cc, err := ae.buildClusterCatalog(&plan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ansible vars: %v", err)
+		}
+		var limit []string
+		for _, n := range plan.GetUniqueNodes() {
+			if !skipped[n.Host] {
+				limit = append(limit, n.Host)
+			}
+		}
+		recorder := &hostFailureRecorder{AnsibleEventExplainer: ae.defaultExplainer()}
+		t := task{
+			name:           "add-worker-update-hosts",
+			playbook:       "_hosts.yaml",
+			plan:           plan,
+			inventory:      buildInventoryFromPlan(&plan),
 			clusterCatalog: *cc,
-			explainer:      ae.defaultExplainer(),
+			explainer:      recorder,
+			limit:          limit,
+		}
+		if err = ae.execute(t); err == nil {
+			return pending, nil
+		}
+		failed := recorder.failures()
+		if len(failed) == 0 {
+			for _, w := range pending {
+				errs[w.Host] = fmt.Errorf("error updating hosts files on all nodes: %v", err)
+			}
+			return nil, nil
+		}
+		var remaining []Node
+		for _, w := range pending {
+			if msg, ok := failed[w.Host]; ok {
+				errs[w.Host] = fmt.Errorf("error updating hosts file: %s", msg)
+				delete(failed, w.Host)
+				continue
+			}
+			remaining = append(remaining, w)
 		}
-		if err = ae.execute(t); err != nil {
-			return nil, fmt.Errorf("error adding new worker to volume allow list: %v", err)
+		hosts := make([]string, 0, len(failed))
+		for host := range failed {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+		for _, host := range hosts {
+			skipped[host] = true
+			util.PrettyPrintWarn(ae.stdout, "The hosts file on node %q was not updated to include the new workers: %s", host, failed[host])
+		}
+		pending = remaining
+	}
+	return pending, nil
+}
+
+// executeOnNodes runs the task against the given nodes. If the task fails on
+// some of the nodes, the error is recorded for each of them, and the task is
+// run again against the remaining nodes. If the failure can't be attributed
+// to any of the nodes, the error is recorded for all of them.
+// Returns the nodes on which the task succeeded.
+func (ae *ansibleExecutor) executeOnNodes(t task, nodes []Node, errs map[string]error, errMsg string) []Node {
+	explainer := t.explainer
+	for len(nodes) > 0 {
+		recorder := &hostFailureRecorder{AnsibleEventExplainer: explainer}
+		t.explainer = recorder
+		t.limit = nodeHosts(nodes)
+		err := ae.execute(t)
+		if err == nil {
+			return nodes
+		}
+		failed := recorder.failures()
+		var remaining []Node
+		for _, n := range nodes {
+			if _, ok := failed[n.Host]; !ok {
+				remaining = append(remaining, n)
+			}
+		}
+		if len(remaining) == len(nodes) {
+			for _, n := range nodes {
+				errs[n.Host] = fmt.Errorf("%s: %v", errMsg, err)
+			}
+			return nil
+		}
+		for _, n := range nodes {
+			if msg, ok := failed[n.Host]; ok {
+				errs[n.Host] = fmt.Errorf("%s: %s", errMsg, msg)
+			}
+		}
+		nodes = remaining
+	}
+	return nodes
+}
+
+// hostFailureRecorder records the hosts on which ansible failed to run a task,
+// and passes the events on to the wrapped explainer.
+type hostFailureRecorder struct {
+	explain.AnsibleEventExplainer
+	mu     sync.Mutex
+	failed map[string]string
+}
+
+func (r *hostFailureRecorder) ExplainEvent(e ansible.Event) {
+	switch event := e.(type) {
+	case *ansible.RunnerFailedEvent:
+		if !event.IgnoreErrors {
+			r.record(event.Host, event.Result.Message)
 		}
+	case *ansible.RunnerUnreachableEvent:
+		r.record(event.Host, fmt.Sprintf("host is unreachable: %s", event.Result.Message))
+	}
+	if r.AnsibleEventExplainer != nil {
+		r.AnsibleEventExplainer.ExplainEvent(e)
+	}
+}
+
+func (r *hostFailureRecorder) record(host string, msg string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failed == nil {
+		r.failed = map[string]string{}
+	}
+	// keep the first failure, as it is the one that caused the others
+	if _, ok := r.failed[host]; !ok {
+		r.failed[host] = msg
 	}
-	return &updatedPlan, nil
+}
+
+func (r *hostFailureRecorder) failures() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failed := make(map[string]string, len(r.failed))
+	for k, v := range r.failed {
+		failed[k] = v
+	}
+	return failed
 }
 
 func addWorkerToPlan(plan Plan, worker Node) Plan {
@@ -104,6 +298,35 @@ func addWorkerToPlan(plan Plan, worker Node) Plan {
 	return plan
 }
 
+func addWorkersToPlan(plan Plan, workers []Node) Plan {
+	// copy the nodes so that the original plan is not modified
+	nodes := make([]Node, len(plan.Worker.Nodes), len(plan.Worker.Nodes)+len(workers))
+	copy(nodes, plan.Worker.Nodes)
+	plan.Worker.Nodes = nodes
+	for _, w := range workers {
+		plan = addWorkerToPlan(plan, w)
+	}
+	return plan
+}
+
+func nodesWithoutErrors(nodes []Node, errs map[string]error) []Node {
+	var ok []Node
+	for _, n := range nodes {
+		if errs[n.Host] == nil {
+			ok = append(ok, n)
+		}
+	}
+	return ok
+}
+
+func nodeHosts(nodes []Node) []string {
+	hosts := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		hosts = append(hosts, n.Host)
+	}
+	return hosts
+}
+
 // ensure the assumptions we are making are solid
 func checkAddWorkerPrereqs(pki PKI, newWorker Node) error {
 	// 1. if the node certificate is not there, we need to ensure that
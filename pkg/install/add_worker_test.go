@@ -271,13 +271,14 @@ func TestAddWorkerHostsFilesDNSEnabled(t *testing.T) {
 	}
 	expectedPlaybook := "_hosts.yaml"
 	found := false
-	for _, p := range fakeRunner.allNodesPlaybooks {
-		if p == expectedPlaybook {
+	// the hosts files are updated on all the nodes
+	for i, p := range fakeRunner.nodePlaybooks {
+		if p == expectedPlaybook && len(fakeRunner.limits[i]) == 3 {
 			found = true
 		}
 	}
 	if !found {
-		t.Errorf("expected playbook %s was not run during add-worker. The following plays ran: %v", expectedPlaybook, fakeRunner.allNodesPlaybooks)
+		t.Errorf("expected playbook %s was not run on all nodes during add-worker. The following plays ran: %v on %v", expectedPlaybook, fakeRunner.nodePlaybooks, fakeRunner.limits)
 	}
 }
 
@@ -288,6 +289,14 @@ type fakePKI struct {
 	err                    error
 	generateCACalled       bool
 	generateNodeCertCalled bool
+	rotateCACalled         bool
+	rotateCertsCalled      bool
+	regenerateNodeCerts    []string
+	regenerated            bool
+	// the subject alternate names of the generated certificates, by name
+	certificates map[string][]string
+	// the organizations of the generated certificates, by name
+	organizations map[string][]string
 }
 
 func (f *fakePKI) CertificateAuthorityExists() (bool, error)     { return f.caExists, f.err }
@@ -296,13 +305,35 @@ func (f *fakePKI) GenerateNodeCertificate(plan *Plan, node Node, ca *tls.CA) err
 	f.generateNodeCertCalled = true
 	return f.err
 }
-func (f *fakePKI) GetClusterCA() (*tls.CA, error) { return nil, f.err }
+func (f *fakePKI) RegenerateNodeCertificate(plan *Plan, node Node, ca *tls.CA) (bool, error) {
+	f.regenerateNodeCerts = append(f.regenerateNodeCerts, node.Host)
+	return f.regenerated, f.err
+}
+func (f *fakePKI) GetClusterCA() (*tls.CA, error) {
+	return &tls.CA{Cert: []byte("previous CA\n")}, f.err
+}
 func (f *fakePKI) GenerateClusterCA(p *Plan) (*tls.CA, error) {
 	f.generateCACalled = true
 	return nil, f.err
 }
 func (f *fakePKI) GenerateClusterCertificates(p *Plan, ca *tls.CA) error { return f.err }
+func (f *fakePKI) RotateClusterCA(p *Plan) (*tls.CA, error) {
+	f.rotateCACalled = true
+	return &tls.CA{Cert: []byte("new CA\n")}, f.err
+}
+func (f *fakePKI) RotateClusterCertificates(p *Plan, ca *tls.CA) error {
+	f.rotateCertsCalled = true
+	return f.err
+}
 func (f *fakePKI) GenerateCertificate(name string, validityPeriod string, commonName string, subjectAlternateNames []string, organizations []string, ca *tls.CA, overwrite bool) (bool, error) {
+	if f.certificates == nil {
+		f.certificates = map[string][]string{}
+	}
+	f.certificates[name] = subjectAlternateNames
+	if f.organizations == nil {
+		f.organizations = map[string][]string{}
+	}
+	f.organizations[name] = organizations
 	return false, f.err
 }
 
@@ -311,16 +342,32 @@ type fakeRunner struct {
 	err               error
 	incomingCatalog   ansible.ClusterCatalog
 	allNodesPlaybooks []string
+	nodePlaybooks     []string
+	limits            [][]string
 }
 
 func (f *fakeRunner) StartPlaybook(playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog) (<-chan ansible.Event, error) {
 	f.allNodesPlaybooks = append(f.allNodesPlaybooks, playbookFile)
-	return f.eventChan, f.err
+	f.incomingCatalog = cc
+	return f.events(), f.err
 }
 func (f *fakeRunner) WaitPlaybook() error { return f.err }
 func (f *fakeRunner) StartPlaybookOnNode(playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog, node ...string) (<-chan ansible.Event, error) {
+	f.nodePlaybooks = append(f.nodePlaybooks, playbookFile)
+	f.limits = append(f.limits, node)
 	f.incomingCatalog = cc
-	return f.eventChan, f.err
+	return f.events(), f.err
+}
+
+// events returns the event channel of the runner, or a closed channel if
+// the runner does not produce events
+func (f *fakeRunner) events() <-chan ansible.Event {
+	if f.eventChan != nil {
+		return f.eventChan
+	}
+	c := make(chan ansible.Event)
+	close(c)
+	return c
 }
 
 func fakeRunnerExplainer(execError error) func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
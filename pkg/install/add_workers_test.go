@@ -0,0 +1,298 @@
+package install
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/inspector"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+// runs the playbooks, failing on the given host the first time a playbook
+// runs against it
+func failOnHostRunnerExplainer(runner *fakeRunner, failHost string) func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+	failed := false
+	return func(explainer explain.AnsibleEventExplainer, w io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+		runner.err = nil
+		if !failed {
+			failed = true
+			e := &ansible.RunnerFailedEvent{}
+			e.Host = failHost
+			e.Result.Message = "something went wrong"
+			explainer.ExplainEvent(e)
+			runner.err = errors.New("playbook failed")
+		}
+		return runner, &explain.AnsibleEventStreamExplainer{}, nil
+	}
+}
+
+func addWorkersTestPlan() *Plan {
+	return &Plan{
+		Master: MasterNodeGroup{
+			Nodes: []Node{{InternalIP: "10.10.2.20"}},
+		},
+		Worker: NodeGroup{
+			ExpectedCount: 1,
+			Nodes:         []Node{{Host: "existingWorker"}},
+		},
+		Cluster: Cluster{
+			Networking: NetworkConfig{
+				ServiceCIDRBlock: "10.0.0.0/16",
+			},
+		},
+	}
+}
+
+func TestAddWorkersFailedWorkerIsLeftOut(t *testing.T) {
+	runner := &fakeRunner{}
+	e := ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		pki:                    &fakePKI{caExists: true},
+		runnerExplainerFactory: failOnHostRunnerExplainer(runner, "worker1"),
+		certsDir:               mustGetTempDir(t),
+	}
+	originalPlan := addWorkersTestPlan()
+	newWorkers := []Node{{Host: "worker1"}, {Host: "worker2"}}
+	updatedPlan, results, err := e.AddWorkers(originalPlan, newWorkers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, but got %d", len(results))
+	}
+	if results[0].Node.Host != "worker1" || results[0].Error == nil {
+		t.Errorf("expected worker1 to fail, but got %+v", results[0])
+	}
+	if results[1].Node.Host != "worker2" || results[1].Error != nil {
+		t.Errorf("expected worker2 to succeed, but got %+v", results[1])
+	}
+	if len(updatedPlan.Worker.Nodes) != 2 || updatedPlan.Worker.Nodes[1].Host != "worker2" {
+		t.Errorf("expected the plan to only include worker2, but got %v", updatedPlan.Worker.Nodes)
+	}
+	if updatedPlan.Worker.ExpectedCount != 2 {
+		t.Errorf("expected count was not updated, got %d", updatedPlan.Worker.ExpectedCount)
+	}
+	if len(originalPlan.Worker.Nodes) != 1 {
+		t.Errorf("the original plan was modified")
+	}
+	// the playbook is run against both workers, and then retried against worker2
+	if len(runner.limits) < 2 || len(runner.limits[0]) != 2 || len(runner.limits[1]) != 1 || runner.limits[1][0] != "worker2" {
+		t.Errorf("unexpected limits: %v", runner.limits)
+	}
+}
+
+func TestAddWorkersUnattributedFailureFailsAllWorkers(t *testing.T) {
+	e := ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		pki:                    &fakePKI{caExists: true},
+		runnerExplainerFactory: fakeRunnerExplainer(errors.New("exec error")),
+		certsDir:               mustGetTempDir(t),
+	}
+	originalPlan := addWorkersTestPlan()
+	updatedPlan, results, err := e.AddWorkers(originalPlan, []Node{{Host: "worker1"}, {Host: "worker2"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, r := range results {
+		if r.Error == nil {
+			t.Errorf("expected an error for %q, but didn't get one", r.Node.Host)
+		}
+	}
+	if len(updatedPlan.Worker.Nodes) != 1 {
+		t.Errorf("expected no workers to be added to the plan, but got %v", updatedPlan.Worker.Nodes)
+	}
+}
+
+func TestAddWorkersCAMissing(t *testing.T) {
+	e := ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		pki:                    &fakePKI{},
+		runnerExplainerFactory: fakeRunnerExplainer(nil),
+		certsDir:               mustGetTempDir(t),
+	}
+	_, results, err := e.AddWorkers(addWorkersTestPlan(), []Node{{Host: "worker1"}, {Host: "worker2"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, r := range results {
+		if r.Error != errMissingClusterCA {
+			t.Errorf("expected %v for %q, but got %v", errMissingClusterCA, r.Node.Host, r.Error)
+		}
+	}
+}
+
+// runs the playbooks, failing on the hosts given for each playbook. Playbooks
+// that run without a limit fail if they run for one of the given workers.
+type playbookFailuresRunner struct {
+	fakeRunner
+	failures  map[string][]string
+	explainer explain.AnsibleEventExplainer
+}
+
+func (r *playbookFailuresRunner) StartPlaybook(playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog) (<-chan ansible.Event, error) {
+	r.err = nil
+	for _, host := range r.failures[playbookFile] {
+		if cc.WorkerNode == host {
+			r.err = errors.New("playbook failed")
+		}
+	}
+	return r.fakeRunner.StartPlaybook(playbookFile, inventory, cc)
+}
+
+func (r *playbookFailuresRunner) StartPlaybookOnNode(playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog, node ...string) (<-chan ansible.Event, error) {
+	r.err = nil
+	for _, host := range r.failures[playbookFile] {
+		for _, n := range node {
+			if n != host {
+				continue
+			}
+			e := &ansible.RunnerFailedEvent{}
+			e.Host = host
+			e.Result.Message = "something went wrong"
+			r.explainer.ExplainEvent(e)
+			r.err = errors.New("playbook failed")
+		}
+	}
+	return r.fakeRunner.StartPlaybookOnNode(playbookFile, inventory, cc, node...)
+}
+
+func playbookFailuresRunnerExplainer(runner *playbookFailuresRunner) func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+	return func(explainer explain.AnsibleEventExplainer, w io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+		runner.explainer = explainer
+		return runner, &explain.AnsibleEventStreamExplainer{}, nil
+	}
+}
+
+func TestRunNewWorkersPreFlightCheckPerWorker(t *testing.T) {
+	runner := &playbookFailuresRunner{failures: map[string][]string{"preflight.yaml": {"worker1"}}}
+	e := ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		inspectorPKI:           &fakePKI{},
+		runnerExplainerFactory: playbookFailuresRunnerExplainer(runner),
+		certsDir:               mustGetTempDir(t),
+	}
+	errs, err := e.RunNewWorkersPreFlightCheck(*addWorkersTestPlan(), []Node{{Host: "worker1"}, {Host: "worker2"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if errs["worker1"] == nil {
+		t.Errorf("expected worker1 to fail the pre-flight checks")
+	}
+	if errs["worker2"] != nil {
+		t.Errorf("expected worker2 to pass the pre-flight checks, but got %v", errs["worker2"])
+	}
+}
+
+func TestAddWorkersHostsFileFailures(t *testing.T) {
+	runner := &playbookFailuresRunner{failures: map[string][]string{"_hosts.yaml": {"worker1", "existingWorker"}}}
+	e := ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		pki:                    &fakePKI{caExists: true},
+		runnerExplainerFactory: playbookFailuresRunnerExplainer(runner),
+		certsDir:               mustGetTempDir(t),
+	}
+	originalPlan := addWorkersTestPlan()
+	originalPlan.Cluster.Networking.UpdateHostsFiles = true
+	updatedPlan, results, err := e.AddWorkers(originalPlan, []Node{{Host: "worker1"}, {Host: "worker2"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Error == nil {
+		t.Errorf("expected worker1 to fail")
+	}
+	// the failure on the existing node does not fail worker2
+	if results[1].Error != nil {
+		t.Errorf("expected worker2 to succeed, but got %v", results[1].Error)
+	}
+	if len(updatedPlan.Worker.Nodes) != 2 || updatedPlan.Worker.Nodes[1].Host != "worker2" {
+		t.Errorf("expected the plan to include worker2, but got %v", updatedPlan.Worker.Nodes)
+	}
+	var lastHostsLimit []string
+	for i, p := range runner.nodePlaybooks {
+		if p == "_hosts.yaml" {
+			lastHostsLimit = runner.limits[i]
+		}
+	}
+	for _, host := range lastHostsLimit {
+		if host == "worker1" || host == "existingWorker" {
+			t.Errorf("expected the failed nodes to be left out of the hosts file update, but got %v", lastHostsLimit)
+		}
+	}
+}
+
+func TestAddWorkersVolumeUpdateFailure(t *testing.T) {
+	runner := &playbookFailuresRunner{failures: map[string][]string{"_volume-update-allowed.yaml": {"worker2"}}}
+	e := ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		pki:                    &fakePKI{caExists: true},
+		runnerExplainerFactory: playbookFailuresRunnerExplainer(runner),
+		certsDir:               mustGetTempDir(t),
+	}
+	originalPlan := addWorkersTestPlan()
+	originalPlan.Storage.Nodes = []Node{{Host: "storage1"}}
+	_, results, err := e.AddWorkers(originalPlan, []Node{{Host: "worker1"}, {Host: "worker2"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Error != nil {
+		t.Errorf("expected worker1 to succeed, but got %v", results[0].Error)
+	}
+	if results[1].Error == nil {
+		t.Errorf("expected worker2 to fail")
+	}
+}
+
+func TestNewWorkersPreFlightInspectorCertificates(t *testing.T) {
+	clusterPKI := &fakePKI{}
+	pki := &fakePKI{}
+	e := ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		pki:                    clusterPKI,
+		inspectorPKI:           pki,
+		runnerExplainerFactory: fakeRunnerExplainer(nil),
+		certsDir:               mustGetTempDir(t),
+	}
+	p := addWorkersTestPlan()
+	p.Master.Nodes[0].Host = "master1"
+	p.Master.Nodes[0].IP = "10.10.1.20"
+	newWorkers := []Node{{Host: "worker1", IP: "10.10.1.30", InternalIP: "10.10.2.30"}, {Host: "worker2", IP: "10.10.1.31"}}
+	if _, err := e.RunNewWorkersPreFlightCheck(*p, newWorkers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the new workers, and the nodes that run the inspector clients
+	expected := map[string][]string{
+		"worker1-inspector":        {"worker1", "10.10.1.30", "10.10.2.30"},
+		"worker2-inspector":        {"worker2", "10.10.1.31"},
+		"master1-inspector":        {"master1", "10.10.1.20", "10.10.2.20"},
+		"existingWorker-inspector": {"existingWorker", ""},
+	}
+	if !reflect.DeepEqual(pki.certificates, expected) {
+		t.Errorf("expected inspector certificates %v, but got %v", expected, pki.certificates)
+	}
+	for name, orgs := range pki.organizations {
+		if !reflect.DeepEqual(orgs, []string{inspector.CertificateOrganization}) {
+			t.Errorf("expected certificate %q to be issued to the inspector, but got organizations %v", name, orgs)
+		}
+	}
+	if clusterPKI.generateCACalled || len(clusterPKI.certificates) > 0 {
+		t.Errorf("expected the cluster CA not to be used for the inspector certificates")
+	}
+}
@@ -0,0 +1,194 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+const (
+	etcdSnapshotFilename  = "etcd-k8s-snapshot.db"
+	backupPlanFilename    = "kismatic-cluster.yaml"
+	backupCertsDirectory  = "keys"
+	etcdBackupNameFormat  = "2006-01-02-15-04-05"
+	backupsDirectoryName  = "backups"
+	backupDirectoryPerms  = 0700
+	backupCertsFilesPerms = 0600
+)
+
+// EtcdBackup is a snapshot of the Kubernetes etcd cluster that is kept in the
+// generated assets directory, along with the plan file and certificates that
+// were in use when the snapshot was taken.
+type EtcdBackup struct {
+	Name         string
+	Directory    string
+	CreatedAt    time.Time
+	SnapshotSize int64
+}
+
+// SnapshotFile returns the path to the etcd snapshot of the backup
+func (b EtcdBackup) SnapshotFile() string {
+	return filepath.Join(b.Directory, etcdSnapshotFilename)
+}
+
+// PlanFile returns the path to the plan file that was recorded with the backup
+func (b EtcdBackup) PlanFile() string {
+	return filepath.Join(b.Directory, backupPlanFilename)
+}
+
+// BackupsDirectory returns the directory where backups are stored within
+// the generated assets directory
+func BackupsDirectory(generatedAssetsDir string) string {
+	return filepath.Join(generatedAssetsDir, backupsDirectoryName)
+}
+
+// NewEtcdBackupName returns the name of a backup that is taken at the given time
+func NewEtcdBackupName(t time.Time) string {
+	return t.Format(etcdBackupNameFormat)
+}
+
+// ListEtcdBackups returns the backups found in the backups directory, sorted
+// from oldest to newest. Directories that do not contain an etcd snapshot are
+// ignored.
+func ListEtcdBackups(backupsDir string) ([]EtcdBackup, error) {
+	files, err := ioutil.ReadDir(backupsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading backups directory %q: %v", backupsDir, err)
+	}
+	var backups []EtcdBackup
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		b, err := GetEtcdBackup(backupsDir, f.Name())
+		if err != nil {
+			continue
+		}
+		backups = append(backups, *b)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.Before(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// GetEtcdBackup returns the backup with the given name
+func GetEtcdBackup(backupsDir string, name string) (*EtcdBackup, error) {
+	b := EtcdBackup{
+		Name:      name,
+		Directory: filepath.Join(backupsDir, name),
+	}
+	fi, err := os.Stat(b.SnapshotFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("backup %q was not found in %q", name, backupsDir)
+		}
+		return nil, fmt.Errorf("error reading etcd snapshot of backup %q: %v", name, err)
+	}
+	b.SnapshotSize = fi.Size()
+	b.CreatedAt = fi.ModTime()
+	if t, err := time.ParseInLocation(etcdBackupNameFormat, name, time.Local); err == nil {
+		b.CreatedAt = t
+	}
+	return &b, nil
+}
+
+// BackupEtcd takes a snapshot of the Kubernetes etcd cluster and copies it
+// into the backup directory, together with the plan file and the cluster
+// certificates.
+func (ae *ansibleExecutor) BackupEtcd(plan Plan, backupDir string) error {
+	if err := os.MkdirAll(backupDir, backupDirectoryPerms); err != nil {
+		return fmt.Errorf("error creating backup directory %q: %v", backupDir, err)
+	}
+	// absolute path required for ansible
+	absBackupDir, err := filepath.Abs(backupDir)
+	if err != nil {
+		return fmt.Errorf("failed to determine absolute path to %s: %v", backupDir, err)
+	}
+	cc, err := ae.buildClusterCatalog(&plan)
+	if err != nil {
+		return err
+	}
+	cc.EtcdBackupDirectory = absBackupDir
+	cc.EtcdSnapshotFile = etcdSnapshotFilename
+	t := task{
+		name:           "backup-etcd",
+		playbook:       "backup-etcd.yaml",
+		inventory:      buildInventoryFromPlan(&plan),
+		clusterCatalog: *cc,
+		plan:           plan,
+		explainer:      ae.defaultExplainer(),
+	}
+	util.PrintHeader(ae.stdout, "Backup Etcd Cluster", '=')
+	if err = ae.execute(t); err != nil {
+		return err
+	}
+
+	// Keep the plan file and certificates that go with the snapshot
+	fp := FilePlanner{File: filepath.Join(backupDir, backupPlanFilename)}
+	if err = fp.Write(&plan); err != nil {
+		return fmt.Errorf("error recording plan file to %s: %v", fp.File, err)
+	}
+	if err = copyCertificates(ae.certsDir, filepath.Join(backupDir, backupCertsDirectory)); err != nil {
+		return fmt.Errorf("error copying certificates to backup: %v", err)
+	}
+	return nil
+}
+
+// RestoreEtcd stops the Kubernetes control plane, restores every member of
+// the Kubernetes etcd cluster from the snapshot found in the backup directory,
+// and starts the control plane again.
+func (ae *ansibleExecutor) RestoreEtcd(plan Plan, backupDir string) error {
+	snapshot := filepath.Join(backupDir, etcdSnapshotFilename)
+	if _, err := os.Stat(snapshot); err != nil {
+		return fmt.Errorf("error reading etcd snapshot %q: %v", snapshot, err)
+	}
+	// absolute path required for ansible
+	absBackupDir, err := filepath.Abs(backupDir)
+	if err != nil {
+		return fmt.Errorf("failed to determine absolute path to %s: %v", backupDir, err)
+	}
+	cc, err := ae.buildClusterCatalog(&plan)
+	if err != nil {
+		return err
+	}
+	cc.EtcdBackupDirectory = absBackupDir
+	cc.EtcdSnapshotFile = etcdSnapshotFilename
+	t := task{
+		name:           "restore-etcd",
+		playbook:       "restore-etcd.yaml",
+		inventory:      buildInventoryFromPlan(&plan),
+		clusterCatalog: *cc,
+		plan:           plan,
+		explainer:      ae.defaultExplainer(),
+	}
+	util.PrintHeader(ae.stdout, "Restore Etcd Cluster", '=')
+	return ae.execute(t)
+}
+
+func copyCertificates(certsDir string, destDir string) error {
+	files, err := ioutil.ReadDir(certsDir)
+	if err != nil {
+		return fmt.Errorf("error reading certificates directory %q: %v", certsDir, err)
+	}
+	if err = os.MkdirAll(destDir, backupDirectoryPerms); err != nil {
+		return fmt.Errorf("error creating directory %q: %v", destDir, err)
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if err = util.CopyFile(filepath.Join(certsDir, f.Name()), filepath.Join(destDir, f.Name()), backupCertsFilesPerms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
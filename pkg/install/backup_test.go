@@ -0,0 +1,115 @@
+package install
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+func TestBackupEtcdRecordsPlanAndCertificates(t *testing.T) {
+	certsDir := mustGetTempDir(t)
+	if err := ioutil.WriteFile(filepath.Join(certsDir, "ca.pem"), []byte("ca"), 0644); err != nil {
+		t.Fatalf("error writing CA: %v", err)
+	}
+	fakeRunner := fakeRunner{}
+	e := ansibleExecutor{
+		options:             ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:              ioutil.Discard,
+		consoleOutputFormat: ansible.RawFormat,
+		runnerExplainerFactory: func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+			return &fakeRunner, &explain.AnsibleEventStreamExplainer{}, nil
+		},
+		certsDir: certsDir,
+	}
+	plan := Plan{
+		Master: MasterNodeGroup{
+			Nodes: []Node{{InternalIP: "10.10.2.20"}},
+		},
+		Cluster: Cluster{
+			Networking: NetworkConfig{
+				ServiceCIDRBlock: "10.0.0.0/16",
+			},
+		},
+	}
+	backupDir := filepath.Join(mustGetTempDir(t), "backup")
+	if err := e.BackupEtcd(plan, backupDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fakeRunner.allNodesPlaybooks) != 1 || fakeRunner.allNodesPlaybooks[0] != "backup-etcd.yaml" {
+		t.Errorf("expected backup-etcd.yaml to run, but got %v", fakeRunner.allNodesPlaybooks)
+	}
+	if !filepath.IsAbs(fakeRunner.incomingCatalog.EtcdBackupDirectory) {
+		t.Errorf("expected an absolute backup directory, but got %q", fakeRunner.incomingCatalog.EtcdBackupDirectory)
+	}
+	if _, err := os.Stat(filepath.Join(backupDir, backupPlanFilename)); err != nil {
+		t.Errorf("plan file was not recorded in the backup: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(backupDir, backupCertsDirectory, "ca.pem")); err != nil {
+		t.Errorf("certificates were not copied to the backup: %v", err)
+	}
+}
+
+func TestRestoreEtcdSnapshotMissing(t *testing.T) {
+	e := ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		runnerExplainerFactory: fakeRunnerExplainer(nil),
+		certsDir:               mustGetTempDir(t),
+	}
+	if err := e.RestoreEtcd(Plan{}, mustGetTempDir(t)); err == nil {
+		t.Errorf("expected an error, but didn't get one")
+	}
+}
+
+func TestListEtcdBackups(t *testing.T) {
+	backupsDir := mustGetTempDir(t)
+	now := time.Now()
+	names := []string{
+		NewEtcdBackupName(now),
+		NewEtcdBackupName(now.Add(-time.Hour)),
+	}
+	for _, n := range names {
+		dir := filepath.Join(backupsDir, n)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatalf("error creating backup dir: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, etcdSnapshotFilename), []byte("snapshot"), 0600); err != nil {
+			t.Fatalf("error writing snapshot: %v", err)
+		}
+	}
+	// directories without a snapshot are not backups
+	if err := os.MkdirAll(filepath.Join(backupsDir, "incomplete"), 0700); err != nil {
+		t.Fatalf("error creating dir: %v", err)
+	}
+
+	backups, err := ListEtcdBackups(backupsDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, but got %d", len(backups))
+	}
+	if backups[0].Name != names[1] || backups[1].Name != names[0] {
+		t.Errorf("expected backups to be sorted oldest first, got %s, %s", backups[0].Name, backups[1].Name)
+	}
+	if backups[0].SnapshotSize != int64(len("snapshot")) {
+		t.Errorf("expected snapshot size %d, got %d", len("snapshot"), backups[0].SnapshotSize)
+	}
+}
+
+func TestListEtcdBackupsDirectoryMissing(t *testing.T) {
+	backups, err := ListEtcdBackups(filepath.Join(mustGetTempDir(t), "doesnotexist"))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(backups) != 0 {
+		t.Errorf("expected no backups, but got %d", len(backups))
+	}
+}
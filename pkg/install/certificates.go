@@ -0,0 +1,330 @@
+package install
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ssh"
+	"github.com/apprenda/kismatic/pkg/tls"
+	"github.com/cloudflare/cfssl/helpers"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ansibleGroupVarsDir contains the ansible variables that define the location
+// of the certificates on the nodes
+var ansibleGroupVarsDir = filepath.Join("ansible", "playbooks", "group_vars")
+
+// The groups of variables of the etcd clusters
+var etcdClusterGroupVars = []string{"etcd-k8s.yaml", "etcd-networking.yaml"}
+
+var ansibleVarRegexp = regexp.MustCompile(`{{\s*(\w+)\s*}}`)
+
+// CertificateInfo contains information about a certificate of the cluster
+// that is stored in the generated assets directory
+type CertificateInfo struct {
+	Name                  string                `json:"name"`
+	Description           string                `json:"description"`
+	Missing               bool                  `json:"missing,omitempty"`
+	Subject               string                `json:"subject,omitempty"`
+	SubjectAlternateNames []string              `json:"subjectAlternateNames,omitempty"`
+	Organizations         []string              `json:"organizations,omitempty"`
+	Issuer                string                `json:"issuer,omitempty"`
+	NotAfter              time.Time             `json:"notAfter,omitempty"`
+	DaysRemaining         int                   `json:"daysRemaining"`
+	Fingerprint           string                `json:"fingerprint,omitempty"`
+	Deployed              []DeployedCertificate `json:"deployed,omitempty"`
+}
+
+// DeployedCertificate is a copy of a cluster certificate found on a node
+type DeployedCertificate struct {
+	Host        string `json:"host"`
+	Path        string `json:"path"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Match       bool   `json:"match"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Drift returns the deployed copies of the certificate that do not match the local copy
+func (c CertificateInfo) Drift() []DeployedCertificate {
+	var drift []DeployedCertificate
+	for _, d := range c.Deployed {
+		if !d.Match {
+			drift = append(drift, d)
+		}
+	}
+	return drift
+}
+
+// ListClusterCertificates returns information about the Certificate Authority
+// and the certificates required by the cluster described in the plan.
+func ListClusterCertificates(p *Plan, certsDir string) ([]CertificateInfo, error) {
+	manifest, err := certManifestForCluster(*p)
+	if err != nil {
+		return nil, err
+	}
+	manifest = append([]certificateSpec{{description: "cluster certificate authority", filename: "ca"}}, manifest...)
+	now := time.Now()
+	certs := make([]CertificateInfo, 0, len(manifest))
+	for _, s := range manifest {
+		info := CertificateInfo{
+			Name:        s.filename,
+			Description: s.description,
+		}
+		exists, err := tls.CertKeyPairExists(s.filename, certsDir)
+		if err != nil {
+			return nil, fmt.Errorf("error checking if certificate for %s exists: %v", s.description, err)
+		}
+		if !exists {
+			info.Missing = true
+			certs = append(certs, info)
+			continue
+		}
+		cert, err := tls.ReadCert(s.filename, certsDir)
+		if err != nil {
+			return nil, fmt.Errorf("error reading certificate for %s: %v", s.description, err)
+		}
+		info.Subject = cert.Subject.CommonName
+		info.SubjectAlternateNames = certSubjectAlternateNames(cert)
+		info.Organizations = cert.Subject.Organization
+		info.Issuer = cert.Issuer.CommonName
+		info.NotAfter = cert.NotAfter
+		info.DaysRemaining = int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24))
+		info.Fingerprint = certFingerprint(cert)
+		certs = append(certs, info)
+	}
+	return certs, nil
+}
+
+// CompareDeployedCertificates connects to the nodes in the plan and compares
+// the certificates deployed on them with the local copies. The location of
+// the certificates is read from the ansible variables.
+func CompareDeployedCertificates(p *Plan, certs []CertificateInfo) error {
+	locations, err := readCertificateLocations(ansibleGroupVarsDir)
+	if err != nil {
+		return err
+	}
+	return compareDeployedCertificates(p, certs, locations, func(node Node) (ssh.Client, error) {
+		return p.GetSSHClient(node.Host)
+	})
+}
+
+func compareDeployedCertificates(p *Plan, certs []CertificateInfo, locations *certificateLocations, connect func(node Node) (ssh.Client, error)) error {
+	byName := make(map[string]*CertificateInfo, len(certs))
+	for i := range certs {
+		byName[certs[i].Name] = &certs[i]
+	}
+	for _, node := range p.GetUniqueNodes() {
+		if err := compareNodeCertificates(*p, node, byName, locations, connect); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareNodeCertificates compares the certificates deployed on the node
+// with the local copies. An error is returned if the node cannot be reached.
+func compareNodeCertificates(p Plan, node Node, byName map[string]*CertificateInfo, locations *certificateLocations, connect func(node Node) (ssh.Client, error)) error {
+	client, err := connect(node)
+	if err != nil {
+		return fmt.Errorf("error connecting to node %q: %v", node.Host, err)
+	}
+	defer client.Close()
+	paths := locations.deployedCertificatePaths(p, node)
+	for _, name := range sortedKeys(paths) {
+		info, ok := byName[name]
+		if !ok || info.Missing {
+			continue
+		}
+		for _, path := range paths[name] {
+			d := DeployedCertificate{
+				Host: node.Host,
+				Path: path,
+			}
+			out, err := client.Output(true, fmt.Sprintf("sudo cat %s", path))
+			if ssh.IsConnectionError(err) {
+				return fmt.Errorf("error connecting to node %q: %v", node.Host, err)
+			}
+			if err != nil {
+				// the output contains the actual error message from the remote command
+				d.Error = fmt.Sprintf("error reading certificate: %v %s", err, strings.TrimSpace(out))
+				info.Deployed = append(info.Deployed, d)
+				continue
+			}
+			cert, err := helpers.ParseCertificatePEM([]byte(out))
+			if err != nil {
+				d.Error = fmt.Sprintf("error parsing certificate: %v", err)
+				info.Deployed = append(info.Deployed, d)
+				continue
+			}
+			d.Fingerprint = certFingerprint(cert)
+			d.Match = d.Fingerprint == info.Fingerprint
+			info.Deployed = append(info.Deployed, d)
+		}
+	}
+	return nil
+}
+
+// certificateLocations are the locations of the certificates on the nodes,
+// keyed by the name of the certificate in the ansible variables
+type certificateLocations struct {
+	kubernetes map[string]string
+	// etcd contains the locations in each of the etcd clusters
+	etcd []map[string]string
+}
+
+// readCertificateLocations reads the location of the certificates from the
+// kubernetes_certificates and etcd_certificates ansible variables
+func readCertificateLocations(groupVarsDir string) (*certificateLocations, error) {
+	vars, err := readAnsibleVars(filepath.Join(groupVarsDir, "all.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	locations := &certificateLocations{}
+	locations.kubernetes, err = resolveCertificateLocations(vars, "kubernetes_certificates")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range etcdClusterGroupVars {
+		groupVars, err := readAnsibleVars(filepath.Join(groupVarsDir, f))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range vars {
+			if _, ok := groupVars[k]; !ok {
+				groupVars[k] = v
+			}
+		}
+		etcd, err := resolveCertificateLocations(groupVars, "etcd_certificates")
+		if err != nil {
+			return nil, err
+		}
+		locations.etcd = append(locations.etcd, etcd)
+	}
+	return locations, nil
+}
+
+func readAnsibleVars(file string) (map[string]interface{}, error) {
+	d, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ansible variables: %v", err)
+	}
+	vars := map[string]interface{}{}
+	if err := yaml.Unmarshal(d, &vars); err != nil {
+		return nil, fmt.Errorf("error unmarshaling ansible variables in %q: %v", file, err)
+	}
+	return vars, nil
+}
+
+// resolveCertificateLocations returns the paths in the dictionary variable
+// with the given name, after replacing the variables they refer to
+func resolveCertificateLocations(vars map[string]interface{}, name string) (map[string]string, error) {
+	dict, ok := vars[name].(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("ansible variable %q is not defined", name)
+	}
+	locations := map[string]string{}
+	for k, v := range dict {
+		path, ok := v.(string)
+		if !ok || !strings.HasSuffix(path, ".pem") {
+			continue
+		}
+		resolved, err := resolveAnsibleVars(path, vars)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving ansible variable %s.%v: %v", name, k, err)
+		}
+		locations[fmt.Sprint(k)] = resolved
+	}
+	return locations, nil
+}
+
+// resolveAnsibleVars replaces the variables in the value, as in "{{ name }}",
+// with their values. Only variables that do not use filters are supported.
+func resolveAnsibleVars(value string, vars map[string]interface{}) (string, error) {
+	// variables can refer to other variables
+	for i := 0; i < 10 && strings.Contains(value, "{{"); i++ {
+		var err error
+		value = ansibleVarRegexp.ReplaceAllStringFunc(value, func(ref string) string {
+			name := ansibleVarRegexp.FindStringSubmatch(ref)[1]
+			v, ok := vars[name]
+			if !ok {
+				err = fmt.Errorf("variable %q is not defined", name)
+				return ref
+			}
+			return fmt.Sprint(v)
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	if strings.Contains(value, "{{") {
+		return "", fmt.Errorf("cannot resolve %q", value)
+	}
+	return value, nil
+}
+
+// returns the location of the cluster certificates on the given node, keyed by
+// the name of the certificate in the generated assets directory
+func (l certificateLocations) deployedCertificatePaths(plan Plan, node Node) map[string][]string {
+	paths := map[string][]string{}
+	add := func(name, path string) {
+		if path != "" {
+			paths[name] = append(paths[name], path)
+		}
+	}
+	roles := plan.GetRolesForIP(node.IP)
+	if contains("etcd", roles) {
+		for _, etcd := range l.etcd {
+			add("ca", etcd["ca"])
+			add(fmt.Sprintf("%s-etcd", node.Host), etcd["etcd"])
+			add("etcd-client", etcd["etcd_client"])
+		}
+	}
+	if containsAny([]string{"master", "worker", "ingress", "storage"}, roles) {
+		add("ca", l.kubernetes["ca"])
+		add(adminCertFilename, l.kubernetes["admin"])
+		add(fmt.Sprintf("%s-kubelet", node.Host), l.kubernetes["kubelet"])
+		add("etcd-client", l.kubernetes["etcd_client"])
+	}
+	if contains("master", roles) {
+		add(fmt.Sprintf("%s-apiserver", node.Host), l.kubernetes["api_server"])
+		add(schedulerCertFilenamePrefix, l.kubernetes["scheduler"])
+		add(controllerManagerCertFilenamePrefix, l.kubernetes["controller_manager"])
+		add(serviceAccountCertFilename, l.kubernetes["service_account"])
+	}
+	return paths
+}
+
+func certSubjectAlternateNames(cert *x509.Certificate) []string {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return sans
+}
+
+// returns the SHA-256 fingerprint of the certificate, formatted like openssl does
+func certFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hex, ":")
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
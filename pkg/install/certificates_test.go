@@ -0,0 +1,192 @@
+package install
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ssh"
+)
+
+type fakeSSHClient struct {
+	readFile func(path string) (string, error)
+	closed   bool
+}
+
+func (c *fakeSSHClient) Output(pty bool, args ...string) (string, error) {
+	return c.readFile(strings.TrimPrefix(strings.Join(args, " "), "sudo cat "))
+}
+
+func (c *fakeSSHClient) Shell(pty bool, args ...string) error { return nil }
+
+func (c *fakeSSHClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestListClusterCertificates(t *testing.T) {
+	pki := getPKI(t)
+	defer cleanup(pki.GeneratedCertsDirectory, t)
+	p := getPlan()
+	ca, err := pki.GenerateClusterCA(p)
+	if err != nil {
+		t.Fatalf("error generating CA: %v", err)
+	}
+	if err = pki.GenerateClusterCertificates(p, ca); err != nil {
+		t.Fatalf("error generating certificates: %v", err)
+	}
+	// remove one of the certificates
+	if err = os.Remove(filepath.Join(pki.GeneratedCertsDirectory, "etcd01-etcd.pem")); err != nil {
+		t.Fatalf("error removing certificate: %v", err)
+	}
+
+	certs, err := ListClusterCertificates(p, pki.GeneratedCertsDirectory)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if certs[0].Name != "ca" || certs[0].Missing {
+		t.Errorf("expected the CA to be listed first, but got %+v", certs[0])
+	}
+	var found bool
+	for _, c := range certs {
+		if c.Name == "etcd01-etcd" {
+			found = true
+			if !c.Missing {
+				t.Errorf("expected etcd01-etcd to be missing")
+			}
+			continue
+		}
+		if c.Missing {
+			t.Errorf("certificate %s was unexpectedly missing", c.Name)
+		}
+		if c.Name == "etcd02-etcd" && c.Subject != "etcd02" {
+			t.Errorf("expected subject etcd02, but got %q", c.Subject)
+		}
+		if c.Issuer != certs[0].Subject {
+			t.Errorf("expected %s to be issued by %q, but got %q", c.Name, certs[0].Subject, c.Issuer)
+		}
+		// certificate expiry in the test plan is 1h
+		if c.Name != "ca" && c.DaysRemaining != 0 {
+			t.Errorf("expected 0 days remaining for %s, but got %d", c.Name, c.DaysRemaining)
+		}
+	}
+	if !found {
+		t.Errorf("etcd01-etcd was not listed")
+	}
+}
+
+func TestCompareDeployedCertificates(t *testing.T) {
+	pki := getPKI(t)
+	defer cleanup(pki.GeneratedCertsDirectory, t)
+	p := getPlan()
+	ca, err := pki.GenerateClusterCA(p)
+	if err != nil {
+		t.Fatalf("error generating CA: %v", err)
+	}
+	if err = pki.GenerateClusterCertificates(p, ca); err != nil {
+		t.Fatalf("error generating certificates: %v", err)
+	}
+	certs, err := ListClusterCertificates(p, pki.GeneratedCertsDirectory)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	otherPEM, err := ioutil.ReadFile(filepath.Join(pki.GeneratedCertsDirectory, "etcd-client.pem"))
+	if err != nil {
+		t.Fatalf("error reading certificate: %v", err)
+	}
+	readFile := func(node Node, path string) (string, error) {
+		if node.Host == "etcd02" && path == "/etc/etcd_k8s/etcd.pem" {
+			// a certificate that does not match the local copy
+			return string(otherPEM), nil
+		}
+		if node.Host == "etcd02" && path == "/etc/etcd_networking/etcd.pem" {
+			return "cat: no such file", fmt.Errorf("exit status 1")
+		}
+		name := map[string]string{
+			"/etc/etcd_k8s/ca.pem":          "ca",
+			"/etc/etcd_networking/ca.pem":   "ca",
+			"/etc/etcd_k8s/etcd.pem":        node.Host + "-etcd",
+			"/etc/etcd_networking/etcd.pem": node.Host + "-etcd",
+		}[path]
+		if name == "" {
+			name = "etcd-client"
+		}
+		b, err := ioutil.ReadFile(filepath.Join(pki.GeneratedCertsDirectory, name+".pem"))
+		return string(b), err
+	}
+	locations, err := readCertificateLocations("../../ansible/group_vars")
+	if err != nil {
+		t.Fatalf("error reading the location of the certificates: %v", err)
+	}
+	clients := []*fakeSSHClient{}
+	connect := func(node Node) (ssh.Client, error) {
+		c := &fakeSSHClient{readFile: func(path string) (string, error) { return readFile(node, path) }}
+		clients = append(clients, c)
+		return c, nil
+	}
+	if err = compareDeployedCertificates(p, certs, locations, connect); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, c := range clients {
+		if !c.closed {
+			t.Errorf("expected all the SSH clients to be closed")
+		}
+	}
+	for _, c := range certs {
+		switch c.Name {
+		case "etcd01-etcd":
+			if len(c.Deployed) != 2 || len(c.Drift()) != 0 {
+				t.Errorf("expected 2 matching deployed certificates for %s, got %+v", c.Name, c.Deployed)
+			}
+		case "etcd02-etcd":
+			drift := c.Drift()
+			if len(drift) != 2 {
+				t.Fatalf("expected 2 drifted certificates for %s, got %+v", c.Name, drift)
+			}
+			if drift[0].Error != "" || drift[0].Fingerprint == "" {
+				t.Errorf("expected a fingerprint mismatch, got %+v", drift[0])
+			}
+			if drift[1].Error == "" {
+				t.Errorf("expected an error reading the certificate, got %+v", drift[1])
+			}
+		}
+	}
+}
+
+func TestCompareDeployedCertificatesConnectionError(t *testing.T) {
+	p := getPlan()
+	locations, err := readCertificateLocations("../../ansible/group_vars")
+	if err != nil {
+		t.Fatalf("error reading the location of the certificates: %v", err)
+	}
+	connect := func(node Node) (ssh.Client, error) {
+		return nil, errors.New("connection refused")
+	}
+	err = compareDeployedCertificates(p, []CertificateInfo{{Name: "ca"}}, locations, connect)
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("expected an error connecting to the node, but got %v", err)
+	}
+}
+
+func TestReadCertificateLocations(t *testing.T) {
+	locations, err := readCertificateLocations("../../ansible/group_vars")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if locations.kubernetes["api_server"] != "/etc/kubernetes/pki/api-server.pem" {
+		t.Errorf("unexpected location of the API server certificate %q", locations.kubernetes["api_server"])
+	}
+	etcd := []string{}
+	for _, l := range locations.etcd {
+		etcd = append(etcd, l["etcd"])
+	}
+	expected := []string{"/etc/etcd_k8s/etcd.pem", "/etc/etcd_networking/etcd.pem"}
+	if !reflect.DeepEqual(etcd, expected) {
+		t.Errorf("expected etcd certificates at %v, but got %v", expected, etcd)
+	}
+}
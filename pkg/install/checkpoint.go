@@ -0,0 +1,199 @@
+package install
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+	"github.com/apprenda/kismatic/pkg/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const checkpointFilename = "checkpoint.json"
+
+// checkpoint records the plays that completed on each host during a run.
+// The plays are identified by the "checkpoint" variable that is set on the
+// plays of the playbook, as the play names are not stable.
+type checkpoint struct {
+	// PlanDigest is the digest of the plan that was used for the run
+	PlanDigest string `json:"planDigest"`
+	// CompletedPlays contains the checkpoints of the plays that completed, keyed by host
+	CompletedPlays map[string][]string `json:"completedPlays"`
+}
+
+// returns the SHA-256 digest of the plan
+func planDigest(p Plan) (string, error) {
+	b, err := yaml.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling plan to yaml: %v", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// readCheckpoint returns the checkpoint of the most recent run of the given
+// task that recorded one, or nil if there is none.
+func readCheckpoint(runsDir string, taskName string) (*checkpoint, error) {
+	taskDir := filepath.Join(runsDir, taskName)
+	files, err := ioutil.ReadDir(taskDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error listing runs in %q: %v", taskDir, err)
+	}
+	// the run directories are named after the time they were created,
+	// so the most recent run comes first in reverse order
+	var runs []string
+	for _, f := range files {
+		if f.IsDir() {
+			runs = append(runs, f.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(runs)))
+	for _, r := range runs {
+		b, err := ioutil.ReadFile(filepath.Join(taskDir, r, checkpointFilename))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading checkpoint: %v", err)
+		}
+		cp := &checkpoint{}
+		if err = json.Unmarshal(b, cp); err != nil {
+			return nil, fmt.Errorf("error reading checkpoint in %q: %v", filepath.Join(taskDir, r), err)
+		}
+		return cp, nil
+	}
+	return nil, nil
+}
+
+// resumeFromCheckpoint sets the plays that completed in the most recent run
+// of the task in the cluster catalog, so that they are skipped. The plays are
+// only skipped if the plan has not changed since that run.
+func (ae *ansibleExecutor) resumeFromCheckpoint(taskName string, p Plan, cc *ansible.ClusterCatalog) error {
+	cp, err := readCheckpoint(ae.options.RunsDirectory, taskName)
+	if err != nil {
+		return err
+	}
+	if cp == nil {
+		util.PrettyPrintWarn(ae.stdout, "No checkpoint was found for a previous run, all plays will be run")
+		return nil
+	}
+	digest, err := planDigest(p)
+	if err != nil {
+		return err
+	}
+	if cp.PlanDigest != digest {
+		util.PrettyPrintWarn(ae.stdout, "The plan has changed since the checkpoint was recorded, all plays will be run")
+		return nil
+	}
+	cc.CompletedPlays = cp.CompletedPlays
+	util.PrettyPrintOk(ae.stdout, "Resuming from checkpoint, plays that completed on a node will be skipped")
+	return nil
+}
+
+// checkpointRecorder records the plays that complete on each host to the
+// checkpoint file, and passes the events on to the wrapped explainer.
+// Plays without a checkpoint are not recorded.
+// A play is considered complete on the hosts it ran on when the next play
+// starts, or when the playbook ends, without any host failing.
+type checkpointRecorder struct {
+	explain.AnsibleEventExplainer
+	file string
+
+	mu          sync.Mutex
+	checkpoint  checkpoint
+	currentPlay string
+	playHosts   map[string]bool
+	playFailed  bool
+	// err is the first error that occurred when writing the checkpoint
+	err error
+}
+
+func newCheckpointRecorder(explainer explain.AnsibleEventExplainer, file string, digest string, completedPlays map[string][]string) *checkpointRecorder {
+	cp := checkpoint{
+		PlanDigest:     digest,
+		CompletedPlays: map[string][]string{},
+	}
+	for host, plays := range completedPlays {
+		cp.CompletedPlays[host] = append([]string{}, plays...)
+	}
+	return &checkpointRecorder{
+		AnsibleEventExplainer: explainer,
+		file:                  file,
+		checkpoint:            cp,
+		playHosts:             map[string]bool{},
+	}
+}
+
+func (r *checkpointRecorder) ExplainEvent(e ansible.Event) {
+	r.record(e)
+	if r.AnsibleEventExplainer != nil {
+		r.AnsibleEventExplainer.ExplainEvent(e)
+	}
+}
+
+func (r *checkpointRecorder) record(e ansible.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch event := e.(type) {
+	case *ansible.PlayStartEvent:
+		r.completePlay()
+		r.currentPlay = event.Checkpoint
+		r.playHosts = map[string]bool{}
+		r.playFailed = false
+	case *ansible.PlaybookEndEvent:
+		r.completePlay()
+		r.currentPlay = ""
+	case *ansible.RunnerOKEvent:
+		r.playHosts[event.Host] = true
+	case *ansible.RunnerItemOKEvent:
+		r.playHosts[event.Host] = true
+	case *ansible.RunnerSkippedEvent:
+		r.playHosts[event.Host] = true
+	case *ansible.RunnerFailedEvent:
+		if !event.IgnoreErrors {
+			r.playFailed = true
+		}
+	case *ansible.RunnerItemFailedEvent:
+		if !event.IgnoreErrors {
+			r.playFailed = true
+		}
+	case *ansible.RunnerUnreachableEvent:
+		r.playFailed = true
+	}
+}
+
+// completePlay adds the current play to the checkpoint, and writes
+// the checkpoint file
+func (r *checkpointRecorder) completePlay() {
+	if r.currentPlay == "" || r.playFailed || len(r.playHosts) == 0 {
+		return
+	}
+	for host := range r.playHosts {
+		if !contains(r.currentPlay, r.checkpoint.CompletedPlays[host]) {
+			r.checkpoint.CompletedPlays[host] = append(r.checkpoint.CompletedPlays[host], r.currentPlay)
+		}
+	}
+	b, err := json.MarshalIndent(r.checkpoint, "", "  ")
+	if err == nil {
+		err = util.WriteFileAtomic(r.file, b, 0644)
+	}
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("error writing checkpoint file %q: %v", r.file, err)
+	}
+}
+
+// writeErr returns the first error that occurred when writing the checkpoint
+func (r *checkpointRecorder) writeErr() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
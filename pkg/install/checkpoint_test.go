@@ -0,0 +1,256 @@
+package install
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestCheckpointRecorder(t *testing.T) {
+	runsDir := mustGetTempDir(t)
+	runDir := filepath.Join(runsDir, "apply", "2017-01-01-00-00-00")
+	if err := os.MkdirAll(runDir, 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	r := newCheckpointRecorder(nil, filepath.Join(runDir, checkpointFilename), "digest", map[string][]string{"host1": {"play0"}})
+
+	ok := func(host string) ansible.Event {
+		e := &ansible.RunnerOKEvent{}
+		e.Host = host
+		return e
+	}
+	skipped := &ansible.RunnerSkippedEvent{}
+	skipped.Host = "host2"
+	failed := &ansible.RunnerFailedEvent{}
+	failed.Host = "host2"
+	ignored := &ansible.RunnerFailedEvent{}
+	ignored.Host = "host1"
+	ignored.IgnoreErrors = true
+	play1 := &ansible.PlayStartEvent{Checkpoint: "play1"}
+	play1.Name = "Play One"
+	play2 := &ansible.PlayStartEvent{Checkpoint: "play2"}
+	play2.Name = "Play Two"
+	play3 := &ansible.PlayStartEvent{Checkpoint: "play3"}
+	play3.Name = "Play Three"
+	// plays without a checkpoint are always run
+	alwaysRun := &ansible.PlayStartEvent{}
+	alwaysRun.Name = "Always Run"
+
+	events := []ansible.Event{
+		play1, ok("host1"), ignored, skipped,
+		alwaysRun, ok("host1"), ok("host2"),
+		play2, ok("host1"),
+		play3, ok("host1"), failed,
+	}
+	for _, e := range events {
+		r.ExplainEvent(e)
+	}
+	if err := r.writeErr(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cp, err := readCheckpoint(runsDir, "apply")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp == nil {
+		t.Fatalf("checkpoint was not recorded")
+	}
+	expected := map[string][]string{
+		"host1": {"play0", "play1", "play2"},
+		"host2": {"play1"},
+	}
+	if !reflect.DeepEqual(cp.CompletedPlays, expected) {
+		t.Errorf("expected completed plays %v, but got %v", expected, cp.CompletedPlays)
+	}
+	if cp.PlanDigest != "digest" {
+		t.Errorf("expected plan digest %q, but got %q", "digest", cp.PlanDigest)
+	}
+}
+
+func TestReadCheckpointReturnsMostRecent(t *testing.T) {
+	runsDir := mustGetTempDir(t)
+	for _, run := range []string{"2017-01-01-00-00-00", "2017-01-02-00-00-00", "2017-01-03-00-00-00"} {
+		dir := filepath.Join(runsDir, "apply", run)
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			t.Fatalf("error creating run directory: %v", err)
+		}
+		// the most recent run did not record a checkpoint
+		if run == "2017-01-03-00-00-00" {
+			continue
+		}
+		b, _ := json.Marshal(checkpoint{PlanDigest: run})
+		if err := ioutil.WriteFile(filepath.Join(dir, checkpointFilename), b, 0644); err != nil {
+			t.Fatalf("error writing checkpoint: %v", err)
+		}
+	}
+	cp, err := readCheckpoint(runsDir, "apply")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp == nil || cp.PlanDigest != "2017-01-02-00-00-00" {
+		t.Errorf("expected the checkpoint of the most recent run, but got %v", cp)
+	}
+
+	cp, err = readCheckpoint(runsDir, "doesnotexist")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if cp != nil {
+		t.Errorf("expected no checkpoint, but got %v", cp)
+	}
+}
+
+func TestInstallResume(t *testing.T) {
+	plan := &Plan{
+		Master: MasterNodeGroup{
+			Nodes: []Node{{Host: "master", InternalIP: "10.10.2.20"}},
+		},
+		Cluster: Cluster{
+			Networking: NetworkConfig{
+				ServiceCIDRBlock: "10.0.0.0/16",
+			},
+		},
+	}
+	digest, err := planDigest(*plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	completed := map[string][]string{"master": {"docker"}}
+
+	tests := []struct {
+		digest   string
+		expected map[string][]string
+	}{
+		{digest: digest, expected: completed},
+		// the plan has changed
+		{digest: "other", expected: nil},
+	}
+	for _, test := range tests {
+		runsDir := mustGetTempDir(t)
+		runDir := filepath.Join(runsDir, "apply", "2017-01-01-00-00-00")
+		if err := os.MkdirAll(runDir, 0777); err != nil {
+			t.Fatalf("error creating run directory: %v", err)
+		}
+		b, _ := json.Marshal(checkpoint{PlanDigest: test.digest, CompletedPlays: completed})
+		if err := ioutil.WriteFile(filepath.Join(runDir, checkpointFilename), b, 0644); err != nil {
+			t.Fatalf("error writing checkpoint: %v", err)
+		}
+		fakeRunner := fakeRunner{}
+		e := ansibleExecutor{
+			options:             ExecutorOptions{RunsDirectory: runsDir, Resume: true},
+			stdout:              ioutil.Discard,
+			consoleOutputFormat: ansible.RawFormat,
+			runnerExplainerFactory: func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+				return &fakeRunner, &explain.AnsibleEventStreamExplainer{}, nil
+			},
+		}
+		if err := e.Install(plan); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(fakeRunner.incomingCatalog.CompletedPlays, test.expected) {
+			t.Errorf("expected completed plays %v, but got %v", test.expected, fakeRunner.incomingCatalog.CompletedPlays)
+		}
+	}
+}
+
+func TestExecuteRecordsCheckpointOnlyForResumableTasks(t *testing.T) {
+	plan := Plan{
+		Master: MasterNodeGroup{
+			Nodes: []Node{{Host: "master", InternalIP: "10.10.2.20"}},
+		},
+	}
+	for _, resumable := range []bool{true, false} {
+		runsDir := mustGetTempDir(t)
+		e := ansibleExecutor{
+			options:             ExecutorOptions{RunsDirectory: runsDir},
+			stdout:              ioutil.Discard,
+			consoleOutputFormat: ansible.RawFormat,
+			runnerExplainerFactory: func(explainer explain.AnsibleEventExplainer, w io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+				play := &ansible.PlayStartEvent{Checkpoint: "docker"}
+				ok := &ansible.RunnerOKEvent{}
+				ok.Host = "master"
+				events := make(chan ansible.Event, 3)
+				events <- play
+				events <- ok
+				events <- &ansible.PlaybookEndEvent{}
+				close(events)
+				return &fakeRunner{eventChan: events}, &explain.AnsibleEventStreamExplainer{EventExplainer: explainer}, nil
+			},
+		}
+		if err := e.execute(task{name: "apply", plan: plan, explainer: &hostFailureRecorder{}, resumable: resumable}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		cp, err := readCheckpoint(runsDir, "apply")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resumable && (cp == nil || !reflect.DeepEqual(cp.CompletedPlays, map[string][]string{"master": {"docker"}})) {
+			t.Errorf("expected the checkpoint to be recorded for a resumable task, but got %v", cp)
+		}
+		if !resumable && cp != nil {
+			t.Errorf("expected no checkpoint for a task that is not resumable, but got %v", cp)
+		}
+	}
+}
+
+// Every play of the installation that is skipped when resuming must set the
+// checkpoint that the play is recorded with
+func TestKubernetesPlaybookCheckpoints(t *testing.T) {
+	// these include plays that are always run
+	alwaysRun := map[string]bool{"_all.yaml": true, "_storage.yaml": true}
+	guard := "completed_plays[inventory_hostname] is not defined or checkpoint not in completed_plays[inventory_hostname]"
+
+	b, err := ioutil.ReadFile(filepath.Join("..", "..", "ansible", "kubernetes.yaml"))
+	if err != nil {
+		t.Fatalf("error reading playbook: %v", err)
+	}
+	var includes []struct {
+		Include string
+		Vars    map[string]string
+		When    interface{}
+	}
+	if err = yaml.Unmarshal(b, &includes); err != nil {
+		t.Fatalf("error unmarshaling playbook: %v", err)
+	}
+	checkpoints := map[string]string{}
+	for _, inc := range includes {
+		if alwaysRun[inc.Include] {
+			continue
+		}
+		cp := inc.Vars["checkpoint"]
+		if cp == "" {
+			t.Errorf("%s: the checkpoint variable is not set", inc.Include)
+			continue
+		}
+		if other, ok := checkpoints[cp]; ok {
+			t.Errorf("%s: checkpoint %q is also used by %s", inc.Include, cp, other)
+		}
+		checkpoints[cp] = inc.Include
+		if !strings.Contains(fmt.Sprint(inc.When), guard) {
+			t.Errorf("%s: the play is not skipped when its checkpoint was completed", inc.Include)
+		}
+		// the checkpoint is recorded per play
+		pb, err := ioutil.ReadFile(filepath.Join("..", "..", "ansible", inc.Include))
+		if err != nil {
+			t.Fatalf("error reading playbook: %v", err)
+		}
+		var plays []interface{}
+		if err = yaml.Unmarshal(pb, &plays); err != nil {
+			t.Fatalf("error unmarshaling playbook %s: %v", inc.Include, err)
+		}
+		if len(plays) != 1 {
+			t.Errorf("%s: expected a single play, but found %d", inc.Include, len(plays))
+		}
+	}
+}
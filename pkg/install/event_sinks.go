@@ -0,0 +1,89 @@
+package install
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+// eventsFilename is the name of the file in the run directory that
+// the "jsonl" event sink writes the ansible events to
+const eventsFilename = "events.jsonl"
+
+// The kinds of event sinks
+const (
+	jsonLinesEventSink  = "jsonl"
+	webhookEventSink    = "webhook"
+	prometheusEventSink = "prometheus"
+)
+
+// eventSink receives the ansible events of every run, in addition to the console
+type eventSink struct {
+	kind string
+	url  string
+}
+
+// parseEventSinks parses the event sinks given as "jsonl", "webhook=URL"
+// or "prometheus=URL"
+func parseEventSinks(specs []string) ([]eventSink, error) {
+	sinks := []eventSink{}
+	for _, spec := range specs {
+		kind, sinkURL := spec, ""
+		if i := strings.Index(spec, "="); i >= 0 {
+			kind, sinkURL = spec[:i], spec[i+1:]
+		}
+		switch kind {
+		case jsonLinesEventSink:
+			if sinkURL != "" {
+				return nil, fmt.Errorf("event sink %q does not take a URL", kind)
+			}
+		case webhookEventSink, prometheusEventSink:
+			u, err := url.Parse(sinkURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("event sink %q requires an http or https URL, as in %s=http://host:port/path", kind, kind)
+			}
+		default:
+			return nil, fmt.Errorf("event sink %q is not supported (options \"jsonl\"|\"webhook=URL\"|\"prometheus=URL\")", spec)
+		}
+		sinks = append(sinks, eventSink{kind: kind, url: sinkURL})
+	}
+	return sinks, nil
+}
+
+// newEventSinks returns the explainers of the configured event sinks for the run.
+// The name of the run directory, which is unique to the run, identifies it.
+func (ae *ansibleExecutor) newEventSinks(runName string, runDirectory string) ([]explain.AnsibleEventExplainer, error) {
+	explainers := []explain.AnsibleEventExplainer{}
+	client := &http.Client{Timeout: 10 * time.Second}
+	for _, s := range ae.eventSinks {
+		switch s.kind {
+		case jsonLinesEventSink:
+			e, err := explain.JSONLinesFile(filepath.Join(runDirectory, eventsFilename))
+			if err != nil {
+				closeEventSinks(explainers)
+				return nil, err
+			}
+			explainers = append(explainers, e)
+		case webhookEventSink:
+			explainers = append(explainers, explain.Webhook(s.url, client))
+		case prometheusEventSink:
+			explainers = append(explainers, explain.PrometheusPush(s.url, runName, filepath.Base(runDirectory), client))
+		}
+	}
+	return explainers, nil
+}
+
+// closeEventSinks releases the event sinks of a run that did not start
+func closeEventSinks(sinks []explain.AnsibleEventExplainer) {
+	for _, s := range sinks {
+		if c, ok := s.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package install
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEventSinks(t *testing.T) {
+	tests := []struct {
+		specs    []string
+		expected []eventSink
+		valid    bool
+	}{
+		{
+			specs:    nil,
+			expected: []eventSink{},
+			valid:    true,
+		},
+		{
+			specs: []string{"jsonl", "webhook=https://chatops.example.com/hooks/kismatic?token=a=b", "prometheus=http://pushgateway:9091"},
+			expected: []eventSink{
+				{kind: "jsonl"},
+				{kind: "webhook", url: "https://chatops.example.com/hooks/kismatic?token=a=b"},
+				{kind: "prometheus", url: "http://pushgateway:9091"},
+			},
+			valid: true,
+		},
+		{
+			specs: []string{"jsonl=/tmp/events.jsonl"},
+		},
+		{
+			specs: []string{"webhook"},
+		},
+		{
+			specs: []string{"webhook=chatops.example.com"},
+		},
+		{
+			specs: []string{"prometheus=ftp://pushgateway:9091"},
+		},
+		{
+			specs: []string{"tty"},
+		},
+	}
+	for _, test := range tests {
+		sinks, err := parseEventSinks(test.specs)
+		if err != nil && test.valid {
+			t.Errorf("%v: unexpected error: %v", test.specs, err)
+			continue
+		}
+		if err == nil && !test.valid {
+			t.Errorf("%v: expected an error, but didn't get one", test.specs)
+			continue
+		}
+		if test.valid && !reflect.DeepEqual(sinks, test.expected) {
+			t.Errorf("%v: expected %v, but got %v", test.specs, test.expected, sinks)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package install
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +13,8 @@ import (
 	"strings"
 
 	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/inspector"
+	"github.com/apprenda/kismatic/pkg/inspector/rule"
 	"github.com/apprenda/kismatic/pkg/install/explain"
 	"github.com/apprenda/kismatic/pkg/tls"
 	"github.com/apprenda/kismatic/pkg/util"
@@ -23,6 +26,8 @@ type PreFlightExecutor interface {
 	RunPreFlightCheck(*Plan) error
 	CopyInspector(*Plan) error
 	RunNewWorkerPreFlightCheck(Plan, Node) error
+	RunNewWorkersPreFlightCheck(Plan, []Node) (map[string]error, error)
+	RunNewNodePreFlightCheck(Plan, Node, []string) error
 	RunUpgradePreFlightCheck(*Plan, ListableNode) error
 }
 
@@ -31,12 +36,19 @@ type Executor interface {
 	PreFlightExecutor
 	Install(p *Plan) error
 	GenerateCertificates(p *Plan, useExistingCA bool) error
+	RotateCertificates(plan Plan, rotateCA bool) error
 	RunSmokeTest(*Plan) error
 	AddWorker(*Plan, Node) (*Plan, error)
+	AddWorkers(plan *Plan, workers []Node) (*Plan, []AddWorkerResult, error)
+	RemoveWorker(plan *Plan, workerName string) (*Plan, error)
+	AddNode(plan *Plan, node Node, roles []string) (*Plan, error)
 	RunPlay(string, *Plan) error
 	AddVolume(*Plan, StorageVolume) error
 	DeleteVolume(*Plan, string) error
-	UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, maxParallelWorkers int) error
+	UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, workerOpts WorkerUpgradeOptions) error
+	RollbackNodes(plan Plan, nodes []ListableNode) error
+	BackupEtcd(plan Plan, backupDir string) error
+	RestoreEtcd(plan Plan, backupDir string) error
 	ValidateControlPlane(plan Plan) error
 	UpgradeClusterServices(plan Plan) error
 }
@@ -64,6 +76,12 @@ type ExecutorOptions struct {
 	DiagnosticsDirecty string
 	// DryRun determines if the executor should actually run the task
 	DryRun bool
+	// Resume the installation from the checkpoint recorded in the most
+	// recent run, skipping the plays that completed
+	Resume bool
+	// EventSinks receive the ansible events of every run, in addition to the
+	// console. Supported sinks are "jsonl", "webhook=URL" and "prometheus=URL".
+	EventSinks []string
 }
 
 // NewExecutor returns an executor for performing installations according to the installation plan.
@@ -77,14 +95,13 @@ func NewExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOptions) (E
 	}
 
 	// Setup the console output format
-	var outFormat ansible.OutputFormat
-	switch options.OutputFormat {
-	case "raw":
-		outFormat = ansible.RawFormat
-	case "simple":
-		outFormat = ansible.JSONLinesFormat
-	default:
-		return nil, fmt.Errorf("Output format %q is not supported", options.OutputFormat)
+	outFormat, stdout, jsonOut, err := consoleOutput(options.OutputFormat, stdout, errOut)
+	if err != nil {
+		return nil, err
+	}
+	eventSinks, err := parseEventSinks(options.EventSinks)
+	if err != nil {
+		return nil, err
 	}
 	certsDir := filepath.Join(options.GeneratedAssetsDirectory, "keys")
 	pki := &LocalPKI{
@@ -92,38 +109,51 @@ func NewExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOptions) (E
 		GeneratedCertsDirectory: certsDir,
 		Log: stdout,
 	}
+	inspectorCertsDir := filepath.Join(options.GeneratedAssetsDirectory, "inspector", "keys")
 	return &ansibleExecutor{
 		options:             options,
 		stdout:              stdout,
 		consoleOutputFormat: outFormat,
+		jsonOut:             jsonOut,
+		eventSinks:          eventSinks,
 		ansibleDir:          ansibleDir,
 		certsDir:            certsDir,
 		pki:                 pki,
+		inspectorCertsDir:   inspectorCertsDir,
+		inspectorPKI:        newInspectorPKI(ansibleDir, inspectorCertsDir),
 	}, nil
 }
 
 // NewPreFlightExecutor returns an executor for running preflight
 func NewPreFlightExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOptions) (PreFlightExecutor, error) {
 	ansibleDir := "ansible"
+	if options.GeneratedAssetsDirectory == "" {
+		return nil, fmt.Errorf("GeneratedAssetsDirectory option cannot be empty")
+	}
 	if options.RunsDirectory == "" {
 		options.RunsDirectory = "./runs"
 	}
 	// Setup the console output format
-	var outFormat ansible.OutputFormat
-	switch options.OutputFormat {
-	case "raw":
-		outFormat = ansible.RawFormat
-	case "simple":
-		outFormat = ansible.JSONLinesFormat
-	default:
-		return nil, fmt.Errorf("Output format %q is not supported", options.OutputFormat)
+	outFormat, stdout, jsonOut, err := consoleOutput(options.OutputFormat, stdout, errOut)
+	if err != nil {
+		return nil, err
 	}
-
+	eventSinks, err := parseEventSinks(options.EventSinks)
+	if err != nil {
+		return nil, err
+	}
+	certsDir := filepath.Join(options.GeneratedAssetsDirectory, "keys")
+	inspectorCertsDir := filepath.Join(options.GeneratedAssetsDirectory, "inspector", "keys")
 	return &ansibleExecutor{
 		options:             options,
 		stdout:              stdout,
 		consoleOutputFormat: outFormat,
+		jsonOut:             jsonOut,
+		eventSinks:          eventSinks,
 		ansibleDir:          ansibleDir,
+		certsDir:            certsDir,
+		inspectorCertsDir:   inspectorCertsDir,
+		inspectorPKI:        newInspectorPKI(ansibleDir, inspectorCertsDir),
 	}, nil
 }
 
@@ -142,31 +172,55 @@ func NewDiagnosticsExecutor(stdout io.Writer, errOut io.Writer, options Executor
 	}
 
 	// Setup the console output format
-	var outFormat ansible.OutputFormat
-	switch options.OutputFormat {
-	case "raw":
-		outFormat = ansible.RawFormat
-	case "simple":
-		outFormat = ansible.JSONLinesFormat
-	default:
-		return nil, fmt.Errorf("Output format %q is not supported", options.OutputFormat)
+	outFormat, stdout, jsonOut, err := consoleOutput(options.OutputFormat, stdout, errOut)
+	if err != nil {
+		return nil, err
+	}
+	eventSinks, err := parseEventSinks(options.EventSinks)
+	if err != nil {
+		return nil, err
 	}
-
 	return &ansibleExecutor{
 		options:             options,
 		stdout:              stdout,
 		consoleOutputFormat: outFormat,
+		jsonOut:             jsonOut,
+		eventSinks:          eventSinks,
 		ansibleDir:          ansibleDir,
 	}, nil
 }
 
+// consoleOutput returns the format of the ansible output on the console, the
+// writer for the messages of the executor, and the writer for the ansible events
+// in JSON. When the "json" output format is used, stdout only contains the events,
+// and everything else goes to errOut.
+func consoleOutput(format string, stdout io.Writer, errOut io.Writer) (ansible.OutputFormat, io.Writer, io.Writer, error) {
+	switch format {
+	case "raw":
+		return ansible.RawFormat, stdout, nil, nil
+	case "simple":
+		return ansible.JSONLinesFormat, stdout, nil, nil
+	case "json":
+		return ansible.JSONLinesFormat, errOut, stdout, nil
+	default:
+		return "", nil, nil, fmt.Errorf("Output format %q is not supported", format)
+	}
+}
+
 type ansibleExecutor struct {
 	options             ExecutorOptions
 	stdout              io.Writer
 	consoleOutputFormat ansible.OutputFormat
-	ansibleDir          string
-	certsDir            string
-	pki                 PKI
+	// jsonOut receives the ansible events as JSON, when the JSON output format is used
+	jsonOut    io.Writer
+	eventSinks []eventSink
+	ansibleDir string
+	certsDir   string
+	pki        PKI
+	// the inspector certificates are issued by a CA that is only used by the
+	// inspector, so that the pre-flight checks do not require the cluster CA
+	inspectorCertsDir string
+	inspectorPKI      PKI
 
 	// Hook for testing purposes.. default implementation is used at runtime
 	runnerExplainerFactory func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error)
@@ -187,6 +241,8 @@ type task struct {
 	plan Plan
 	// run the task on specific nodes
 	limit []string
+	// record the plays that complete, so that the task can be resumed
+	resumable bool
 }
 
 // execute will run the given task, and setup all what's needed for us to run ansible.
@@ -210,10 +266,34 @@ func (ae *ansibleExecutor) execute(t task) error {
 	if err != nil {
 		return fmt.Errorf("error creating ansible log file %q: %v", ansibleLogFilename, err)
 	}
-	runner, explainer, err := ae.ansibleRunnerWithExplainer(t.explainer, ansibleLogFile, runDirectory)
+	// Record the plays that complete, so that the run can be resumed
+	eventExplainer := t.explainer
+	var recorder *checkpointRecorder
+	if t.resumable {
+		digest, err := planDigest(t.plan)
+		if err != nil {
+			return err
+		}
+		recorder = newCheckpointRecorder(t.explainer, filepath.Join(runDirectory, checkpointFilename), digest, t.clusterCatalog.CompletedPlays)
+		eventExplainer = recorder
+	}
+	// Send the events to the configured sinks as well
+	sinks, err := ae.newEventSinks(t.name, runDirectory)
 	if err != nil {
 		return err
 	}
+	if len(sinks) > 0 {
+		explainers := sinks
+		if eventExplainer != nil {
+			explainers = append([]explain.AnsibleEventExplainer{eventExplainer}, sinks...)
+		}
+		eventExplainer = explain.FanOut(explainers...)
+	}
+	runner, explainer, err := ae.ansibleRunnerWithExplainer(eventExplainer, ansibleLogFile, runDirectory)
+	if err != nil {
+		closeEventSinks(sinks)
+		return err
+	}
 
 	// Start running ansible with the given playbook
 	var eventStream <-chan ansible.Event
@@ -223,14 +303,27 @@ func (ae *ansibleExecutor) execute(t task) error {
 		eventStream, err = runner.StartPlaybook(t.playbook, t.inventory, t.clusterCatalog)
 	}
 	if err != nil {
+		closeEventSinks(sinks)
 		return fmt.Errorf("error running ansible playbook: %v", err)
 	}
 	// Ansible blocks until explainer starts reading from stream. Start
 	// explainer in a separate go routine
-	go explainer.Explain(eventStream)
-
-	// Wait until ansible exits
-	if err = runner.WaitPlaybook(); err != nil {
+	explained := make(chan error, 1)
+	go func() { explained <- explainer.Explain(eventStream) }()
+
+	// Wait until ansible exits, and until all the events have been explained,
+	// as the explainers that record the outcome of the run are read afterwards.
+	// The sinks are closed once the event stream ends.
+	err = runner.WaitPlaybook()
+	if sinkErr := <-explained; sinkErr != nil {
+		util.PrettyPrintWarn(ae.stdout, "Error sending events to the event sinks: %v", sinkErr)
+	}
+	if recorder != nil {
+		if cpErr := recorder.writeErr(); cpErr != nil {
+			util.PrettyPrintWarn(ae.stdout, "%v", cpErr)
+		}
+	}
+	if err != nil {
 		return fmt.Errorf("error running playbook: %v", err)
 	}
 	return nil
@@ -291,8 +384,14 @@ func (ae *ansibleExecutor) Install(p *Plan) error {
 		inventory:      buildInventoryFromPlan(p),
 		clusterCatalog: *cc,
 		explainer:      ae.defaultExplainer(),
+		resumable:      true,
 	}
 	util.PrintHeader(ae.stdout, "Installing Cluster", '=')
+	if ae.options.Resume {
+		if err = ae.resumeFromCheckpoint(t.name, *p, &t.clusterCatalog); err != nil {
+			return err
+		}
+	}
 	return ae.execute(t)
 }
 
@@ -315,11 +414,14 @@ func (ae *ansibleExecutor) RunSmokeTest(p *Plan) error {
 
 // RunPreflightCheck against the nodes defined in the plan
 func (ae *ansibleExecutor) RunPreFlightCheck(p *Plan) error {
+	if err := ae.generateInspectorCertificates(p, p.GetUniqueNodes()); err != nil {
+		return err
+	}
 	cc, err := ae.buildClusterCatalog(p)
 	if err != nil {
 		return err
 	}
-	cc, err = setPreflightOptions(*p, *cc)
+	cc, err = ae.setPreflightOptions(*p, *cc)
 	if err != nil {
 		return err
 	}
@@ -340,7 +442,7 @@ func (ae *ansibleExecutor) CopyInspector(p *Plan) error {
 	if err != nil {
 		return err
 	}
-	cc, err = setPreflightOptions(*p, *cc)
+	cc, err = ae.setPreflightOptions(*p, *cc)
 	if err != nil {
 		return err
 	}
@@ -357,35 +459,72 @@ func (ae *ansibleExecutor) CopyInspector(p *Plan) error {
 
 // RunNewWorkerPreFlightCheck runs the preflight checks against a new worker node
 func (ae *ansibleExecutor) RunNewWorkerPreFlightCheck(p Plan, node Node) error {
-	cc, err := ae.buildClusterCatalog(&p)
+	errs, err := ae.RunNewWorkersPreFlightCheck(p, []Node{node})
 	if err != nil {
 		return err
 	}
-	cc, err = setPreflightOptions(p, *cc)
+	return errs[node.Host]
+}
+
+// RunNewWorkersPreFlightCheck runs the pre-flight checks against all the
+// workers that are going to be added to the cluster at once. The checks
+// that failed are returned for each worker that did not pass them.
+func (ae *ansibleExecutor) RunNewWorkersPreFlightCheck(p Plan, nodes []Node) (map[string]error, error) {
+	t, err := ae.newNodePreFlightTask("add-worker-preflight", addWorkersToPlan(p, nodes), nodes)
+	if err != nil {
+		return nil, err
+	}
+	errs := map[string]error{}
+	ae.executeOnNodes(*t, nodes, errs, "error running pre-flight checks")
+	return errs, nil
+}
+
+// RunNewNodePreFlightCheck runs the pre-flight checks against a node that is
+// going to be added to the cluster with the given roles
+func (ae *ansibleExecutor) RunNewNodePreFlightCheck(p Plan, node Node, roles []string) error {
+	t, err := ae.newNodePreFlightTask("add-node-preflight", addNodeToPlan(p, node, roles), []Node{node})
 	if err != nil {
 		return err
 	}
-	p.Worker.ExpectedCount++
-	p.Worker.Nodes = append(p.Worker.Nodes, node)
+	return ae.execute(*t)
+}
+
+// returns the task that runs the pre-flight checks against the new nodes,
+// which have already been added to the plan
+func (ae *ansibleExecutor) newNodePreFlightTask(name string, p Plan, nodes []Node) (*task, error) {
+	if err := ae.generateInspectorCertificates(&p, nodes); err != nil {
+		return nil, err
+	}
+	cc, err := ae.buildClusterCatalog(&p)
+	if err != nil {
+		return nil, err
+	}
+	cc, err = ae.setPreflightOptions(p, *cc)
+	if err != nil {
+		return nil, err
+	}
 	t := task{
-		name:           "add-worker-preflight",
+		name:           name,
 		playbook:       "preflight.yaml",
 		inventory:      buildInventoryFromPlan(&p),
 		clusterCatalog: *cc,
 		explainer:      ae.preflightExplainer(),
 		plan:           p,
-		limit:          []string{node.Host},
+		limit:          nodeHosts(nodes),
 	}
-	return ae.execute(t)
+	return &t, nil
 }
 
 func (ae *ansibleExecutor) RunUpgradePreFlightCheck(p *Plan, node ListableNode) error {
+	if err := ae.generateInspectorCertificates(p, []Node{node.Node}); err != nil {
+		return err
+	}
 	inventory := buildInventoryFromPlan(p)
 	cc, err := ae.buildClusterCatalog(p)
 	if err != nil {
 		return err
 	}
-	cc, err = setPreflightOptions(*p, *cc)
+	cc, err = ae.setPreflightOptions(*p, *cc)
 	if err != nil {
 		return err
 	}
@@ -401,12 +540,124 @@ func (ae *ansibleExecutor) RunUpgradePreFlightCheck(p *Plan, node ListableNode)
 	return ae.execute(t)
 }
 
-func setPreflightOptions(p Plan, cc ansible.ClusterCatalog) (*ansible.ClusterCatalog, error) {
+// newInspectorPKI returns the PKI that issues the inspector certificates
+func newInspectorPKI(ansibleDir string, certsDir string) PKI {
+	return &LocalPKI{
+		CACsr:                   filepath.Join(ansibleDir, "playbooks", "tls", "ca-csr.json"),
+		GeneratedCertsDirectory: certsDir,
+		Log:                     ioutil.Discard,
+	}
+}
+
+// generateInspectorCertificates issues the short-lived certificates that the
+// inspector servers and clients use to authenticate each other during the
+// pre-flight checks. Each of the nodes that are checked gets its own
+// certificate, as do the first master and worker, which run the inspector
+// clients. The certificates are issued by the inspector CA, which is created
+// if it does not exist yet.
+func (ae *ansibleExecutor) generateInspectorCertificates(p *Plan, nodes []Node) error {
+	ca, err := ae.inspectorPKI.GenerateClusterCA(p)
+	if err != nil {
+		return fmt.Errorf("error getting CA for the inspector: %v", err)
+	}
+	for _, n := range inspectorNodes(*p, nodes) {
+		sans := []string{n.Host, n.IP}
+		if n.InternalIP != "" {
+			sans = append(sans, n.InternalIP)
+		}
+		if _, err := ae.inspectorPKI.GenerateCertificate(inspectorCertName(n), inspectorCertExpiry, n.Host, sans, []string{inspector.CertificateOrganization}, ca, true); err != nil {
+			return fmt.Errorf("error generating certificate for the inspector on %q: %v", n.Host, err)
+		}
+	}
+	return nil
+}
+
+// returns the nodes that are checked, along with the nodes that run the
+// inspector clients
+func inspectorNodes(p Plan, nodes []Node) []Node {
+	all := append([]Node{}, nodes...)
+	if len(p.Master.Nodes) > 0 {
+		all = append(all, p.Master.Nodes[0])
+	}
+	if len(p.Worker.Nodes) > 0 {
+		all = append(all, p.Worker.Nodes[0])
+	}
+	unique := []Node{}
+	seen := map[string]bool{}
+	for _, n := range all {
+		if !seen[n.Host] {
+			seen[n.Host] = true
+			unique = append(unique, n)
+		}
+	}
+	return unique
+}
+
+// returns the name of the inspector certificate of the node
+func inspectorCertName(n Node) string {
+	return fmt.Sprintf("%s-%s", n.Host, inspectorCertFilename)
+}
+
+func (ae *ansibleExecutor) setPreflightOptions(p Plan, cc ansible.ClusterCatalog) (*ansible.ClusterCatalog, error) {
 	cc.KismaticPreflightCheckerLinux = filepath.Join("inspector", "linux", "amd64", "kismatic-inspector")
 	cc.EnablePackageInstallation = !p.Cluster.DisablePackageInstallation
+	inspectorTLSDir, err := filepath.Abs(ae.inspectorCertsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine absolute path to %s: %v", ae.inspectorCertsDir, err)
+	}
+	cc.KismaticInspectorTLSDirectory = inspectorTLSDir
+	custom, err := p.inspectorRuleSets()
+	if err != nil {
+		return nil, err
+	}
+	if len(custom) > 0 {
+		rulesFile, upgradeRulesFile, err := ae.writeInspectorRules(custom)
+		if err != nil {
+			return nil, err
+		}
+		cc.KismaticInspectorRules = rulesFile
+		cc.KismaticInspectorUpgradeRules = upgradeRulesFile
+	}
 	return &cc, nil
 }
 
+// writeInspectorRules writes the rule sets that the inspector uses during the
+// pre-flight checks, which are the default and upgrade rules merged with the
+// custom rule sets. Returns the absolute paths of the rules files.
+func (ae *ansibleExecutor) writeInspectorRules(custom [][]byte) (string, string, error) {
+	dir, err := filepath.Abs(filepath.Join(ae.options.GeneratedAssetsDirectory, "inspector"))
+	if err != nil {
+		return "", "", fmt.Errorf("error getting absolute path of inspector rules directory: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", fmt.Errorf("error creating inspector rules directory: %v", err)
+	}
+	write := func(filename string, dumpDefaults func(io.Writer) error) (string, error) {
+		var defaults bytes.Buffer
+		if err := dumpDefaults(&defaults); err != nil {
+			return "", fmt.Errorf("error getting default inspector rules: %v", err)
+		}
+		merged, err := rule.MergeRulesYAML(append([][]byte{defaults.Bytes()}, custom...)...)
+		if err != nil {
+			return "", fmt.Errorf("error merging inspector rules: %v", err)
+		}
+		file := filepath.Join(dir, filename)
+		if err := ioutil.WriteFile(file, merged, 0600); err != nil {
+			return "", fmt.Errorf("error writing inspector rules to %q: %v", file, err)
+		}
+		return file, nil
+	}
+	rulesFile, err := write("rules.yaml", rule.DumpDefaultRules)
+	if err != nil {
+		return "", "", err
+	}
+	upgradeRulesFile, err := write("upgrade-rules.yaml", rule.DumpUpgradeRules)
+	if err != nil {
+		return "", "", err
+	}
+	return rulesFile, upgradeRulesFile, nil
+}
+
 func (ae *ansibleExecutor) RunPlay(playName string, p *Plan) error {
 	cc, err := ae.buildClusterCatalog(p)
 	if err != nil {
@@ -504,68 +755,22 @@ func (ae *ansibleExecutor) DeleteVolume(plan *Plan, name string) error {
 // which phase of the upgrade we are in. For example, when upgrading a node that is both an etcd and master,
 // the etcd components and the master components will be upgraded when we are in the upgrade etcd nodes
 // phase.
-func (ae *ansibleExecutor) UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, maxParallelWorkers int) error {
-	// Nodes can have multiple roles. For this reason, we need to keep track of which nodes
-	// have been upgraded to avoid re-upgrading them.
-	upgradedNodes := map[string]bool{}
-	// Upgrade etcd nodes
-	for _, nodeToUpgrade := range nodesToUpgrade {
-		for _, role := range nodeToUpgrade.Roles {
-			if role == "etcd" {
-				node := nodeToUpgrade
-				if err := ae.upgradeNodes(plan, onlineUpgrade, node); err != nil {
-					return fmt.Errorf("error upgrading node %q: %v", node.Node.Host, err)
-				}
-				upgradedNodes[node.Node.IP] = true
-				break
-			}
-		}
-	}
-
-	// Upgrade master nodes
-	for _, nodeToUpgrade := range nodesToUpgrade {
-		if upgradedNodes[nodeToUpgrade.Node.IP] == true {
-			continue
-		}
-		for _, role := range nodeToUpgrade.Roles {
-			if role == "master" {
-				node := nodeToUpgrade
-				if err := ae.upgradeNodes(plan, onlineUpgrade, node); err != nil {
-					return fmt.Errorf("error upgrading node %q: %v", node.Node.Host, err)
-				}
-				upgradedNodes[node.Node.IP] = true
-				break
-			}
+//
+// Etcd and master nodes are upgraded one at a time. The rest of the nodes are
+// upgraded in waves, as determined by the worker upgrade options.
+func (ae *ansibleExecutor) UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, workerOpts WorkerUpgradeOptions) error {
+	etcdNodes, masterNodes, workers := upgradePhases(nodesToUpgrade)
+	// Upgrade etcd nodes, and then master nodes
+	for _, node := range append(etcdNodes, masterNodes...) {
+		if err := ae.upgradeNodes(plan, onlineUpgrade, ae.defaultExplainer(), node); err != nil {
+			return fmt.Errorf("error upgrading node %q: %v", node.Node.Host, err)
 		}
 	}
-
-	var limitNodes []ListableNode
 	// Upgrade the rest of the nodes
-	for n, nodeToUpgrade := range nodesToUpgrade {
-		if upgradedNodes[nodeToUpgrade.Node.IP] == true {
-			continue
-		}
-		for _, role := range nodeToUpgrade.Roles {
-			if role != "etcd" && role != "master" {
-				node := nodeToUpgrade
-				limitNodes = append(limitNodes, node)
-				// don't forget to run the remaining nodes if its < maxParallelWorkers
-				if len(limitNodes) == maxParallelWorkers || n == len(nodesToUpgrade)-1 {
-					if err := ae.upgradeNodes(plan, onlineUpgrade, limitNodes...); err != nil {
-						return fmt.Errorf("error upgrading node %q: %v", node.Node.Host, err)
-					}
-					// empty the slice
-					limitNodes = limitNodes[:0]
-				}
-				upgradedNodes[node.Node.IP] = true
-				break
-			}
-		}
-	}
-	return nil
+	return ae.upgradeWorkers(plan, workers, onlineUpgrade, workerOpts)
 }
 
-func (ae *ansibleExecutor) upgradeNodes(plan Plan, onlineUpgrade bool, nodes ...ListableNode) error {
+func (ae *ansibleExecutor) upgradeNodes(plan Plan, onlineUpgrade bool, explainer explain.AnsibleEventExplainer, nodes ...ListableNode) error {
 	inventory := buildInventoryFromPlan(&plan)
 	cc, err := ae.buildClusterCatalog(&plan)
 	if err != nil {
@@ -584,7 +789,7 @@ func (ae *ansibleExecutor) upgradeNodes(plan Plan, onlineUpgrade bool, nodes ...
 		inventory:      inventory,
 		clusterCatalog: *cc,
 		plan:           plan,
-		explainer:      ae.defaultExplainer(),
+		explainer:      explainer,
 		limit:          limit,
 	}
 	if len(limit) == 1 {
@@ -811,9 +1016,13 @@ func (ae *ansibleExecutor) buildClusterCatalog(p *Plan) (*ansible.ClusterCatalog
 	return &cc, nil
 }
 
+// runDirectoryNameFormat is the layout of the time the run started, which
+// is the name of the run directory
+const runDirectoryNameFormat = "2006-01-02-15-04-05"
+
 func (ae *ansibleExecutor) createRunDirectory(runName string) (string, error) {
 	start := time.Now()
-	runDirectory := filepath.Join(ae.options.RunsDirectory, runName, start.Format("2006-01-02-15-04-05"))
+	runDirectory := filepath.Join(ae.options.RunsDirectory, runName, start.Format(runDirectoryNameFormat))
 	if err := os.MkdirAll(runDirectory, 0777); err != nil {
 		return "", fmt.Errorf("error creating directory: %v", err)
 	}
@@ -848,6 +1057,9 @@ func (ae *ansibleExecutor) ansibleRunnerWithExplainer(explainer explain.AnsibleE
 }
 
 func (ae *ansibleExecutor) defaultExplainer() explain.AnsibleEventExplainer {
+	if ae.jsonOut != nil {
+		return explain.JSONExplainer(ae.jsonOut)
+	}
 	var out io.Writer
 	switch ae.consoleOutputFormat {
 	case ansible.JSONLinesFormat:
@@ -859,6 +1071,9 @@ func (ae *ansibleExecutor) defaultExplainer() explain.AnsibleEventExplainer {
 }
 
 func (ae *ansibleExecutor) preflightExplainer() explain.AnsibleEventExplainer {
+	if ae.jsonOut != nil {
+		return explain.JSONExplainer(ae.jsonOut)
+	}
 	var out io.Writer
 	switch ae.consoleOutputFormat {
 	case ansible.JSONLinesFormat:
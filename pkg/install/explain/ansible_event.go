@@ -1,6 +1,10 @@
 package explain
 
-import "github.com/apprenda/kismatic/pkg/ansible"
+import (
+	"io"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
 
 // AnsibleEventStreamExplainer explains the incoming ansible event stream
 type AnsibleEventStreamExplainer struct {
@@ -8,11 +12,15 @@ type AnsibleEventStreamExplainer struct {
 	EventExplainer AnsibleEventExplainer
 }
 
-// Explain the incoming ansible event stream
+// Explain the incoming ansible event stream. If the event explainer
+// implements io.Closer, it is closed once the stream ends.
 func (e *AnsibleEventStreamExplainer) Explain(events <-chan ansible.Event) error {
 	for event := range events {
 		e.EventExplainer.ExplainEvent(event)
 	}
+	if c, ok := e.EventExplainer.(io.Closer); ok {
+		return c.Close()
+	}
 	return nil
 }
 
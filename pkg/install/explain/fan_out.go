@@ -0,0 +1,42 @@
+package explain
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+// FanOut returns an explainer that passes each event to all the given
+// explainers, in order. Closing the returned explainer closes the explainers
+// that implement io.Closer.
+func FanOut(explainers ...AnsibleEventExplainer) AnsibleEventExplainer {
+	return fanOutExplainer(explainers)
+}
+
+type fanOutExplainer []AnsibleEventExplainer
+
+func (f fanOutExplainer) ExplainEvent(e ansible.Event) {
+	for _, explainer := range f {
+		explainer.ExplainEvent(e)
+	}
+}
+
+// Close all the explainers, even if some of them fail to close
+func (f fanOutExplainer) Close() error {
+	var errs []string
+	for _, explainer := range f {
+		c, ok := explainer.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := c.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
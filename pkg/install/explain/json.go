@@ -0,0 +1,231 @@
+package explain
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+// JSONEventVersion is the version of the JSON event format. It changes
+// when fields are removed or their meaning changes. New fields can be added
+// without changing the version.
+const JSONEventVersion = "v1"
+
+// The types of the JSON events
+const (
+	JSONPlaybookStart     = "playbook_start"
+	JSONPlaybookEnd       = "playbook_end"
+	JSONPlayStart         = "play_start"
+	JSONTaskStart         = "task_start"
+	JSONHandlerTaskStart  = "handler_task_start"
+	JSONRunnerOK          = "runner_ok"
+	JSONRunnerFailed      = "runner_failed"
+	JSONRunnerSkipped     = "runner_skipped"
+	JSONRunnerUnreachable = "runner_unreachable"
+	JSONRunnerItemOK      = "runner_item_ok"
+	JSONRunnerItemFailed  = "runner_item_failed"
+	JSONRunnerItemRetry   = "runner_item_retry"
+	JSONSummary           = "summary"
+)
+
+// JSONEvent is the JSON representation of an ansible event
+type JSONEvent struct {
+	Version  string    `json:"version"`
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Playbook string    `json:"playbook,omitempty"`
+	Play     string    `json:"play,omitempty"`
+	Task     string    `json:"task,omitempty"`
+	Host     string    `json:"host,omitempty"`
+	Item     string    `json:"item,omitempty"`
+	// Duration in seconds of the task on the host, or of the playbook
+	Duration     float64 `json:"duration,omitempty"`
+	Message      string  `json:"message,omitempty"`
+	Stdout       string  `json:"stdout,omitempty"`
+	Stderr       string  `json:"stderr,omitempty"`
+	IgnoreErrors bool    `json:"ignoreErrors,omitempty"`
+	Attempt      int     `json:"attempt,omitempty"`
+	MaxRetries   int     `json:"maxRetries,omitempty"`
+	// Summary is only set in the summary event, which follows the end of the playbook
+	Summary *JSONRunSummary `json:"summary,omitempty"`
+}
+
+// JSONRunSummary is the outcome of running a playbook
+type JSONRunSummary struct {
+	Success bool                        `json:"success"`
+	Hosts   map[string]*JSONHostSummary `json:"hosts"`
+	// FailedHosts contains the hosts that failed or were unreachable
+	FailedHosts []string `json:"failedHosts,omitempty"`
+}
+
+// JSONHostSummary counts the task results of a host. Failed includes the
+// failed items of the tasks that loop.
+type JSONHostSummary struct {
+	OK          int `json:"ok"`
+	Failed      int `json:"failed"`
+	Skipped     int `json:"skipped"`
+	Unreachable int `json:"unreachable"`
+	Retries     int `json:"retries"`
+}
+
+// JSONExplainer returns an explainer that writes each ansible event as a line
+// of JSON, followed by a summary when the playbook ends.
+func JSONExplainer(out io.Writer) AnsibleEventExplainer {
+	return &jsonExplainer{
+		out:   out,
+		now:   time.Now,
+		hosts: map[string]*JSONHostSummary{},
+	}
+}
+
+type jsonExplainer struct {
+	out io.Writer
+	now func() time.Time
+
+	mu            sync.Mutex
+	playbook      string
+	playbookStart time.Time
+	play          string
+	task          string
+	taskStart     time.Time
+	hosts         map[string]*JSONHostSummary
+	failedHosts   []string
+}
+
+func (e *jsonExplainer) ExplainEvent(ansibleEvent ansible.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	je := JSONEvent{
+		Version: JSONEventVersion,
+		Time:    now,
+	}
+	switch event := ansibleEvent.(type) {
+	case *ansible.PlaybookStartEvent:
+		e.playbook = event.Name
+		e.playbookStart = now
+		e.play = ""
+		e.task = ""
+		e.hosts = map[string]*JSONHostSummary{}
+		e.failedHosts = nil
+		je.Type = JSONPlaybookStart
+	case *ansible.PlayStartEvent:
+		e.play = event.Name
+		e.task = ""
+		je.Type = JSONPlayStart
+	case *ansible.TaskStartEvent:
+		e.task = event.Name
+		e.taskStart = now
+		je.Type = JSONTaskStart
+	case *ansible.HandlerTaskStartEvent:
+		e.task = event.Name
+		e.taskStart = now
+		je.Type = JSONHandlerTaskStart
+	case *ansible.RunnerOKEvent:
+		je.Type = JSONRunnerOK
+		e.host(event.Host).OK++
+		e.setResult(&je, event.Host, event.Result.Message, now)
+	case *ansible.RunnerItemOKEvent:
+		je.Type = JSONRunnerItemOK
+		je.Item = event.Result.Item
+		e.setResult(&je, event.Host, event.Result.Message, now)
+	case *ansible.RunnerSkippedEvent:
+		je.Type = JSONRunnerSkipped
+		e.host(event.Host).Skipped++
+		e.setResult(&je, event.Host, event.Result.Message, now)
+	case *ansible.RunnerFailedEvent:
+		je.Type = JSONRunnerFailed
+		je.IgnoreErrors = event.IgnoreErrors
+		je.Stdout = event.Result.Stdout
+		je.Stderr = event.Result.Stderr
+		if !event.IgnoreErrors {
+			e.host(event.Host).Failed++
+			e.hostFailed(event.Host)
+		}
+		e.setResult(&je, event.Host, event.Result.Message, now)
+	case *ansible.RunnerItemFailedEvent:
+		je.Type = JSONRunnerItemFailed
+		je.Item = event.Result.Item
+		je.IgnoreErrors = event.IgnoreErrors
+		je.Stdout = event.Result.Stdout
+		je.Stderr = event.Result.Stderr
+		if !event.IgnoreErrors {
+			e.host(event.Host).Failed++
+			e.hostFailed(event.Host)
+		}
+		e.setResult(&je, event.Host, event.Result.Message, now)
+	case *ansible.RunnerItemRetryEvent:
+		je.Type = JSONRunnerItemRetry
+		je.Attempt = event.Result.Attempts
+		je.MaxRetries = event.Result.MaxRetries
+		e.host(event.Host).Retries++
+		e.setResult(&je, event.Host, event.Result.Message, now)
+	case *ansible.RunnerUnreachableEvent:
+		je.Type = JSONRunnerUnreachable
+		e.host(event.Host).Unreachable++
+		e.hostFailed(event.Host)
+		e.setResult(&je, event.Host, event.Result.Message, now)
+	case *ansible.PlaybookEndEvent:
+		je.Type = JSONPlaybookEnd
+		je.Playbook = e.playbook
+		je.Duration = now.Sub(e.playbookStart).Seconds()
+		e.write(je)
+		// The summary follows the end of the playbook
+		je = JSONEvent{
+			Version:  JSONEventVersion,
+			Type:     JSONSummary,
+			Time:     now,
+			Playbook: e.playbook,
+			Duration: je.Duration,
+			Summary: &JSONRunSummary{
+				Success:     len(e.failedHosts) == 0,
+				Hosts:       e.hosts,
+				FailedHosts: e.failedHosts,
+			},
+		}
+		e.write(je)
+		return
+	default:
+		return
+	}
+	je.Playbook = e.playbook
+	je.Play = e.play
+	je.Task = e.task
+	e.write(je)
+}
+
+func (e *jsonExplainer) setResult(je *JSONEvent, host string, msg string, now time.Time) {
+	je.Host = host
+	je.Message = msg
+	je.Duration = now.Sub(e.taskStart).Seconds()
+}
+
+func (e *jsonExplainer) host(host string) *JSONHostSummary {
+	h, ok := e.hosts[host]
+	if !ok {
+		h = &JSONHostSummary{}
+		e.hosts[host] = h
+	}
+	return h
+}
+
+func (e *jsonExplainer) hostFailed(host string) {
+	for _, h := range e.failedHosts {
+		if h == host {
+			return
+		}
+	}
+	e.failedHosts = append(e.failedHosts, host)
+}
+
+func (e *jsonExplainer) write(je JSONEvent) {
+	b, err := json.Marshal(je)
+	if err != nil {
+		// all the fields of the event can be marshaled
+		return
+	}
+	e.out.Write(append(b, '\n'))
+}
@@ -0,0 +1,105 @@
+package explain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+func TestJSONExplainer(t *testing.T) {
+	out := &bytes.Buffer{}
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	e := &jsonExplainer{
+		out:   out,
+		now:   func() time.Time { return now },
+		hosts: map[string]*JSONHostSummary{},
+	}
+
+	playbookStart := &ansible.PlaybookStartEvent{}
+	playbookStart.Name = "kubernetes.yaml"
+	play := &ansible.PlayStartEvent{}
+	play.Name = "Install Docker"
+	task := &ansible.TaskStartEvent{}
+	task.Name = "install docker"
+	ok := &ansible.RunnerOKEvent{}
+	ok.Host = "node1"
+	failed := &ansible.RunnerFailedEvent{}
+	failed.Host = "node2"
+	failed.Result.Message = "package not found"
+	itemFailed := &ansible.RunnerItemFailedEvent{}
+	itemFailed.Host = "node3"
+	ignoredItemFailed := &ansible.RunnerItemFailedEvent{}
+	ignoredItemFailed.Host = "node1"
+	ignoredItemFailed.IgnoreErrors = true
+	retry := &ansible.RunnerItemRetryEvent{}
+	retry.Host = "node1"
+	retry.Result.Attempts = 1
+	retry.Result.MaxRetries = 3
+
+	e.ExplainEvent(playbookStart)
+	e.ExplainEvent(play)
+	e.ExplainEvent(task)
+	now = start.Add(2 * time.Second)
+	e.ExplainEvent(ok)
+	e.ExplainEvent(retry)
+	now = start.Add(3 * time.Second)
+	e.ExplainEvent(failed)
+	e.ExplainEvent(itemFailed)
+	e.ExplainEvent(ignoredItemFailed)
+	e.ExplainEvent(&ansible.PlaybookEndEvent{})
+
+	var events []JSONEvent
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var je JSONEvent
+		if err := json.Unmarshal(scanner.Bytes(), &je); err != nil {
+			t.Fatalf("error unmarshaling line %q: %v", scanner.Text(), err)
+		}
+		if je.Version != JSONEventVersion {
+			t.Errorf("expected version %q, but got %q", JSONEventVersion, je.Version)
+		}
+		events = append(events, je)
+	}
+	expectedTypes := []string{JSONPlaybookStart, JSONPlayStart, JSONTaskStart, JSONRunnerOK, JSONRunnerItemRetry, JSONRunnerFailed, JSONRunnerItemFailed, JSONRunnerItemFailed, JSONPlaybookEnd, JSONSummary}
+	if len(events) != len(expectedTypes) {
+		t.Fatalf("expected %d events, but got %d", len(expectedTypes), len(events))
+	}
+	for i, je := range events {
+		if je.Type != expectedTypes[i] {
+			t.Errorf("expected event %d to be %q, but got %q", i, expectedTypes[i], je.Type)
+		}
+	}
+
+	f := events[5]
+	if f.Host != "node2" || f.Task != "install docker" || f.Play != "Install Docker" || f.Playbook != "kubernetes.yaml" {
+		t.Errorf("unexpected failed event: %+v", f)
+	}
+	if f.Message != "package not found" {
+		t.Errorf("expected the error message, but got %q", f.Message)
+	}
+	if f.Duration != 3 {
+		t.Errorf("expected duration of 3 seconds, but got %v", f.Duration)
+	}
+	if events[4].Attempt != 1 || events[4].MaxRetries != 3 {
+		t.Errorf("unexpected retry event: %+v", events[4])
+	}
+
+	s := events[9].Summary
+	if s == nil {
+		t.Fatalf("summary is missing")
+	}
+	if s.Success {
+		t.Errorf("expected the summary to report a failure")
+	}
+	if len(s.FailedHosts) != 2 || s.FailedHosts[0] != "node2" || s.FailedHosts[1] != "node3" {
+		t.Errorf("expected node2 and node3 to be reported as failed, but got %v", s.FailedHosts)
+	}
+	if s.Hosts["node1"].OK != 1 || s.Hosts["node1"].Retries != 1 || s.Hosts["node1"].Failed != 0 || s.Hosts["node2"].Failed != 1 || s.Hosts["node3"].Failed != 1 {
+		t.Errorf("unexpected host summaries: node1 %+v, node2 %+v, node3 %+v", s.Hosts["node1"], s.Hosts["node2"], s.Hosts["node3"])
+	}
+}
@@ -0,0 +1,312 @@
+package explain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+// webhookQueueSize is the number of events that are queued for posting to a
+// webhook. Events are dropped when the queue is full, so that a slow webhook
+// does not hold up ansible.
+const webhookQueueSize = 1000
+
+// webhookDrainTimeout is the time that closing a webhook explainer waits for the
+// queued events to be posted. The events that are still queued are not posted.
+const webhookDrainTimeout = 30 * time.Second
+
+// JSONLinesFile returns an explainer that writes the events to the file
+// in the JSON format, one event per line. The file is closed when the
+// explainer is closed.
+func JSONLinesFile(path string) (AnsibleEventExplainer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating event file %q: %v", path, err)
+	}
+	return &jsonLinesFile{AnsibleEventExplainer: JSONExplainer(f), file: f}, nil
+}
+
+type jsonLinesFile struct {
+	AnsibleEventExplainer
+	file *os.File
+}
+
+func (s *jsonLinesFile) Close() error {
+	return s.file.Close()
+}
+
+// Webhook returns an explainer that posts each event in the JSON format to
+// the given URL. Events are posted in the background, in the order in which
+// they were explained. Closing the explainer waits until all the queued
+// events have been posted, or until the drain timeout expires.
+func Webhook(url string, client *http.Client) AnsibleEventExplainer {
+	s := &webhookSink{
+		url:          url,
+		client:       client,
+		drainTimeout: webhookDrainTimeout,
+		queue:        make(chan []byte, webhookQueueSize),
+		done:         make(chan struct{}),
+		stop:         make(chan struct{}),
+	}
+	s.AnsibleEventExplainer = JSONExplainer(s)
+	go s.post()
+	return s
+}
+
+type webhookSink struct {
+	AnsibleEventExplainer
+	url          string
+	client       *http.Client
+	drainTimeout time.Duration
+	queue        chan []byte
+	done         chan struct{}
+	stop         chan struct{}
+
+	// only accessed by the explainer
+	queued  int
+	dropped int
+
+	mu      sync.Mutex
+	posted  int
+	failed  int
+	lastErr error
+}
+
+// Write queues an event that was encoded by the JSON explainer
+func (s *webhookSink) Write(p []byte) (int, error) {
+	b := make([]byte, len(p))
+	copy(b, p)
+	select {
+	case s.queue <- b:
+		s.queued++
+	default:
+		s.dropped++
+	}
+	return len(p), nil
+}
+
+func (s *webhookSink) post() {
+	defer close(s.done)
+	for b := range s.queue {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+		err := s.send(b)
+		s.mu.Lock()
+		if err != nil {
+			s.failed++
+			s.lastErr = err
+		} else {
+			s.posted++
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *webhookSink) send(event []byte) error {
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(event))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	close(s.queue)
+	select {
+	case <-s.done:
+	case <-time.After(s.drainTimeout):
+		close(s.stop)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []string
+	if s.dropped > 0 {
+		errs = append(errs, fmt.Sprintf("%d event(s) were not posted to %s, as the webhook could not keep up", s.dropped, s.url))
+	}
+	if unsent := s.queued - s.posted - s.failed; unsent > 0 {
+		errs = append(errs, fmt.Sprintf("%d event(s) were not posted to %s within %v of the end of the run", unsent, s.url, s.drainTimeout))
+	}
+	if s.failed > 0 {
+		errs = append(errs, fmt.Sprintf("%d event(s) could not be posted to %s: %v", s.failed, s.url, s.lastErr))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// PrometheusPush returns an explainer that records the duration of the ansible
+// tasks, and pushes it to the Prometheus Pushgateway at the given URL when the
+// explainer is closed. The metrics are grouped by the job "kismatic", the
+// name of the execution, such as "apply", and the run, which is unique to each
+// execution. They replace the metrics previously pushed for the same run only.
+// The execution is not labeled "task", as the task metrics have a label of
+// their own with that name.
+func PrometheusPush(gatewayURL string, execution string, run string, client *http.Client) AnsibleEventExplainer {
+	return &prometheusSink{
+		url:       strings.TrimSuffix(gatewayURL, "/") + "/metrics/job/kismatic/execution/" + url.PathEscape(execution) + "/run/" + url.PathEscape(run),
+		client:    client,
+		now:       time.Now,
+		tasks:     map[taskDurationKey]float64{},
+		playbooks: map[string]*playbookMetrics{},
+	}
+}
+
+type taskDurationKey struct {
+	playbook string
+	task     string
+	host     string
+}
+
+type playbookMetrics struct {
+	duration    float64
+	failedHosts map[string]bool
+}
+
+type prometheusSink struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	playbook      string
+	playbookStart time.Time
+	task          string
+	taskStart     time.Time
+	// tasks with the same name on the same host are added up
+	tasks     map[taskDurationKey]float64
+	playbooks map[string]*playbookMetrics
+}
+
+func (s *prometheusSink) ExplainEvent(ansibleEvent ansible.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	switch event := ansibleEvent.(type) {
+	case *ansible.PlaybookStartEvent:
+		s.playbook = event.Name
+		s.playbookStart = now
+		s.playbooks[s.playbook] = &playbookMetrics{failedHosts: map[string]bool{}}
+	case *ansible.TaskStartEvent:
+		s.task = event.Name
+		s.taskStart = now
+	case *ansible.HandlerTaskStartEvent:
+		s.task = event.Name
+		s.taskStart = now
+	case *ansible.RunnerOKEvent:
+		s.taskDone(event.Host, now)
+	case *ansible.RunnerFailedEvent:
+		s.taskDone(event.Host, now)
+		if !event.IgnoreErrors {
+			s.hostFailed(event.Host)
+		}
+	case *ansible.RunnerUnreachableEvent:
+		s.taskDone(event.Host, now)
+		s.hostFailed(event.Host)
+	case *ansible.PlaybookEndEvent:
+		if pb, ok := s.playbooks[s.playbook]; ok {
+			pb.duration = now.Sub(s.playbookStart).Seconds()
+		}
+	}
+}
+
+func (s *prometheusSink) taskDone(host string, now time.Time) {
+	k := taskDurationKey{playbook: s.playbook, task: s.task, host: host}
+	s.tasks[k] += now.Sub(s.taskStart).Seconds()
+}
+
+func (s *prometheusSink) hostFailed(host string) {
+	if pb, ok := s.playbooks[s.playbook]; ok {
+		pb.failedHosts[host] = true
+	}
+}
+
+// Close pushes the metrics to the gateway, unless no playbook was run
+func (s *prometheusSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.playbooks) == 0 {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodPut, s.url, bytes.NewReader(s.metrics()))
+	if err != nil {
+		return fmt.Errorf("error pushing metrics to %s: %v", s.url, err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error pushing metrics to %s: %v", s.url, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("error pushing metrics to %s: unexpected response status %q", s.url, resp.Status)
+	}
+	return nil
+}
+
+// metrics returns the metrics in the Prometheus text format
+func (s *prometheusSink) metrics() []byte {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "# HELP kismatic_ansible_task_duration_seconds Time it took to run the ansible task on the host.")
+	fmt.Fprintln(&b, "# TYPE kismatic_ansible_task_duration_seconds gauge")
+	keys := make([]taskDurationKey, 0, len(s.tasks))
+	for k := range s.tasks {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].playbook != keys[j].playbook {
+			return keys[i].playbook < keys[j].playbook
+		}
+		if keys[i].task != keys[j].task {
+			return keys[i].task < keys[j].task
+		}
+		return keys[i].host < keys[j].host
+	})
+	for _, k := range keys {
+		fmt.Fprintf(&b, "kismatic_ansible_task_duration_seconds{playbook=\"%s\",task=\"%s\",host=\"%s\"} %g\n",
+			escapeLabelValue(k.playbook), escapeLabelValue(k.task), escapeLabelValue(k.host), s.tasks[k])
+	}
+
+	playbooks := make([]string, 0, len(s.playbooks))
+	for name := range s.playbooks {
+		playbooks = append(playbooks, name)
+	}
+	sort.Strings(playbooks)
+	fmt.Fprintln(&b, "# HELP kismatic_ansible_playbook_duration_seconds Time it took to run the ansible playbook.")
+	fmt.Fprintln(&b, "# TYPE kismatic_ansible_playbook_duration_seconds gauge")
+	for _, name := range playbooks {
+		fmt.Fprintf(&b, "kismatic_ansible_playbook_duration_seconds{playbook=\"%s\"} %g\n", escapeLabelValue(name), s.playbooks[name].duration)
+	}
+	fmt.Fprintln(&b, "# HELP kismatic_ansible_playbook_failed_hosts Number of hosts that failed or were unreachable while running the ansible playbook.")
+	fmt.Fprintln(&b, "# TYPE kismatic_ansible_playbook_failed_hosts gauge")
+	for _, name := range playbooks {
+		fmt.Fprintf(&b, "kismatic_ansible_playbook_failed_hosts{playbook=\"%s\"} %d\n", escapeLabelValue(name), len(s.playbooks[name].failedHosts))
+	}
+	return b.Bytes()
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
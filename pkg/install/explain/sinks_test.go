@@ -0,0 +1,246 @@
+package explain
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+type recordingExplainer struct {
+	events   []ansible.Event
+	closed   bool
+	closeErr error
+}
+
+func (r *recordingExplainer) ExplainEvent(e ansible.Event) {
+	r.events = append(r.events, e)
+}
+
+func (r *recordingExplainer) Close() error {
+	r.closed = true
+	return r.closeErr
+}
+
+type nonClosingExplainer struct {
+	events int
+}
+
+func (n *nonClosingExplainer) ExplainEvent(e ansible.Event) {
+	n.events++
+}
+
+func testEventStream() <-chan ansible.Event {
+	start := &ansible.PlaybookStartEvent{}
+	start.Name = "kubernetes.yaml"
+	task := &ansible.TaskStartEvent{}
+	task.Name = "install docker"
+	ok := &ansible.RunnerOKEvent{}
+	ok.Host = "node1"
+	events := make(chan ansible.Event, 4)
+	events <- start
+	events <- task
+	events <- ok
+	events <- &ansible.PlaybookEndEvent{}
+	close(events)
+	return events
+}
+
+func TestFanOutExplainsEventsAndClosesExplainers(t *testing.T) {
+	first := &recordingExplainer{}
+	second := &nonClosingExplainer{}
+	third := &recordingExplainer{closeErr: errors.New("close failed")}
+	stream := &AnsibleEventStreamExplainer{EventExplainer: FanOut(first, second, third)}
+
+	err := stream.Explain(testEventStream())
+	if err == nil || !strings.Contains(err.Error(), "close failed") {
+		t.Errorf("expected the close error to be returned, but got %v", err)
+	}
+	if len(first.events) != 4 || second.events != 4 || len(third.events) != 4 {
+		t.Errorf("expected all explainers to receive 4 events, but got %d, %d and %d", len(first.events), second.events, len(third.events))
+	}
+	if !first.closed || !third.closed {
+		t.Errorf("expected all the explainers to be closed")
+	}
+}
+
+func TestJSONLinesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonl-sink")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "events.jsonl")
+	e, err := JSONLinesFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stream := &AnsibleEventStreamExplainer{EventExplainer: e}
+	if err := stream.Explain(testEventStream()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("error opening events file: %v", err)
+	}
+	defer f.Close()
+	var types []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var je JSONEvent
+		if err := json.Unmarshal(scanner.Bytes(), &je); err != nil {
+			t.Fatalf("error unmarshaling line %q: %v", scanner.Text(), err)
+		}
+		types = append(types, je.Type)
+	}
+	expected := []string{JSONPlaybookStart, JSONTaskStart, JSONRunnerOK, JSONPlaybookEnd, JSONSummary}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected events %v, but got %v", expected, types)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var types []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected a POST, but got %s", r.Method)
+		}
+		var je JSONEvent
+		if err := json.NewDecoder(r.Body).Decode(&je); err != nil {
+			t.Errorf("error decoding event: %v", err)
+		}
+		mu.Lock()
+		types = append(types, je.Type)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	stream := &AnsibleEventStreamExplainer{EventExplainer: Webhook(server.URL, http.DefaultClient)}
+	if err := stream.Explain(testEventStream()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// All the events have been posted once the stream explainer returns
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{JSONPlaybookStart, JSONTaskStart, JSONRunnerOK, JSONPlaybookEnd, JSONSummary}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected events %v, but got %v", expected, types)
+	}
+}
+
+func TestWebhookErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	stream := &AnsibleEventStreamExplainer{EventExplainer: Webhook(server.URL, http.DefaultClient)}
+	err := stream.Explain(testEventStream())
+	if err == nil || !strings.Contains(err.Error(), "5 event(s) could not be posted") {
+		t.Errorf("expected an error about the events that were not posted, but got %v", err)
+	}
+}
+
+func TestWebhookDrainTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	sink := Webhook(server.URL, http.DefaultClient).(*webhookSink)
+	sink.drainTimeout = 100 * time.Millisecond
+	stream := &AnsibleEventStreamExplainer{EventExplainer: sink}
+	start := time.Now()
+	err := stream.Explain(testEventStream())
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected closing the webhook to give up after the drain timeout, but it took %v", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "5 event(s) were not posted") {
+		t.Errorf("expected an error about the events that were not posted, but got %v", err)
+	}
+}
+
+func TestPrometheusPush(t *testing.T) {
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.EscapedPath()
+		b, _ := ioutil.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer server.Close()
+
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	sink := PrometheusPush(server.URL+"/", "upgrade nodes", "2017-01-01-00-00-00", http.DefaultClient).(*prometheusSink)
+	sink.now = func() time.Time { return now }
+
+	playbookStart := &ansible.PlaybookStartEvent{}
+	playbookStart.Name = "kubernetes.yaml"
+	task := &ansible.TaskStartEvent{}
+	task.Name = `install "docker"`
+	ok := &ansible.RunnerOKEvent{}
+	ok.Host = "node1"
+	failed := &ansible.RunnerFailedEvent{}
+	failed.Host = "node2"
+
+	sink.ExplainEvent(playbookStart)
+	sink.ExplainEvent(task)
+	now = start.Add(2 * time.Second)
+	sink.ExplainEvent(ok)
+	now = start.Add(3 * time.Second)
+	sink.ExplainEvent(failed)
+	now = start.Add(5 * time.Second)
+	sink.ExplainEvent(&ansible.PlaybookEndEvent{})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if method != http.MethodPut {
+		t.Errorf("expected a PUT, but got %s", method)
+	}
+	if path != "/metrics/job/kismatic/execution/upgrade%20nodes/run/2017-01-01-00-00-00" {
+		t.Errorf("unexpected push path %q", path)
+	}
+	expected := []string{
+		`kismatic_ansible_task_duration_seconds{playbook="kubernetes.yaml",task="install \"docker\"",host="node1"} 2`,
+		`kismatic_ansible_task_duration_seconds{playbook="kubernetes.yaml",task="install \"docker\"",host="node2"} 3`,
+		`kismatic_ansible_playbook_duration_seconds{playbook="kubernetes.yaml"} 5`,
+		`kismatic_ansible_playbook_failed_hosts{playbook="kubernetes.yaml"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected the pushed metrics to contain %q, but got:\n%s", line, body)
+		}
+	}
+}
+
+func TestPrometheusPushWithoutPlaybook(t *testing.T) {
+	pushed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushed = true
+	}))
+	defer server.Close()
+
+	sink := PrometheusPush(server.URL, "apply", "2017-01-01-00-00-00", http.DefaultClient).(*prometheusSink)
+	if err := sink.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pushed {
+		t.Errorf("expected metrics not to be pushed when no playbook was run")
+	}
+}
@@ -29,6 +29,9 @@ const (
 	kubeletUserPrefix                   = "system:node"
 	kubeletGroup                        = "system:nodes"
 	contivProxyServerCertFilename       = "contiv-proxy-server"
+	inspectorCertFilename               = "inspector"
+	// the inspector certificate is only used while the pre-flight checks run
+	inspectorCertExpiry = "2h"
 )
 
 // The PKI provides a way for generating certificates for the cluster described by the Plan
@@ -36,10 +39,13 @@ type PKI interface {
 	CertificateAuthorityExists() (bool, error)
 	NodeCertificateExists(node Node) (bool, error)
 	GenerateNodeCertificate(plan *Plan, node Node, ca *tls.CA) error
+	RegenerateNodeCertificate(plan *Plan, node Node, ca *tls.CA) (bool, error)
 	GetClusterCA() (*tls.CA, error)
 	GenerateClusterCA(p *Plan) (*tls.CA, error)
 	GenerateClusterCertificates(p *Plan, ca *tls.CA) error
 	GenerateCertificate(name string, validityPeriod string, commonName string, subjectAlternateNames []string, organizations []string, ca *tls.CA, overwrite bool) (bool, error)
+	RotateClusterCA(p *Plan) (*tls.CA, error)
+	RotateClusterCertificates(p *Plan, ca *tls.CA) error
 }
 
 // LocalPKI is a file-based PKI
@@ -328,6 +334,42 @@ func (lp *LocalPKI) GenerateClusterCertificates(p *Plan, ca *tls.CA) error {
 	return nil
 }
 
+// RotateClusterCA backs up the existing Certificate Authority and generates
+// a new one for the cluster.
+func (lp *LocalPKI) RotateClusterCA(p *Plan) (*tls.CA, error) {
+	for _, f := range []string{"ca.pem", "ca-key.pem"} {
+		file := filepath.Join(lp.GeneratedCertsDirectory, f)
+		if err := os.Rename(file, file+".bak"); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error backing up existing CA file %q: %v", file, err)
+		}
+	}
+	return lp.GenerateClusterCA(p)
+}
+
+// RotateClusterCertificates reissues all the certificates required for the
+// cluster described in the plan file, replacing the existing ones. The service
+// account signing certificate is not reissued, as replacing its key would
+// invalidate all the service account tokens in the cluster.
+func (lp *LocalPKI) RotateClusterCertificates(p *Plan, ca *tls.CA) error {
+	if lp.Log == nil {
+		lp.Log = ioutil.Discard
+	}
+	manifest, err := certManifestForCluster(*p)
+	if err != nil {
+		return err
+	}
+	for _, s := range manifest {
+		if s.filename == serviceAccountCertFilename {
+			continue
+		}
+		if err := generateCert(ca, lp.GeneratedCertsDirectory, s, p.Cluster.Certificates.Expiry); err != nil {
+			return err
+		}
+		util.PrettyPrintOk(lp.Log, "Reissued certificate for %s", s.description)
+	}
+	return nil
+}
+
 // Validates that the certificate was generated by us. If so, renames it
 // to make a backup and returns true. Otherwise returns false.
 func renamePre133AdminCert(filename, dir string) (bool, error) {
@@ -416,6 +458,40 @@ func (lp *LocalPKI) GenerateNodeCertificate(plan *Plan, node Node, ca *tls.CA) e
 	return nil
 }
 
+// RegenerateNodeCertificate replaces the certificates of the given node that are
+// missing, or that are no longer valid for the plan. For example, when the
+// subject alternate names required by the node have changed. Returns true if
+// any certificate was generated.
+func (lp *LocalPKI) RegenerateNodeCertificate(plan *Plan, node Node, ca *tls.CA) (bool, error) {
+	m, err := certManifestForNode(*plan, node)
+	if err != nil {
+		return false, err
+	}
+	var regenerated bool
+	for _, s := range m {
+		exists, err := tls.CertKeyPairExists(s.filename, lp.GeneratedCertsDirectory)
+		if err != nil {
+			return regenerated, err
+		}
+		if exists {
+			warn, err := tls.CertValid(s.commonName, s.subjectAlternateNames, s.organizations, s.filename, lp.GeneratedCertsDirectory)
+			if err != nil {
+				return regenerated, err
+			}
+			if len(warn) == 0 {
+				continue
+			}
+			util.PrettyPrintWarn(lp.Log, "Found certificate for %s, but it is no longer valid", s.description)
+		}
+		if err := generateCert(ca, lp.GeneratedCertsDirectory, s, plan.Cluster.Certificates.Expiry); err != nil {
+			return regenerated, err
+		}
+		regenerated = true
+		util.PrettyPrintOk(lp.Log, "Generated certificate for %s", s.description)
+	}
+	return regenerated, nil
+}
+
 // GenerateCertificate creates a private key and certificate for the given name, CN, subjectAlternateNames and organizations
 // If cert exists, will not fail
 // Pass overwrite to replace an existing cert
@@ -1,6 +1,7 @@
 package install
 
 import (
+	"bytes"
 	"crypto/x509"
 	"fmt"
 	"io/ioutil"
@@ -1084,3 +1085,105 @@ func TestGenerateCertificate(t *testing.T) {
 		}
 	}
 }
+
+func TestRotateClusterCertificates(t *testing.T) {
+	pki := getPKI(t)
+	defer cleanup(pki.GeneratedCertsDirectory, t)
+
+	p := getPlan()
+	ca, err := pki.GenerateClusterCA(p)
+	if err != nil {
+		t.Fatalf("error generating CA for test: %v", err)
+	}
+	if err = pki.GenerateClusterCertificates(p, ca); err != nil {
+		t.Fatalf("error generating cluster certificates: %v", err)
+	}
+	adminCert := mustReadCertFile(filepath.Join(pki.GeneratedCertsDirectory, adminCertFilename+".pem"), t)
+	saCert := mustReadCertFile(filepath.Join(pki.GeneratedCertsDirectory, serviceAccountCertFilename+".pem"), t)
+
+	if err = pki.RotateClusterCertificates(p, ca); err != nil {
+		t.Fatalf("error rotating cluster certificates: %v", err)
+	}
+
+	rotatedAdminCert := mustReadCertFile(filepath.Join(pki.GeneratedCertsDirectory, adminCertFilename+".pem"), t)
+	if bytes.Equal(rotatedAdminCert.Raw, adminCert.Raw) {
+		t.Errorf("admin certificate was not reissued")
+	}
+	rotatedSACert := mustReadCertFile(filepath.Join(pki.GeneratedCertsDirectory, serviceAccountCertFilename+".pem"), t)
+	if !bytes.Equal(rotatedSACert.Raw, saCert.Raw) {
+		t.Errorf("service account signing certificate was reissued")
+	}
+	// The reissued certificates must still be valid for the cluster
+	warns, errs := pki.ValidateClusterCertificates(p)
+	if len(warns) > 0 || len(errs) > 0 {
+		t.Errorf("rotated certificates are not valid. warnings: %v, errors: %v", warns, errs)
+	}
+}
+
+func TestRotateClusterCA(t *testing.T) {
+	pki := getPKI(t)
+	defer cleanup(pki.GeneratedCertsDirectory, t)
+
+	p := getPlan()
+	if _, err := pki.GenerateClusterCA(p); err != nil {
+		t.Fatalf("error generating CA for test: %v", err)
+	}
+	caCert := mustReadCertFile(filepath.Join(pki.GeneratedCertsDirectory, "ca.pem"), t)
+
+	if _, err := pki.RotateClusterCA(p); err != nil {
+		t.Fatalf("error rotating CA: %v", err)
+	}
+
+	rotatedCACert := mustReadCertFile(filepath.Join(pki.GeneratedCertsDirectory, "ca.pem"), t)
+	if bytes.Equal(rotatedCACert.Raw, caCert.Raw) {
+		t.Errorf("CA was not regenerated")
+	}
+	backup := mustReadCertFile(filepath.Join(pki.GeneratedCertsDirectory, "ca.pem.bak"), t)
+	if !bytes.Equal(backup.Raw, caCert.Raw) {
+		t.Errorf("the existing CA was not backed up")
+	}
+}
+
+func TestRegenerateNodeCertificate(t *testing.T) {
+	pki := getPKI(t)
+	defer cleanup(pki.GeneratedCertsDirectory, t)
+
+	p := getPlan()
+	ca, err := pki.GenerateClusterCA(p)
+	if err != nil {
+		t.Fatalf("error generating CA for test: %v", err)
+	}
+	node := p.Master.Nodes[0]
+	if err = pki.GenerateNodeCertificate(p, node, ca); err != nil {
+		t.Fatalf("failed to generate certificate for node: %v", err)
+	}
+	regenerated, err := pki.RegenerateNodeCertificate(p, node, ca)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if regenerated {
+		t.Errorf("valid certificates were regenerated")
+	}
+
+	// The API server certificate is no longer valid after changing the load balanced name
+	p.Master.LoadBalancedFQDN = "new.lb.example.com"
+	regenerated, err = pki.RegenerateNodeCertificate(p, node, ca)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !regenerated {
+		t.Errorf("invalid certificate was not regenerated")
+	}
+	certFile := filepath.Join(pki.GeneratedCertsDirectory, fmt.Sprintf("%s-apiserver.pem", node.Host))
+	cert := mustReadCertFile(certFile, t)
+	found := false
+	for _, name := range cert.DNSNames {
+		if name == p.Master.LoadBalancedFQDN {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("regenerated certificate does not have the new load balanced FQDN")
+	}
+}
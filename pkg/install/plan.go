@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
@@ -19,6 +20,7 @@ import (
 const (
 	ket133PackageManagerProvider = "helm"
 	defaultCAExpiry              = "17520h"
+	planFilePerms                = 0644
 )
 
 // PlanTemplateOptions contains the options that are desired when generating
@@ -68,6 +70,11 @@ func (fp *FilePlanner) Read() (*Plan, error) {
 	// set nil values to defaults
 	setDefaults(p)
 
+	// relative rules files are relative to the plan file
+	if p.Inspector != nil {
+		p.Inspector.planDir = filepath.Dir(fp.File)
+	}
+
 	return p, nil
 }
 
@@ -169,11 +176,9 @@ func (fp *FilePlanner) Write(p *Plan) error {
 		return fmt.Errorf("error marshalling plan to yaml: %v", marshalErr)
 	}
 
-	f, err := os.Create(fp.File)
-	if err != nil {
-		return fmt.Errorf("error making plan file: %v", err)
-	}
-	defer f.Close()
+	// the plan is written to a buffer first, so that the plan file is
+	// replaced in one step and never left half-written
+	f := &bytes.Buffer{}
 
 	// the stack keeps track of the object we are in
 	// for example, when we are inside cluster.networking, looking at the key 'foo'
@@ -225,6 +230,9 @@ func (fp *FilePlanner) Write(p *Plan) error {
 		addNewLineBeforeComment = true
 	}
 
+	if err := util.WriteFileAtomic(fp.File, f.Bytes(), planFilePerms); err != nil {
+		return fmt.Errorf("error writing plan file: %v", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,458 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PlanDiff contains the differences between two plans, and the impact of
+// applying the new plan to the cluster
+type PlanDiff struct {
+	// AddedNodes contains the nodes that were added, or that were given new roles
+	AddedNodes []NodeRoles `json:"addedNodes,omitempty"`
+	// RemovedNodes contains the nodes that were removed, or that lost roles
+	RemovedNodes []NodeRoles `json:"removedNodes,omitempty"`
+	// NodeChanges contains the settings that changed on existing nodes
+	NodeChanges []SettingChange `json:"nodeChanges,omitempty"`
+	// OverrideChanges contains the component option overrides that changed
+	OverrideChanges []SettingChange `json:"overrideChanges,omitempty"`
+	// AddOnChanges contains the add-ons that were enabled, disabled or changed
+	AddOnChanges []SettingChange `json:"addOnChanges,omitempty"`
+	// CertificateChanges contains the certificates whose subject alternate names changed
+	CertificateChanges []CertificateChange `json:"certificateChanges,omitempty"`
+	// AffectedComponents contains the components that would be configured
+	// again when applying the new plan
+	AffectedComponents []string `json:"affectedComponents,omitempty"`
+	// Services that would be restarted when applying the new plan
+	RestartedServices []string `json:"restartedServices,omitempty"`
+	// Warnings about changes that are not applied by the installer
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// NodeRoles is a node along with some of its roles
+type NodeRoles struct {
+	Host  string   `json:"host"`
+	IP    string   `json:"ip"`
+	Roles []string `json:"roles"`
+}
+
+// SettingChange is a setting that has a different value in the new plan.
+// The old value is empty when the setting was added, and the new value is
+// empty when the setting was removed.
+type SettingChange struct {
+	Setting string `json:"setting"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+}
+
+// CertificateChange is a certificate whose subject alternate names are
+// different in the new plan
+type CertificateChange struct {
+	Certificate string   `json:"certificate"`
+	AddedSANs   []string `json:"addedSANs,omitempty"`
+	RemovedSANs []string `json:"removedSANs,omitempty"`
+}
+
+// Empty returns true if there are no differences between the plans, and
+// nothing to warn about
+func (d PlanDiff) Empty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 && len(d.NodeChanges) == 0 &&
+		len(d.OverrideChanges) == 0 && len(d.AddOnChanges) == 0 && len(d.CertificateChanges) == 0 &&
+		len(d.Warnings) == 0
+}
+
+// The components that support option overrides, which are also the
+// services that are restarted when the overrides change
+var componentOverrides = []struct {
+	name      string
+	component string
+	get       func(p Plan) map[string]string
+}{
+	{"kube_apiserver", "kube-apiserver", func(p Plan) map[string]string { return p.Cluster.APIServerOptions.Overrides }},
+	{"kube_controller_manager", "kube-controller-manager", func(p Plan) map[string]string { return p.Cluster.KubeControllerManagerOptions.Overrides }},
+	{"kube_scheduler", "kube-scheduler", func(p Plan) map[string]string { return p.Cluster.KubeSchedulerOptions.Overrides }},
+	{"kube_proxy", "kube-proxy", func(p Plan) map[string]string { return p.Cluster.KubeProxyOptions.Overrides }},
+	{"kubelet", "kubelet", func(p Plan) map[string]string { return p.Cluster.KubeletOptions.Overrides }},
+}
+
+// DiffPlans compares the new plan with the old plan, and returns the
+// differences between them, along with the components that would be
+// configured again and the services that would be restarted when applying
+// the new plan.
+func DiffPlans(oldPlan, newPlan Plan) (*PlanDiff, error) {
+	d := &PlanDiff{}
+	components := map[string]bool{}
+	services := map[string]bool{}
+
+	// Nodes
+	oldNodes := nodeRolesByHost(oldPlan)
+	newNodes := nodeRolesByHost(newPlan)
+	for _, host := range sortedNodeHosts(newNodes) {
+		n := newNodes[host]
+		var oldRoles []string
+		if old, ok := oldNodes[host]; ok {
+			oldRoles = old.Roles
+			d.NodeChanges = append(d.NodeChanges, diffNodes(oldPlan, newPlan, old, n)...)
+		}
+		added := stringsNotIn(n.Roles, oldRoles)
+		if len(added) == 0 {
+			continue
+		}
+		d.AddedNodes = append(d.AddedNodes, NodeRoles{Host: host, IP: n.IP, Roles: added})
+		components["certificates"] = true
+		if newPlan.Cluster.Networking.UpdateHostsFiles {
+			components["hosts files"] = true
+		}
+		for _, r := range added {
+			switch r {
+			case "etcd":
+				components["etcd"] = true
+				if etcdNetworkingEnabled(newPlan) {
+					components["network etcd"] = true
+				}
+				// the existing members and the API servers are configured with the etcd cluster members
+				services["etcd"] = true
+				services["kube-apiserver"] = true
+				components["kube-apiserver"] = true
+			case "master":
+				components["kube-apiserver"] = true
+				components["kube-scheduler"] = true
+				components["kube-controller-manager"] = true
+			case "ingress":
+				components["ingress"] = true
+			case "storage":
+				components["storage"] = true
+			}
+		}
+		if containsAny([]string{"master", "worker", "ingress", "storage"}, added) {
+			components["docker"] = true
+			components["kubelet"] = true
+			components["kube-proxy"] = true
+			components["node labels"] = true
+			if cni := cniComponent(newPlan); cni != "" {
+				components[cni] = true
+			}
+		}
+	}
+	for _, host := range sortedNodeHosts(oldNodes) {
+		n := oldNodes[host]
+		var newRoles []string
+		if nn, ok := newNodes[host]; ok {
+			newRoles = nn.Roles
+		}
+		removed := stringsNotIn(n.Roles, newRoles)
+		if len(removed) == 0 {
+			continue
+		}
+		d.RemovedNodes = append(d.RemovedNodes, NodeRoles{Host: host, IP: n.IP, Roles: removed})
+		d.Warnings = append(d.Warnings, fmt.Sprintf("%s %v: removing nodes or roles from the plan does not remove them from the cluster", host, removed))
+	}
+	for _, c := range d.NodeChanges {
+		switch {
+		case strings.Contains(c.Setting, ".labels."):
+			components["node labels"] = true
+		case strings.Contains(c.Setting, ".kubelet."):
+			components["kubelet"] = true
+			services["kubelet"] = true
+		default:
+			d.Warnings = append(d.Warnings, fmt.Sprintf("%s: changing the address of an existing node is not supported", c.Setting))
+		}
+	}
+
+	// Component overrides
+	for _, c := range componentOverrides {
+		changes := diffMaps(c.name, c.get(oldPlan), c.get(newPlan))
+		if len(changes) == 0 {
+			continue
+		}
+		d.OverrideChanges = append(d.OverrideChanges, changes...)
+		components[c.component] = true
+		services[c.component] = true
+	}
+
+	// Add-ons
+	oldAddOns := addOnStates(oldPlan)
+	newAddOns := addOnStates(newPlan)
+	for _, a := range addOnComponents(newPlan) {
+		if oldAddOns[a.name] == newAddOns[a.name] {
+			continue
+		}
+		d.AddOnChanges = append(d.AddOnChanges, SettingChange{Setting: a.name, Old: oldAddOns[a.name], New: newAddOns[a.name]})
+		if newAddOns[a.name] == "disabled" {
+			d.Warnings = append(d.Warnings, fmt.Sprintf("%s: disabling an add-on does not remove it from the cluster", a.name))
+			continue
+		}
+		if a.component != "" {
+			components[a.component] = true
+		}
+	}
+
+	// Certificates
+	certChanges, err := diffCertificateSANs(oldPlan, newPlan)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range certChanges {
+		d.CertificateChanges = append(d.CertificateChanges, c)
+		switch {
+		case strings.HasSuffix(c.Certificate, "-etcd"):
+			components["etcd certificates"] = true
+			services["etcd"] = true
+		case strings.HasSuffix(c.Certificate, "-apiserver"):
+			components["certificates"] = true
+			services["kube-apiserver"] = true
+		}
+	}
+
+	d.AffectedComponents = sortedSet(components)
+	d.RestartedServices = sortedSet(services)
+	return d, nil
+}
+
+// LastRecordedPlan returns the path of the plan file that was recorded in
+// the most recent run in the runs directory, or an empty string if no plan
+// has been recorded.
+func LastRecordedPlan(runsDir string) (string, error) {
+	tasks, err := ioutil.ReadDir(runsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error listing runs in %q: %v", runsDir, err)
+	}
+	var lastRun time.Time
+	var lastPlan string
+	for _, t := range tasks {
+		if !t.IsDir() {
+			continue
+		}
+		runs, err := ioutil.ReadDir(filepath.Join(runsDir, t.Name()))
+		if err != nil {
+			return "", fmt.Errorf("error listing runs in %q: %v", filepath.Join(runsDir, t.Name()), err)
+		}
+		for _, r := range runs {
+			if !r.IsDir() {
+				continue
+			}
+			// the run directories are named after the time the run started
+			started, err := time.ParseInLocation(runDirectoryNameFormat, r.Name(), time.Local)
+			if err != nil || (lastPlan != "" && !started.After(lastRun)) {
+				continue
+			}
+			planFile := filepath.Join(runsDir, t.Name(), r.Name(), "kismatic-cluster.yaml")
+			if _, err := os.Stat(planFile); err != nil {
+				continue
+			}
+			lastRun = started
+			lastPlan = planFile
+		}
+	}
+	return lastPlan, nil
+}
+
+func nodeRolesByHost(p Plan) map[string]NodeRoles {
+	nodes := map[string]NodeRoles{}
+	groups := []struct {
+		role  string
+		nodes []Node
+	}{
+		{"etcd", p.Etcd.Nodes},
+		{"master", p.Master.Nodes},
+		{"worker", p.Worker.Nodes},
+		{"ingress", p.Ingress.Nodes},
+		{"storage", p.Storage.Nodes},
+	}
+	for _, g := range groups {
+		for _, n := range g.nodes {
+			nr := nodes[n.Host]
+			nr.Host = n.Host
+			nr.IP = n.IP
+			nr.Roles = append(nr.Roles, g.role)
+			nodes[n.Host] = nr
+		}
+	}
+	return nodes
+}
+
+// returns the changes to the address, labels and kubelet overrides of a node
+func diffNodes(oldPlan, newPlan Plan, oldNode, newNode NodeRoles) []SettingChange {
+	o := findNode(oldPlan, oldNode.Host)
+	n := findNode(newPlan, newNode.Host)
+	var changes []SettingChange
+	if o.IP != n.IP {
+		changes = append(changes, SettingChange{Setting: n.Host + ".ip", Old: o.IP, New: n.IP})
+	}
+	if o.InternalIP != n.InternalIP {
+		changes = append(changes, SettingChange{Setting: n.Host + ".internalip", Old: o.InternalIP, New: n.InternalIP})
+	}
+	changes = append(changes, diffMaps(n.Host+".labels", o.Labels, n.Labels)...)
+	changes = append(changes, diffMaps(n.Host+".kubelet", o.KubeletOptions.Overrides, n.KubeletOptions.Overrides)...)
+	return changes
+}
+
+func findNode(p Plan, host string) Node {
+	for _, n := range p.getAllNodes() {
+		if n.Host == host {
+			return n
+		}
+	}
+	return Node{}
+}
+
+func diffMaps(prefix string, old, new map[string]string) []SettingChange {
+	keys := map[string]bool{}
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+	var changes []SettingChange
+	for _, k := range sortedSet(keys) {
+		if old[k] != new[k] {
+			changes = append(changes, SettingChange{Setting: prefix + "." + k, Old: old[k], New: new[k]})
+		}
+	}
+	return changes
+}
+
+type addOnComponent struct {
+	name      string
+	component string
+}
+
+// returns the add-ons, along with the component that is configured when
+// the add-on is enabled. Ingress and storage are enabled by their nodes.
+func addOnComponents(p Plan) []addOnComponent {
+	return []addOnComponent{
+		{"cni", cniComponent(p)},
+		{"dns", "dns"},
+		{"heapster", "heapster"},
+		{"dashboard", "dashboard"},
+		{"package_manager", "helm"},
+		{"rescheduler", "rescheduler"},
+		{"ingress", "ingress"},
+		{"storage", "storage"},
+	}
+}
+
+// returns whether each add-on is enabled, the same way the cluster catalog is built
+func addOnStates(p Plan) map[string]string {
+	state := func(enabled bool) string {
+		if enabled {
+			return "enabled"
+		}
+		return "disabled"
+	}
+	states := map[string]string{
+		"cni":             "disabled",
+		"dns":             state(!p.AddOns.DNS.Disable),
+		"heapster":        state(p.AddOns.HeapsterMonitoring != nil && !p.AddOns.HeapsterMonitoring.Disable),
+		"dashboard":       state(p.AddOns.Dashboard == nil || !p.AddOns.Dashboard.Disable),
+		"package_manager": state(!p.AddOns.PackageManager.Disable),
+		"rescheduler":     state(!p.AddOns.Rescheduler.Disable),
+		"ingress":         state(len(p.Ingress.Nodes) > 0),
+		"storage":         state(len(p.Storage.Nodes) > 0),
+	}
+	if p.AddOns.CNI != nil && !p.AddOns.CNI.Disable {
+		states["cni"] = p.AddOns.CNI.Provider
+	}
+	return states
+}
+
+// returns the CNI provider that is installed, if any
+func cniComponent(p Plan) string {
+	if p.AddOns.CNI == nil || p.AddOns.CNI.Disable {
+		return ""
+	}
+	switch p.AddOns.CNI.Provider {
+	case cniProviderCalico, cniProviderWeave, cniProviderContiv:
+		return p.AddOns.CNI.Provider
+	}
+	return ""
+}
+
+func etcdNetworkingEnabled(p Plan) bool {
+	return p.AddOns.CNI != nil && !p.AddOns.CNI.Disable &&
+		(p.AddOns.CNI.Provider == cniProviderCalico || p.AddOns.CNI.Provider == cniProviderContiv)
+}
+
+// returns the certificates of the nodes in both plans whose subject alternate
+// names are different in the new plan
+func diffCertificateSANs(oldPlan, newPlan Plan) ([]CertificateChange, error) {
+	oldSANs, err := nodeCertificateSANs(oldPlan)
+	if err != nil {
+		return nil, err
+	}
+	newSANs, err := nodeCertificateSANs(newPlan)
+	if err != nil {
+		return nil, err
+	}
+	var changes []CertificateChange
+	for _, name := range sortedKeys(newSANs) {
+		old, ok := oldSANs[name]
+		if !ok {
+			// the certificate of a new node
+			continue
+		}
+		c := CertificateChange{
+			Certificate: name,
+			AddedSANs:   stringsNotIn(newSANs[name], old),
+			RemovedSANs: stringsNotIn(old, newSANs[name]),
+		}
+		if len(c.AddedSANs) > 0 || len(c.RemovedSANs) > 0 {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+func nodeCertificateSANs(p Plan) (map[string][]string, error) {
+	sans := map[string][]string{}
+	for _, n := range p.GetUniqueNodes() {
+		manifest, err := certManifestForNode(p, n)
+		if err != nil {
+			return nil, fmt.Errorf("error getting certificates for node %q: %v", n.Host, err)
+		}
+		for _, s := range manifest {
+			if len(s.subjectAlternateNames) > 0 {
+				sans[s.filename] = s.subjectAlternateNames
+			}
+		}
+	}
+	return sans, nil
+}
+
+// returns the items in a that are not in b
+func stringsNotIn(a, b []string) []string {
+	var diff []string
+	for _, s := range a {
+		if !contains(s, b) {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
+
+func sortedNodeHosts(m map[string]NodeRoles) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedSet(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,219 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func diffTestPlan() Plan {
+	return Plan{
+		Cluster: Cluster{
+			Networking: NetworkConfig{
+				ServiceCIDRBlock: "10.0.0.0/24",
+			},
+		},
+		AddOns: AddOns{
+			CNI: &CNI{Provider: cniProviderCalico},
+		},
+		Etcd: NodeGroup{
+			Nodes: []Node{{Host: "etcd01", IP: "10.0.1.1"}},
+		},
+		Master: MasterNodeGroup{
+			Nodes:                 []Node{{Host: "master01", IP: "10.0.2.1"}},
+			LoadBalancedFQDN:      "master.example.com",
+			LoadBalancedShortName: "master",
+		},
+		Worker: NodeGroup{
+			Nodes: []Node{{Host: "worker01", IP: "10.0.3.1"}},
+		},
+	}
+}
+
+func TestDiffPlansNoChanges(t *testing.T) {
+	d, err := DiffPlans(diffTestPlan(), diffTestPlan())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !d.Empty() {
+		t.Errorf("expected no changes, but got %+v", d)
+	}
+	if len(d.AffectedComponents) != 0 || len(d.RestartedServices) != 0 {
+		t.Errorf("expected no components or services, but got %v %v", d.AffectedComponents, d.RestartedServices)
+	}
+}
+
+func TestPlanDiffEmptyWithWarnings(t *testing.T) {
+	d := PlanDiff{Warnings: []string{"Removing the add-on dashboard from the plan file does not remove it from the cluster."}}
+	if d.Empty() {
+		t.Errorf("expected a diff with warnings not to be empty")
+	}
+}
+
+func TestDiffPlansNodes(t *testing.T) {
+	oldPlan := diffTestPlan()
+	newPlan := diffTestPlan()
+	newPlan.Worker.Nodes = []Node{
+		{Host: "worker01", IP: "10.0.3.1", Labels: map[string]string{"team": "blue"}},
+		{Host: "worker02", IP: "10.0.3.2"},
+	}
+	newPlan.Ingress.Nodes = []Node{{Host: "worker01", IP: "10.0.3.1", Labels: map[string]string{"team": "blue"}}}
+	newPlan.Etcd.Nodes = nil
+
+	d, err := DiffPlans(oldPlan, newPlan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedAdded := []NodeRoles{
+		{Host: "worker01", IP: "10.0.3.1", Roles: []string{"ingress"}},
+		{Host: "worker02", IP: "10.0.3.2", Roles: []string{"worker"}},
+	}
+	if !reflect.DeepEqual(d.AddedNodes, expectedAdded) {
+		t.Errorf("expected added nodes %v, but got %v", expectedAdded, d.AddedNodes)
+	}
+	expectedRemoved := []NodeRoles{{Host: "etcd01", IP: "10.0.1.1", Roles: []string{"etcd"}}}
+	if !reflect.DeepEqual(d.RemovedNodes, expectedRemoved) {
+		t.Errorf("expected removed nodes %v, but got %v", expectedRemoved, d.RemovedNodes)
+	}
+	expectedChanges := []SettingChange{{Setting: "worker01.labels.team", New: "blue"}}
+	if !reflect.DeepEqual(d.NodeChanges, expectedChanges) {
+		t.Errorf("expected node changes %v, but got %v", expectedChanges, d.NodeChanges)
+	}
+	expectedAddOns := []SettingChange{{Setting: "ingress", Old: "disabled", New: "enabled"}}
+	if !reflect.DeepEqual(d.AddOnChanges, expectedAddOns) {
+		t.Errorf("expected add-on changes %v, but got %v", expectedAddOns, d.AddOnChanges)
+	}
+	for _, c := range []string{"kubelet", "ingress", "node labels", "calico"} {
+		if !contains(c, d.AffectedComponents) {
+			t.Errorf("expected component %s to be affected, but got %v", c, d.AffectedComponents)
+		}
+	}
+	if len(d.Warnings) != 1 {
+		t.Errorf("expected a warning about the removed node, but got %v", d.Warnings)
+	}
+}
+
+func TestDiffPlansOverridesAndAddOns(t *testing.T) {
+	oldPlan := diffTestPlan()
+	oldPlan.Cluster.APIServerOptions.Overrides = map[string]string{"v": "2", "runtime-config": "foo"}
+	newPlan := diffTestPlan()
+	newPlan.Cluster.APIServerOptions.Overrides = map[string]string{"v": "3"}
+	newPlan.AddOns.Dashboard = &Dashboard{Disable: true}
+	newPlan.AddOns.HeapsterMonitoring = &HeapsterMonitoring{}
+
+	d, err := DiffPlans(oldPlan, newPlan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedOverrides := []SettingChange{
+		{Setting: "kube_apiserver.runtime-config", Old: "foo"},
+		{Setting: "kube_apiserver.v", Old: "2", New: "3"},
+	}
+	if !reflect.DeepEqual(d.OverrideChanges, expectedOverrides) {
+		t.Errorf("expected override changes %v, but got %v", expectedOverrides, d.OverrideChanges)
+	}
+	expectedAddOns := []SettingChange{
+		{Setting: "heapster", Old: "disabled", New: "enabled"},
+		{Setting: "dashboard", Old: "enabled", New: "disabled"},
+	}
+	if !reflect.DeepEqual(d.AddOnChanges, expectedAddOns) {
+		t.Errorf("expected add-on changes %v, but got %v", expectedAddOns, d.AddOnChanges)
+	}
+	expectedComponents := []string{"heapster", "kube-apiserver"}
+	if !reflect.DeepEqual(d.AffectedComponents, expectedComponents) {
+		t.Errorf("expected affected components %v, but got %v", expectedComponents, d.AffectedComponents)
+	}
+	expectedServices := []string{"kube-apiserver"}
+	if !reflect.DeepEqual(d.RestartedServices, expectedServices) {
+		t.Errorf("expected restarted services %v, but got %v", expectedServices, d.RestartedServices)
+	}
+}
+
+func TestDiffPlansStorageRemoved(t *testing.T) {
+	oldPlan := diffTestPlan()
+	oldPlan.Storage.Nodes = []Node{{Host: "worker01", IP: "10.0.3.1"}}
+	newPlan := diffTestPlan()
+
+	d, err := DiffPlans(oldPlan, newPlan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedAddOns := []SettingChange{{Setting: "storage", Old: "enabled", New: "disabled"}}
+	if !reflect.DeepEqual(d.AddOnChanges, expectedAddOns) {
+		t.Errorf("expected add-on changes %v, but got %v", expectedAddOns, d.AddOnChanges)
+	}
+	if len(d.Warnings) == 0 {
+		t.Errorf("expected a warning about the disabled storage")
+	}
+}
+
+func TestDiffPlansCertificateSANs(t *testing.T) {
+	oldPlan := diffTestPlan()
+	newPlan := diffTestPlan()
+	newPlan.Master.LoadBalancedFQDN = "api.example.com"
+
+	d, err := DiffPlans(oldPlan, newPlan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []CertificateChange{{
+		Certificate: "master01-apiserver",
+		AddedSANs:   []string{"api.example.com"},
+		RemovedSANs: []string{"master.example.com"},
+	}}
+	if !reflect.DeepEqual(d.CertificateChanges, expected) {
+		t.Errorf("expected certificate changes %v, but got %v", expected, d.CertificateChanges)
+	}
+	if !reflect.DeepEqual(d.RestartedServices, []string{"kube-apiserver"}) {
+		t.Errorf("expected kube-apiserver to be restarted, but got %v", d.RestartedServices)
+	}
+}
+
+func TestLastRecordedPlan(t *testing.T) {
+	runsDir := mustGetTempDir(t)
+	runs := []string{
+		filepath.Join("apply", "2017-01-01-00-00-00"),
+		filepath.Join("add-worker", "2017-01-02-00-00-00"),
+		filepath.Join("smoketest", "2017-01-01-10-00-00"),
+	}
+	for _, r := range runs {
+		dir := filepath.Join(runsDir, r)
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			t.Fatalf("error creating run directory: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "kismatic-cluster.yaml"), []byte{}, 0644); err != nil {
+			t.Fatalf("error writing plan: %v", err)
+		}
+	}
+	// runs without a recorded plan are ignored
+	if err := os.MkdirAll(filepath.Join(runsDir, "apply", "2017-01-03-00-00-00"), 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	// directories that are not named after the time of the run are ignored
+	dir := filepath.Join(runsDir, "apply", "latest")
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "kismatic-cluster.yaml"), []byte{}, 0644); err != nil {
+		t.Fatalf("error writing plan: %v", err)
+	}
+
+	plan, err := LastRecordedPlan(runsDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := filepath.Join(runsDir, "add-worker", "2017-01-02-00-00-00", "kismatic-cluster.yaml")
+	if plan != expected {
+		t.Errorf("expected %q, but got %q", expected, plan)
+	}
+
+	plan, err = LastRecordedPlan(filepath.Join(runsDir, "doesnotexist"))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if plan != "" {
+		t.Errorf("expected no plan, but got %q", plan)
+	}
+}
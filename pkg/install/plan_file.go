@@ -0,0 +1,340 @@
+package install
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// PlanFileError is an error in the plan file, located at the field it relates to
+type PlanFileError struct {
+	// Field is the path of the field in the plan file, such as "master.nodes[0].ip"
+	Field string
+	// Line and Column of the field in the plan file. They are zero when the
+	// field, or any of its parents, is not in the file.
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *PlanFileError) Error() string {
+	switch {
+	case e.Line == 0 && e.Field == "":
+		return e.Err.Error()
+	case e.Line == 0:
+		return fmt.Sprintf("%s: %v", e.Field, e.Err)
+	case e.Field == "":
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %d (%s): %v", e.Line, e.Column, e.Field, e.Err)
+}
+
+// ValidatePlanFile runs strict validation against the plan file. Fields that
+// are not part of the plan, and option overrides that are not valid option
+// names, are rejected, and the plan is validated as in ValidatePlan. Each error
+// is located at the line and column of the field in the file it relates to,
+// when possible.
+func ValidatePlanFile(file string) (bool, []error) {
+	d, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false, []error{fmt.Errorf("could not read file: %v", err)}
+	}
+	positions, err := planKeyPositions(d)
+	if err != nil {
+		return false, []error{fmt.Errorf("failed to unmarshal plan: %v", err)}
+	}
+	var errs []error
+	p := &Plan{}
+	if err = yaml.UnmarshalStrict(d, p); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return false, []error{fmt.Errorf("failed to unmarshal plan: %v", err)}
+		}
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, locateDecodingError(positions, msg))
+		}
+	}
+	for _, e := range overrideErrors(p) {
+		errs = append(errs, locatePlanError(positions, e))
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, b := errs[i].(*PlanFileError), errs[j].(*PlanFileError)
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Field < b.Field
+	})
+	fp := &FilePlanner{File: file}
+	if p, err = fp.Read(); err != nil {
+		return false, append(errs, err)
+	}
+	_, validationErrs := ValidatePlan(p)
+	for _, e := range validationErrs {
+		errs = append(errs, locatePlanError(positions, e))
+	}
+	if len(errs) > 0 {
+		return false, errs
+	}
+	return true, nil
+}
+
+// locatePlanError returns a PlanFileError for errors that relate to a field,
+// positioned at the field. If the field is not in the file, the error is
+// positioned at the closest parent that is.
+func locatePlanError(positions map[string]planPosition, err error) error {
+	fe, ok := err.(*fieldError)
+	if !ok {
+		return err
+	}
+	pfe := &PlanFileError{Field: fe.field, Err: fe.err}
+	for f := fe.field; f != ""; f = parentField(f) {
+		if pos, ok := positions[f]; ok {
+			pfe.Line = pos.line
+			pfe.Column = pos.column
+			break
+		}
+	}
+	return pfe
+}
+
+// returns the parent of the field, or the empty string for top-level fields
+func parentField(field string) string {
+	i := strings.LastIndexAny(field, ".[")
+	if i < 0 {
+		return ""
+	}
+	return field[:i]
+}
+
+var (
+	decodingErrorRegexp = regexp.MustCompile(`^line (\d+): (.*)$`)
+	unknownFieldRegexp  = regexp.MustCompile(`^field (.+) not found in type (\S+)$`)
+)
+
+// locateDecodingError returns a PlanFileError for an error reported by the
+// strict yaml decoder, positioned at the key of the line it relates to.
+func locateDecodingError(positions map[string]planPosition, msg string) error {
+	m := decodingErrorRegexp.FindStringSubmatch(msg)
+	if m == nil {
+		return &PlanFileError{Err: errors.New(msg)}
+	}
+	line, _ := strconv.Atoi(m[1])
+	pfe := &PlanFileError{Line: line, Err: errors.New(m[2])}
+	key := ""
+	if u := unknownFieldRegexp.FindStringSubmatch(m[2]); u != nil {
+		key = u[1]
+		pfe.Err = fmt.Errorf("field %q is not part of the plan", key)
+		if s := closestField(key, planFieldNames(u[2])); s != "" {
+			pfe.Err = fmt.Errorf("field %q is not part of the plan, did you mean %q?", key, s)
+		}
+	}
+	// the error relates to the key on the line, or to the given key
+	for field, pos := range positions {
+		if pos.line != line || (key != "" && field != key && !strings.HasSuffix(field, "."+key)) {
+			continue
+		}
+		if pfe.Field == "" || len(field) > len(pfe.Field) {
+			pfe.Field = field
+			pfe.Column = pos.column
+		}
+	}
+	return pfe
+}
+
+// validOptionName matches the names of the options of the Kubernetes
+// components, which are given without the leading dashes
+var validOptionName = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// overrideErrors returns an error for each option override that is not a
+// valid option name
+func overrideErrors(p *Plan) []error {
+	overrides := map[string]map[string]string{
+		"cluster.kube_apiserver.option_overrides":          p.Cluster.APIServerOptions.Overrides,
+		"cluster.kube_controller_manager.option_overrides": p.Cluster.KubeControllerManagerOptions.Overrides,
+		"cluster.kube_scheduler.option_overrides":          p.Cluster.KubeSchedulerOptions.Overrides,
+		"cluster.kube_proxy.option_overrides":              p.Cluster.KubeProxyOptions.Overrides,
+		"cluster.kubelet.option_overrides":                 p.Cluster.KubeletOptions.Overrides,
+	}
+	groups := []struct {
+		field string
+		nodes []Node
+	}{
+		{"etcd", p.Etcd.Nodes},
+		{"master", p.Master.Nodes},
+		{"worker", p.Worker.Nodes},
+		{"ingress", p.Ingress.Nodes},
+		{"storage", p.Storage.Nodes},
+	}
+	for _, g := range groups {
+		for i, n := range g.nodes {
+			overrides[fmt.Sprintf("%s.nodes[%d].kubelet.option_overrides", g.field, i)] = n.KubeletOptions.Overrides
+		}
+	}
+	var errs []error
+	for field, opts := range overrides {
+		for name := range opts {
+			if validOptionName.MatchString(name) {
+				continue
+			}
+			err := fmt.Errorf("%q is not a valid option name", name)
+			if fixed := strings.Replace(strings.TrimLeft(name, "-"), "_", "-", -1); validOptionName.MatchString(fixed) {
+				err = fmt.Errorf("%q is not a valid option name, did you mean %q?", name, fixed)
+			}
+			errs = append(errs, newFieldError(joinField(field, name), err))
+		}
+	}
+	return errs
+}
+
+// planFieldNames returns the yaml names of the fields of the type of the
+// plan with the given name, such as "install.Cluster"
+func planFieldNames(typeName string) []string {
+	t := findStructType(reflect.TypeOf(Plan{}), typeName, map[reflect.Type]bool{})
+	if t == nil {
+		return nil
+	}
+	return yamlFieldNames(t)
+}
+
+// findStructType returns the struct type with the given name that t is made of
+func findStructType(t reflect.Type, name string, seen map[reflect.Type]bool) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	if t.String() == name {
+		return t
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if found := findStructType(t.Field(i).Type, name, seen); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// yamlFieldNames returns the names that the fields of the struct type have in yaml
+func yamlFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		if contains("inline", opts[1:]) {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			names = append(names, yamlFieldNames(ft)...)
+			continue
+		}
+		name := opts[0]
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// closestField returns the name of the field that is the closest to the given
+// key, if the key looks like a misspelling of it.
+func closestField(key string, names []string) string {
+	closest, min := "", len(key)/3+1
+	for _, name := range names {
+		if d := editDistance(key, name); d < min || (d == min && closest != "" && name < closest) {
+			closest, min = name, d
+		}
+	}
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between the two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(n int, ns ...int) int {
+	for _, x := range ns {
+		if x < n {
+			n = x
+		}
+	}
+	return n
+}
+
+func joinField(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+type planPosition struct {
+	line   int
+	column int
+}
+
+// planKeyPositions returns the line and column of each key in the yaml
+// document, keyed by the path of the key
+func planKeyPositions(d []byte) (map[string]planPosition, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(d, &doc); err != nil {
+		return nil, err
+	}
+	positions := map[string]planPosition{}
+	var index func(n *yamlv3.Node, path string)
+	index = func(n *yamlv3.Node, path string) {
+		switch n.Kind {
+		case yamlv3.DocumentNode:
+			for _, c := range n.Content {
+				index(c, path)
+			}
+		case yamlv3.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key := joinField(path, n.Content[i].Value)
+				positions[key] = planPosition{line: n.Content[i].Line, column: n.Content[i].Column}
+				index(n.Content[i+1], key)
+			}
+		case yamlv3.SequenceNode:
+			for i, c := range n.Content {
+				index(c, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+	index(&doc, "")
+	return positions, nil
+}
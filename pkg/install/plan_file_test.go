@@ -0,0 +1,208 @@
+package install
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanKeyPositions(t *testing.T) {
+	d := `# comment
+cluster:
+  name: test
+  networking:
+    pod_cidr_block: 172.16.0.0/16 # the pod network
+  "quoted": x
+etcd:
+  expected_count: 2
+  nodes:
+  - host: etcd01
+    labels:
+      foo: bar
+  -   host: etcd02
+      ip: 10.0.0.2
+master:
+  nodes:
+    - host: master01
+      script: |
+        not_a_key: value
+    - host: master02
+worker: {expected_count: 1}
+`
+	expected := map[string]planPosition{
+		"cluster":                           {2, 1},
+		"cluster.name":                      {3, 3},
+		"cluster.networking":                {4, 3},
+		"cluster.networking.pod_cidr_block": {5, 5},
+		"cluster.quoted":                    {6, 3},
+		"etcd":                              {7, 1},
+		"etcd.expected_count":               {8, 3},
+		"etcd.nodes":                        {9, 3},
+		"etcd.nodes[0].host":                {10, 5},
+		"etcd.nodes[0].labels":              {11, 5},
+		"etcd.nodes[0].labels.foo":          {12, 7},
+		"etcd.nodes[1].host":                {13, 7},
+		"etcd.nodes[1].ip":                  {14, 7},
+		"master":                            {15, 1},
+		"master.nodes":                      {16, 3},
+		"master.nodes[0].host":              {17, 7},
+		"master.nodes[0].script":            {18, 7},
+		"master.nodes[1].host":              {20, 7},
+		"worker":                            {21, 1},
+		"worker.expected_count":             {21, 10},
+	}
+	positions, err := planKeyPositions([]byte(d))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(positions, expected) {
+		t.Errorf("expected positions\n%v\nbut got\n%v", expected, positions)
+	}
+}
+
+func writeTestPlanFile(t *testing.T, replacer *strings.Replacer) string {
+	dir, err := ioutil.TempDir("", "plan-file-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	file := filepath.Join(dir, "kismatic-cluster.yaml")
+	p := validPlan
+	fp := &FilePlanner{File: file}
+	if err = fp.Write(&p); err != nil {
+		t.Fatalf("error writing plan: %v", err)
+	}
+	if replacer == nil {
+		return file
+	}
+	d, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("error reading plan: %v", err)
+	}
+	if err = ioutil.WriteFile(file, []byte(replacer.Replace(string(d))), 0644); err != nil {
+		t.Fatalf("error writing plan: %v", err)
+	}
+	return file
+}
+
+func TestValidatePlanFileValidPlan(t *testing.T) {
+	file := writeTestPlanFile(t, nil)
+	ok, errs := ValidatePlanFile(file)
+	if !ok {
+		t.Errorf("expected the plan file to be valid, but got errors: %v", errs)
+	}
+}
+
+func TestValidatePlanFileUnknownFields(t *testing.T) {
+	file := writeTestPlanFile(t, strings.NewReplacer("  kube_apiserver:", "  kube_apiservr:", "load_balanced_fqdn:", "load_balanced_fdqn:"))
+	ok, errs := ValidatePlanFile(file)
+	if ok {
+		t.Fatalf("expected the plan file to be invalid")
+	}
+	var unknown []*PlanFileError
+	for _, err := range errs {
+		if pfe, ok := err.(*PlanFileError); ok && strings.Contains(pfe.Err.Error(), "is not part of the plan") {
+			unknown = append(unknown, pfe)
+		}
+	}
+	if len(unknown) != 2 {
+		t.Fatalf("expected 2 unknown fields, but got %v", errs)
+	}
+	if unknown[0].Field != "cluster.kube_apiservr" || unknown[1].Field != "master.load_balanced_fdqn" {
+		t.Errorf("unexpected unknown fields %q and %q", unknown[0].Field, unknown[1].Field)
+	}
+	if !strings.Contains(unknown[0].Error(), `did you mean "kube_apiserver"?`) {
+		t.Errorf("expected a suggestion, but got %q", unknown[0].Error())
+	}
+	assertPlanFilePosition(t, file, unknown[0], "  kube_apiservr:")
+	assertPlanFilePosition(t, file, unknown[1], "  load_balanced_fdqn:")
+}
+
+func TestValidatePlanFileValidationErrorsAreLocated(t *testing.T) {
+	file := writeTestPlanFile(t, strings.NewReplacer("192.168.205.12", "192.168.205"))
+	ok, errs := ValidatePlanFile(file)
+	if ok {
+		t.Fatalf("expected the plan file to be invalid")
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, but got %v", errs)
+	}
+	pfe, ok := errs[0].(*PlanFileError)
+	if !ok {
+		t.Fatalf("expected a plan file error, but got %v", errs[0])
+	}
+	if pfe.Field != "worker.nodes[0].ip" {
+		t.Errorf("expected the error to relate to worker.nodes[0].ip, but got %q", pfe.Field)
+	}
+	if pfe.Err.Error() != "Worker nodes: Node #1: Invalid IP provided" {
+		t.Errorf("unexpected error message %q", pfe.Err.Error())
+	}
+	assertPlanFilePosition(t, file, pfe, "ip: 192.168.205\n")
+}
+
+// asserts that the error is located at the first line of the file that contains the text
+func assertPlanFilePosition(t *testing.T, file string, pfe *PlanFileError, text string) {
+	d, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("error reading plan: %v", err)
+	}
+	i := strings.Index(string(d), text)
+	if i < 0 {
+		t.Fatalf("%q is not in the plan file", text)
+	}
+	line := strings.Count(string(d[:i]), "\n") + 1
+	lineStart := strings.LastIndex(string(d[:i]), "\n") + 1
+	column := i - lineStart + len(text) - len(strings.TrimLeft(text, " ")) + 1
+	if pfe.Line != line || pfe.Column != column {
+		t.Errorf("expected %s to be located at line %d, column %d, but got line %d, column %d", pfe.Field, line, column, pfe.Line, pfe.Column)
+	}
+}
+
+func TestValidatePlanFileOverrideNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plan-file-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	file := filepath.Join(dir, "kismatic-cluster.yaml")
+	p := validPlan
+	p.Cluster.APIServerOptions.Overrides = map[string]string{"--runtime_config": "batch/v2alpha1", "v": "3"}
+	fp := &FilePlanner{File: file}
+	if err = fp.Write(&p); err != nil {
+		t.Fatalf("error writing plan: %v", err)
+	}
+	ok, errs := ValidatePlanFile(file)
+	if ok {
+		t.Fatalf("expected the plan file to be invalid")
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected one error, but got %v", errs)
+	}
+	pfe, ok := errs[0].(*PlanFileError)
+	if !ok {
+		t.Fatalf("expected a plan file error, but got %v", errs[0])
+	}
+	if pfe.Field != "cluster.kube_apiserver.option_overrides.--runtime_config" {
+		t.Errorf("unexpected field %q", pfe.Field)
+	}
+	if !strings.Contains(pfe.Error(), `did you mean "runtime-config"?`) {
+		t.Errorf("expected a suggestion, but got %q", pfe.Error())
+	}
+	assertPlanFilePosition(t, file, pfe, "      --runtime_config:")
+}
+
+func TestValidatePlanFileTypeErrorsAreLocated(t *testing.T) {
+	file := writeTestPlanFile(t, strings.NewReplacer("  expected_count: 1\n", "  expected_count: one\n"))
+	ok, errs := ValidatePlanFile(file)
+	if ok {
+		t.Fatalf("expected the plan file to be invalid")
+	}
+	pfe, ok := errs[0].(*PlanFileError)
+	if !ok {
+		t.Fatalf("expected a plan file error, but got %v", errs[0])
+	}
+	if !strings.HasSuffix(pfe.Field, ".expected_count") || !strings.Contains(pfe.Error(), "cannot unmarshal") {
+		t.Errorf("expected the error to relate to the expected count, but got %q", pfe.Error())
+	}
+	assertPlanFilePosition(t, file, pfe, "  expected_count: one")
+}
@@ -2,10 +2,13 @@ package install
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net"
+	"path/filepath"
 	"strings"
 
 	"github.com/apprenda/kismatic/pkg/ssh"
+	yaml "gopkg.in/yaml.v2"
 )
 
 const (
@@ -68,6 +71,8 @@ type Plan struct {
 	Storage OptionalNodeGroup
 	// NFS volumes of the cluster.
 	NFS NFS
+	// Configuration of the pre-flight checks run by the inspector
+	Inspector *Inspector `yaml:"inspector,omitempty"`
 }
 
 // Cluster describes a Kubernetes cluster
@@ -500,6 +505,25 @@ func (node Node) HashCode() string {
 	return fmt.Sprint(node.Host, node.IP, node.InternalIP)
 }
 
+// Inspector configures the pre-flight checks that the inspector runs on
+// the nodes, in addition to the rules that are built into it.
+type Inspector struct {
+	// Paths to files that contain inspector rules to run in addition to the
+	// default rules. The files use the format of the inspector rules file.
+	// Relative paths are relative to the directory of the plan file.
+	RulesFiles []string `yaml:"rules_files,omitempty"`
+	// Inspector rules to run in addition to the default rules, using the
+	// format of the inspector rules file.
+	Rules []InspectorRule `yaml:"rules,omitempty"`
+	// planDir is the directory of the plan file, which relative rules files
+	// are relative to
+	planDir string
+}
+
+// InspectorRule is a rule in the format of the inspector rules file, such as
+// the FileContentMatches rule with its file and contentRegex fields.
+type InspectorRule map[string]interface{}
+
 type NFS struct {
 	// List of NFS volumes that should be attached to the cluster during
 	// the installation.
@@ -712,3 +736,93 @@ func (p Plan) NetworkConfigured() bool {
 	// CNI disabled or "custom" return false
 	return p.AddOns.CNI == nil || (!p.AddOns.CNI.Disable && p.AddOns.CNI.Provider != "custom")
 }
+
+// inspectorRuleSets returns the inspector rule sets, in YAML, that are run
+// in addition to the default rules. When a private registry is configured, a
+// rule verifies that the nodes can reach it, and it is followed by the rules
+// in the inspector section of the plan.
+func (p *Plan) inspectorRuleSets() ([][]byte, error) {
+	sets := [][]byte{}
+	if p.PrivateRegistryProvided() {
+		r, err := p.registryInspectorRule()
+		if err != nil {
+			return nil, err
+		}
+		d, err := yaml.Marshal([]InspectorRule{r})
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling inspector rules: %v", err)
+		}
+		sets = append(sets, d)
+	}
+	if p.Inspector != nil {
+		s, err := p.Inspector.ruleSets()
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, s...)
+	}
+	return sets, nil
+}
+
+// registryInspectorRule returns the rule that verifies that the nodes can
+// reach the private registry, through the proxy if one is configured. The
+// registry's API responds with 401 when authentication is required, which
+// does not depend on the credentials in the plan, so both 200 and 401 are
+// accepted.
+func (p *Plan) registryInspectorRule() (InspectorRule, error) {
+	noProxy := p.AllAddresses()
+	if p.Cluster.Networking.NoProxy != "" {
+		noProxy = noProxy + "," + p.Cluster.Networking.NoProxy
+	}
+	r := InspectorRule{
+		"kind":                "HTTPEndpointReachable",
+		"url":                 fmt.Sprintf("https://%s/v2/", p.DockerRegistry.Server),
+		"httpProxy":           p.Cluster.Networking.HTTPProxy,
+		"httpsProxy":          p.Cluster.Networking.HTTPSProxy,
+		"noProxy":             noProxy,
+		"expectedStatusCodes": []int{200, 401},
+		"timeout":             "10s",
+	}
+	if p.DockerRegistry.CAPath != "" {
+		ca, err := ioutil.ReadFile(p.DockerRegistry.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the CA of the docker registry: %v", err)
+		}
+		r["caCert"] = string(ca)
+	}
+	return r, nil
+}
+
+// rulesFilePaths returns the paths of the rules files, with relative paths
+// resolved against the directory of the plan file
+func (i Inspector) rulesFilePaths() []string {
+	paths := make([]string, 0, len(i.RulesFiles))
+	for _, f := range i.RulesFiles {
+		if !filepath.IsAbs(f) && i.planDir != "" {
+			f = filepath.Join(i.planDir, f)
+		}
+		paths = append(paths, f)
+	}
+	return paths
+}
+
+// ruleSets returns the inspector rule sets in the plan, in YAML. The rules
+// files come first, followed by the inline rules.
+func (i Inspector) ruleSets() ([][]byte, error) {
+	sets := [][]byte{}
+	for _, f := range i.rulesFilePaths() {
+		d, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("error reading inspector rules from %q: %v", f, err)
+		}
+		sets = append(sets, d)
+	}
+	if len(i.Rules) > 0 {
+		d, err := yaml.Marshal(i.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling inspector rules: %v", err)
+		}
+		sets = append(sets, d)
+	}
+	return sets, nil
+}
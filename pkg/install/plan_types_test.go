@@ -1,9 +1,14 @@
 package install
 
 import (
+	"fmt"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/apprenda/kismatic/pkg/inspector/rule"
 )
 
 func TestCanReadAPIServerOverrides(t *testing.T) {
@@ -13,3 +18,103 @@ func TestCanReadAPIServerOverrides(t *testing.T) {
 
 	assertEqual(t, p.Cluster.APIServerOptions.Overrides["runtime-config"], "beta/v2api=true,alpha/v1api=true")
 }
+
+func TestInspectorRuleSetsIncludeRegistryRule(t *testing.T) {
+	p := validPlan
+	p.DockerRegistry = DockerRegistry{Server: "registry.example.com:5000", Username: "admin"}
+	p.Cluster.Networking.HTTPSProxy = "http://proxy.example.com:3128"
+	p.Inspector = &Inspector{
+		Rules: []InspectorRule{
+			{"kind": "ExecutableInPath", "executable": "iptables"},
+		},
+	}
+	sets, err := p.inspectorRuleSets()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	merged, err := rule.MergeRulesYAML(sets...)
+	if err != nil {
+		t.Fatalf("unexpected error merging rules: %v", err)
+	}
+	rules, err := rule.ParseRulesYAML(merged)
+	if err != nil {
+		t.Fatalf("the rules are not valid: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, but got %d", len(rules))
+	}
+	r, ok := rules[0].(rule.HTTPEndpointReachable)
+	if !ok {
+		t.Fatalf("expected the first rule to be HTTPEndpointReachable, but got %T", rules[0])
+	}
+	assertEqual(t, r.URL, "https://registry.example.com:5000/v2/")
+	assertEqual(t, r.HTTPSProxy, "http://proxy.example.com:3128")
+	assertEqual(t, r.ExpectedStatusCodes, []int{200, 401})
+}
+
+func TestInspectorRuleSetsWithoutRegistry(t *testing.T) {
+	p := validPlan
+	p.DockerRegistry = DockerRegistry{}
+	sets, err := p.inspectorRuleSets()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sets) != 0 {
+		t.Errorf("expected no rule sets, but got %d", len(sets))
+	}
+}
+
+func TestInspectorRulesFilesRelativeToPlan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspector-rules-files")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	rules := "- kind: ExecutableInPath\n  executable: iptables\n"
+	if err = ioutil.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(rules), 0644); err != nil {
+		t.Fatalf("error writing rules file: %v", err)
+	}
+	p := validPlan
+	p.Inspector = &Inspector{RulesFiles: []string{"rules.yaml"}}
+	fp := &FilePlanner{File: filepath.Join(dir, "kismatic-cluster.yaml")}
+	if err = fp.Write(&p); err != nil {
+		t.Fatalf("error writing plan: %v", err)
+	}
+
+	read, err := fp.Read()
+	if err != nil {
+		t.Fatalf("error reading plan: %v", err)
+	}
+	if valid, errs := read.Inspector.validate(); !valid {
+		t.Errorf("expected the rules file to be found relative to the plan, but got %v", errs)
+	}
+	sets, err := read.Inspector.ruleSets()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sets) != 1 || string(sets[0]) != rules {
+		t.Errorf("expected the rules of the rules file, but got %q", sets)
+	}
+	// the plan keeps the path as written by the user
+	assertEqual(t, read.Inspector.RulesFiles, []string{"rules.yaml"})
+}
+
+func TestInspectorRulesAreNotCopiedToCertificatesDirectory(t *testing.T) {
+	vars, err := readAnsibleVars("../../ansible/group_vars/all.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	certsDir, err := resolveAnsibleVars("{{ kismatic_inspector_certificates_dir }}", vars)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"kismatic_inspector_rules", "kismatic_inspector_upgrade_rules"} {
+		file, err := resolveAnsibleVars(fmt.Sprint(vars[name]), vars)
+		if err != nil {
+			t.Fatalf("unexpected error resolving %s: %v", name, err)
+		}
+		if filepath.Dir(file) == certsDir {
+			t.Errorf("expected %s to be outside of the inspector certificates directory, but got %q", name, file)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package install
+
+import (
+	"fmt"
+
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+// RemoveWorker drains the worker node from the cluster described in the plan,
+// deletes it from the API server and stops the cluster services running on it.
+// If successful, the updated plan is returned.
+func (ae *ansibleExecutor) RemoveWorker(originalPlan *Plan, workerName string) (*Plan, error) {
+	worker, err := checkRemoveWorkerPrereqs(*originalPlan, workerName)
+	if err != nil {
+		return nil, err
+	}
+	updatedPlan := removeWorkerFromPlan(*originalPlan, *worker)
+
+	// The node has to be in the inventory while it is being removed
+	inventory := buildInventoryFromPlan(originalPlan)
+	cc, err := ae.buildClusterCatalog(originalPlan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ansible vars: %v", err)
+	}
+	cc.WorkerNode = worker.Host
+	util.PrintHeader(ae.stdout, "Removing Worker Node from Cluster", '=')
+	t := task{
+		name:           "remove-worker",
+		playbook:       "remove-worker.yaml",
+		plan:           *originalPlan,
+		inventory:      inventory,
+		clusterCatalog: *cc,
+		explainer:      ae.defaultExplainer(),
+		limit:          []string{worker.Host},
+	}
+	if err = ae.execute(t); err != nil {
+		return nil, fmt.Errorf("error running playbook: %v", err)
+	}
+
+	// Remove the worker's access to any storage volumes defined
+	if len(originalPlan.Storage.Nodes) > 0 {
+		util.PrintHeader(ae.stdout, "Updating Allowed IPs On Storage Volumes", '=')
+		cc.RemoveWorker = true
+		t = task{
+			name:           "remove-worker-update-volumes",
+			playbook:       "_volume-update-allowed.yaml",
+			plan:           *originalPlan,
+			inventory:      inventory,
+			clusterCatalog: *cc,
+			explainer:      ae.defaultExplainer(),
+		}
+		if err = ae.execute(t); err != nil {
+			return nil, fmt.Errorf("error removing worker from volume allow list: %v", err)
+		}
+	}
+	return &updatedPlan, nil
+}
+
+func removeWorkerFromPlan(plan Plan, worker Node) Plan {
+	var nodes []Node
+	for _, n := range plan.Worker.Nodes {
+		if !n.Equal(worker) {
+			nodes = append(nodes, n)
+		}
+	}
+	plan.Worker.Nodes = nodes
+	plan.Worker.ExpectedCount = len(nodes)
+	return plan
+}
+
+// returns the worker that is going to be removed, or an error if it can't be
+// removed safely
+func checkRemoveWorkerPrereqs(plan Plan, workerName string) (*Node, error) {
+	var worker *Node
+	for i, n := range plan.Worker.Nodes {
+		if n.Host == workerName {
+			worker = &plan.Worker.Nodes[i]
+			break
+		}
+	}
+	if worker == nil {
+		return nil, fmt.Errorf("according to the plan file, %q is not a worker node", workerName)
+	}
+	if len(plan.Worker.Nodes) == 1 {
+		return nil, fmt.Errorf("%q is the only worker node in the cluster", workerName)
+	}
+	// Stopping the cluster services would affect the other roles of the node
+	if roles := plan.GetRolesForIP(worker.IP); len(roles) > 1 {
+		return nil, fmt.Errorf("%q cannot be removed, as it is also used for %v", workerName, roles)
+	}
+	return worker, nil
+}
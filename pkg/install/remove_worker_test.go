@@ -0,0 +1,126 @@
+package install
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+func removeWorkerTestPlan() *Plan {
+	return &Plan{
+		Master: MasterNodeGroup{
+			Nodes: []Node{{Host: "master", IP: "10.10.2.20"}},
+		},
+		Worker: NodeGroup{
+			ExpectedCount: 2,
+			Nodes: []Node{
+				{Host: "worker1", IP: "10.10.2.21"},
+				{Host: "worker2", IP: "10.10.2.22"},
+			},
+		},
+		Cluster: Cluster{
+			Networking: NetworkConfig{
+				ServiceCIDRBlock: "10.0.0.0/16",
+			},
+		},
+	}
+}
+
+func TestRemoveWorkerPlanIsUpdated(t *testing.T) {
+	fakeRunner := fakeRunner{}
+	e := ansibleExecutor{
+		options:             ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:              ioutil.Discard,
+		consoleOutputFormat: ansible.RawFormat,
+		runnerExplainerFactory: func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+			return &fakeRunner, &explain.AnsibleEventStreamExplainer{}, nil
+		},
+		certsDir: mustGetTempDir(t),
+	}
+	originalPlan := removeWorkerTestPlan()
+	updatedPlan, err := e.RemoveWorker(originalPlan, "worker1")
+	if err != nil {
+		t.Fatalf("unexpected error while removing worker: %v", err)
+	}
+	if updatedPlan.Worker.ExpectedCount != 1 {
+		t.Errorf("expected count was not decremented: %d", updatedPlan.Worker.ExpectedCount)
+	}
+	if len(updatedPlan.Worker.Nodes) != 1 || updatedPlan.Worker.Nodes[0].Host != "worker2" {
+		t.Errorf("worker was not removed from the plan: %v", updatedPlan.Worker.Nodes)
+	}
+	if len(originalPlan.Worker.Nodes) != 2 {
+		t.Errorf("original plan was modified")
+	}
+	if fakeRunner.incomingCatalog.WorkerNode != "worker1" {
+		t.Errorf("expected worker_node to be worker1, but got %q", fakeRunner.incomingCatalog.WorkerNode)
+	}
+	if fakeRunner.incomingCatalog.RemoveWorker {
+		t.Errorf("volume allow lists were updated, but there are no storage nodes")
+	}
+}
+
+func TestRemoveWorkerUpdatesVolumes(t *testing.T) {
+	fakeRunner := fakeRunner{}
+	e := ansibleExecutor{
+		options:             ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+		stdout:              ioutil.Discard,
+		consoleOutputFormat: ansible.RawFormat,
+		runnerExplainerFactory: func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+			return &fakeRunner, &explain.AnsibleEventStreamExplainer{}, nil
+		},
+		certsDir: mustGetTempDir(t),
+	}
+	plan := removeWorkerTestPlan()
+	plan.Storage.Nodes = []Node{{Host: "storage", IP: "10.10.2.23"}}
+	if _, err := e.RemoveWorker(plan, "worker2"); err != nil {
+		t.Fatalf("unexpected error while removing worker: %v", err)
+	}
+	if !fakeRunner.incomingCatalog.RemoveWorker {
+		t.Errorf("volume allow lists were not updated")
+	}
+}
+
+func TestRemoveWorkerPrereqs(t *testing.T) {
+	tests := []struct {
+		name   string
+		plan   func() *Plan
+		worker string
+	}{
+		{
+			name:   "worker not in plan",
+			plan:   removeWorkerTestPlan,
+			worker: "worker3",
+		},
+		{
+			name:   "master is not a worker",
+			plan:   removeWorkerTestPlan,
+			worker: "master",
+		},
+		{
+			name: "only worker",
+			plan: func() *Plan {
+				p := removeWorkerTestPlan()
+				p.Worker.Nodes = p.Worker.Nodes[:1]
+				return p
+			},
+			worker: "worker1",
+		},
+		{
+			name: "worker is also ingress",
+			plan: func() *Plan {
+				p := removeWorkerTestPlan()
+				p.Ingress.Nodes = []Node{p.Worker.Nodes[0]}
+				return p
+			},
+			worker: "worker1",
+		},
+	}
+	for _, test := range tests {
+		if _, err := checkRemoveWorkerPrereqs(*test.plan(), test.worker); err == nil {
+			t.Errorf("%s: expected an error, but didn't get one", test.name)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package install
+
+import (
+	"fmt"
+
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+// rollbackVersionFile is the version file of the snapshot that the upgrade
+// takes on each node before changing it
+const rollbackVersionFile = "/var/lib/kismatic/rollback/kismatic-version"
+
+// RollbackNodes restores the configuration and Kubernetes packages that the
+// nodes had before their last upgrade, using the snapshot that the upgrade took
+// on each node. The nodes are rolled back one at a time, in the same order in
+// which they are upgraded: etcd nodes first, then master nodes, and then the
+// rest of the nodes.
+func (ae *ansibleExecutor) RollbackNodes(plan Plan, nodes []ListableNode) error {
+	for _, n := range nodes {
+		if n.RollbackVersion == nil {
+			return fmt.Errorf("node %q cannot be rolled back, as it has not been upgraded", n.Node.Host)
+		}
+	}
+	// The etcd data is not part of the snapshot, so verify that none of the etcd
+	// nodes would start an older etcd on the data written by a newer one, before
+	// rolling back any of the nodes
+	etcdHosts := []string{}
+	for _, n := range nodes {
+		if util.Contains("etcd", n.Roles) {
+			etcdHosts = append(etcdHosts, n.Node.Host)
+		}
+	}
+	if len(etcdHosts) > 0 {
+		if err := ae.verifyEtcdRollback(plan, etcdHosts); err != nil {
+			return fmt.Errorf("etcd nodes cannot be rolled back: %v", err)
+		}
+	}
+	for _, node := range nodesInUpgradeOrder(nodes) {
+		if err := ae.rollbackNode(plan, node); err != nil {
+			return fmt.Errorf("error rolling back node %q: %v", node.Node.Host, err)
+		}
+	}
+	return nil
+}
+
+func (ae *ansibleExecutor) rollbackNode(plan Plan, node ListableNode) error {
+	cc, err := ae.buildClusterCatalog(&plan)
+	if err != nil {
+		return err
+	}
+	t := task{
+		name:           "rollback-nodes",
+		playbook:       "rollback-nodes.yaml",
+		inventory:      buildInventoryFromPlan(&plan),
+		clusterCatalog: *cc,
+		plan:           plan,
+		explainer:      ae.defaultExplainer(),
+		limit:          []string{node.Node.Host},
+	}
+	util.PrintHeader(ae.stdout, fmt.Sprintf("Roll Back Node: %s %s to v%s", node.Node.Host, node.Roles, node.RollbackVersion), '=')
+	return ae.execute(t)
+}
+
+// verifyEtcdRollback fails if the snapshot of any of the etcd nodes runs a
+// different version of etcd than the one running on the node
+func (ae *ansibleExecutor) verifyEtcdRollback(plan Plan, hosts []string) error {
+	cc, err := ae.buildClusterCatalog(&plan)
+	if err != nil {
+		return err
+	}
+	t := task{
+		name:           "rollback-check",
+		playbook:       "rollback-check.yaml",
+		inventory:      buildInventoryFromPlan(&plan),
+		clusterCatalog: *cc,
+		plan:           plan,
+		explainer:      ae.defaultExplainer(),
+		limit:          hosts,
+	}
+	util.PrintHeader(ae.stdout, "Verify Etcd Nodes Can Be Rolled Back", '=')
+	return ae.execute(t)
+}
+
+// nodesInUpgradeOrder returns the nodes in the order in which they are
+// upgraded: etcd nodes first, then master nodes, and then the rest of the nodes.
+// Nodes with multiple roles appear once, in the position of their first role.
+func nodesInUpgradeOrder(nodes []ListableNode) []ListableNode {
+	ordered := []ListableNode{}
+	added := map[string]bool{}
+	for _, role := range []string{"etcd", "master", ""} {
+		for _, n := range nodes {
+			if added[n.Node.IP] || (role != "" && !util.Contains(role, n.Roles)) {
+				continue
+			}
+			ordered = append(ordered, n)
+			added[n.Node.IP] = true
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,92 @@
+package install
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+	"github.com/blang/semver"
+)
+
+func TestNodesInUpgradeOrder(t *testing.T) {
+	nodes := []ListableNode{
+		{Node: Node{Host: "worker01", IP: "10.0.0.4"}, Roles: []string{"worker"}},
+		{Node: Node{Host: "master01", IP: "10.0.0.2"}, Roles: []string{"master"}},
+		{Node: Node{Host: "ingress01", IP: "10.0.0.5"}, Roles: []string{"ingress", "storage"}},
+		{Node: Node{Host: "etcd01", IP: "10.0.0.1"}, Roles: []string{"etcd", "master"}},
+		{Node: Node{Host: "etcd02", IP: "10.0.0.3"}, Roles: []string{"etcd"}},
+	}
+	expected := []string{"etcd01", "etcd02", "master01", "worker01", "ingress01"}
+	ordered := nodesInUpgradeOrder(nodes)
+	if len(ordered) != len(expected) {
+		t.Fatalf("expected %d nodes, but got %d", len(expected), len(ordered))
+	}
+	for i, n := range ordered {
+		if n.Node.Host != expected[i] {
+			t.Errorf("expected node %q in position %d, but got %q", expected[i], i, n.Node.Host)
+		}
+	}
+}
+
+func TestRollbackNodesWithoutSnapshot(t *testing.T) {
+	e := ansibleExecutor{}
+	nodes := []ListableNode{{Node: Node{Host: "worker01"}, Roles: []string{"worker"}}}
+	if err := e.RollbackNodes(Plan{}, nodes); err == nil {
+		t.Errorf("expected an error when rolling back a node that has not been upgraded")
+	}
+}
+
+func TestRollbackNodesVerifiesEtcdFirst(t *testing.T) {
+	version := semver.MustParse("1.5.0")
+	nodes := []ListableNode{
+		{Node: Node{Host: "worker01", IP: "10.0.0.2"}, Roles: []string{"worker"}, RollbackVersion: &version},
+		{Node: Node{Host: "etcd01", IP: "10.0.0.1"}, Roles: []string{"etcd"}, RollbackVersion: &version},
+	}
+	tests := []struct {
+		runnerErr         error
+		expectedPlaybooks []string
+	}{
+		{
+			expectedPlaybooks: []string{"rollback-check.yaml", "rollback-nodes.yaml", "rollback-nodes.yaml"},
+		},
+		{
+			runnerErr:         errors.New("etcd version differs"),
+			expectedPlaybooks: []string{"rollback-check.yaml"},
+		},
+	}
+	for i, test := range tests {
+		fakeRunner := fakeRunner{err: test.runnerErr}
+		e := ansibleExecutor{
+			options:             ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
+			stdout:              ioutil.Discard,
+			consoleOutputFormat: ansible.RawFormat,
+			runnerExplainerFactory: func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+				return &fakeRunner, &explain.AnsibleEventStreamExplainer{}, nil
+			},
+		}
+		plan := Plan{
+			Etcd:   NodeGroup{Nodes: []Node{nodes[1].Node}},
+			Master: MasterNodeGroup{Nodes: []Node{nodes[1].Node}},
+			Worker: NodeGroup{Nodes: []Node{nodes[0].Node}},
+			Cluster: Cluster{
+				Networking: NetworkConfig{
+					ServiceCIDRBlock: "10.0.0.0/16",
+				},
+			},
+		}
+		err := e.RollbackNodes(plan, nodes)
+		if (err != nil) != (test.runnerErr != nil) {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(fakeRunner.nodePlaybooks, test.expectedPlaybooks) {
+			t.Errorf("test %d: expected playbooks %v, but got %v", i, test.expectedPlaybooks, fakeRunner.nodePlaybooks)
+		}
+		if len(fakeRunner.limits) == 0 || !reflect.DeepEqual(fakeRunner.limits[0], []string{"etcd01"}) {
+			t.Errorf("test %d: expected the check to be limited to the etcd nodes, but got %v", i, fakeRunner.limits[0])
+		}
+	}
+}
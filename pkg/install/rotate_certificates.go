@@ -0,0 +1,121 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+const (
+	caCertFilename = "ca.pem"
+	// caBundleFilename is the bundle of the previous and the new CA, which
+	// the nodes trust while the CA is rotated
+	caBundleFilename = "ca-bundle.pem"
+)
+
+// RotateCertificates reissues the certificates of the cluster, and optionally
+// the Certificate Authority, and distributes them to the nodes. The existing
+// certificates are backed up before they are replaced. Components are restarted
+// in the following order, one node at a time:
+//   1. Etcd nodes
+//   2. Master nodes
+//   3. Kubelet and kube-proxy on all Kubernetes nodes
+//
+// When the CA is rotated, the components are restarted three times, so that
+// they always trust the certificates presented by their peers:
+//   1. A bundle of the previous and the new CA is distributed
+//   2. The certificates signed by the new CA are distributed, along with the bundle
+//   3. The new CA is distributed on its own
+//
+// The bundle is written to its own file, so that the CA certificate in the
+// generated assets directory always holds a single certificate.
+func (ae *ansibleExecutor) RotateCertificates(plan Plan, rotateCA bool) error {
+	util.PrintHeader(ae.stdout, "Rotating Certificates", '=')
+	backupDir := filepath.Join(ae.options.GeneratedAssetsDirectory, "keys-backup", time.Now().Format("2006-01-02-15-04-05"))
+	if err := copyCertificates(ae.certsDir, backupDir); err != nil {
+		return fmt.Errorf("error backing up existing certificates: %v", err)
+	}
+	util.PrettyPrintOk(ae.stdout, "Backed up existing certificates to %q", backupDir)
+
+	if !rotateCA {
+		ca, err := ae.pki.GetClusterCA()
+		if err != nil {
+			return err
+		}
+		if err = ae.pki.RotateClusterCertificates(&plan, ca); err != nil {
+			return fmt.Errorf("error reissuing certificates for the cluster: %v", err)
+		}
+		return ae.distributeCertificates(plan, "rotate-certificates", "Distributing Certificates", caCertFilename)
+	}
+
+	previousCA, err := ae.pki.GetClusterCA()
+	if err != nil {
+		return err
+	}
+	ca, err := ae.pki.RotateClusterCA(&plan)
+	if err != nil {
+		return fmt.Errorf("error generating new CA for the cluster: %v", err)
+	}
+	if err = writeCABundle(ae.certsDir, ca.Cert, previousCA.Cert); err != nil {
+		return err
+	}
+	if err = ae.distributeCertificates(plan, "rotate-ca-trust", "Distributing CA Trust Bundle", caBundleFilename); err != nil {
+		return fmt.Errorf("%v. The certificates backed up to %q can be used to restore the previous CA", err, backupDir)
+	}
+	if err = ae.pki.RotateClusterCertificates(&plan, ca); err != nil {
+		return fmt.Errorf("error reissuing certificates for the cluster: %v", err)
+	}
+	if err = ae.distributeCertificates(plan, "rotate-certificates", "Distributing Certificates", caBundleFilename); err != nil {
+		return fmt.Errorf("%v. The nodes trust both the previous and the new CA", err)
+	}
+	if err = ae.distributeCertificates(plan, "rotate-ca-untrust", "Removing Previous CA", caCertFilename); err != nil {
+		return fmt.Errorf("%v. The nodes trust both the previous and the new CA", err)
+	}
+	bundle := filepath.Join(ae.certsDir, caBundleFilename)
+	if err = os.Remove(bundle); err != nil {
+		return fmt.Errorf("error removing CA trust bundle %q: %v", bundle, err)
+	}
+	return nil
+}
+
+// distributeCertificates copies the certificates in the generated assets
+// directory to the nodes, and restarts the components that use them. The
+// nodes are configured to trust the certificates in the given CA file.
+func (ae *ansibleExecutor) distributeCertificates(plan Plan, name string, header string, caFile string) error {
+	cc, err := ae.buildClusterCatalog(&plan)
+	if err != nil {
+		return err
+	}
+	cc.TLSCAFile = caFile
+	t := task{
+		name:           name,
+		playbook:       "rotate-certificates.yaml",
+		inventory:      buildInventoryFromPlan(&plan),
+		clusterCatalog: *cc,
+		plan:           plan,
+		explainer:      ae.defaultExplainer(),
+	}
+	util.PrintHeader(ae.stdout, header, '=')
+	return ae.execute(t)
+}
+
+// writeCABundle writes the bundle of CA certificates that the nodes trust
+// while the CA is rotated
+func writeCABundle(certsDir string, certs ...[]byte) error {
+	var bundle []byte
+	for _, c := range certs {
+		bundle = append(bundle, c...)
+		if len(c) > 0 && c[len(c)-1] != '\n' {
+			bundle = append(bundle, '\n')
+		}
+	}
+	file := filepath.Join(certsDir, caBundleFilename)
+	if err := ioutil.WriteFile(file, bundle, 0644); err != nil {
+		return fmt.Errorf("error writing CA trust bundle %q: %v", file, err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package install
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+func TestRotateCertificates(t *testing.T) {
+	tests := []struct {
+		rotateCA          bool
+		expectedPlaybooks int
+	}{
+		{rotateCA: false, expectedPlaybooks: 1},
+		{rotateCA: true, expectedPlaybooks: 3},
+	}
+	for i, test := range tests {
+		certsDir := mustGetTempDir(t)
+		if err := ioutil.WriteFile(filepath.Join(certsDir, "admin.pem"), []byte("admin"), 0644); err != nil {
+			t.Fatalf("error writing cert: %v", err)
+		}
+		generatedDir := mustGetTempDir(t)
+		pki := &fakePKI{}
+		fakeRunner := fakeRunner{}
+		e := ansibleExecutor{
+			options:             ExecutorOptions{GeneratedAssetsDirectory: generatedDir, RunsDirectory: mustGetTempDir(t)},
+			stdout:              ioutil.Discard,
+			consoleOutputFormat: ansible.RawFormat,
+			pki:                 pki,
+			runnerExplainerFactory: func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+				return &fakeRunner, &explain.AnsibleEventStreamExplainer{}, nil
+			},
+			certsDir: certsDir,
+		}
+		plan := Plan{
+			Master: MasterNodeGroup{
+				Nodes: []Node{{InternalIP: "10.10.2.20"}},
+			},
+			Cluster: Cluster{
+				Networking: NetworkConfig{
+					ServiceCIDRBlock: "10.0.0.0/16",
+				},
+			},
+		}
+		if err := e.RotateCertificates(plan, test.rotateCA); err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if pki.rotateCACalled != test.rotateCA {
+			t.Errorf("test %d: expected CA rotation to be %v, but was %v", i, test.rotateCA, pki.rotateCACalled)
+		}
+		if !pki.rotateCertsCalled {
+			t.Errorf("test %d: certificates were not reissued", i)
+		}
+		backups, err := filepath.Glob(filepath.Join(generatedDir, "keys-backup", "*", "admin.pem"))
+		if err != nil || len(backups) != 1 {
+			t.Errorf("test %d: expected existing certificates to be backed up, found %v", i, backups)
+		}
+		if len(fakeRunner.allNodesPlaybooks) != test.expectedPlaybooks {
+			t.Errorf("test %d: expected rotate-certificates.yaml to run %d times, but got %v", i, test.expectedPlaybooks, fakeRunner.allNodesPlaybooks)
+		}
+		for _, p := range fakeRunner.allNodesPlaybooks {
+			if p != "rotate-certificates.yaml" {
+				t.Errorf("test %d: unexpected playbook %q", i, p)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(certsDir, caBundleFilename)); !os.IsNotExist(err) {
+			t.Errorf("test %d: expected the CA trust bundle to be removed, but got %v", i, err)
+		}
+	}
+}
+
+// caRecordingRunner records the CA certificates that each run distributes
+type caRecordingRunner struct {
+	fakeRunner
+	certsDir string
+	cas      []string
+}
+
+func (r *caRecordingRunner) StartPlaybook(playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog) (<-chan ansible.Event, error) {
+	ca, err := ioutil.ReadFile(filepath.Join(r.certsDir, cc.TLSCAFile))
+	if err != nil {
+		r.cas = append(r.cas, err.Error())
+	} else {
+		r.cas = append(r.cas, string(ca))
+	}
+	return r.fakeRunner.StartPlaybook(playbookFile, inventory, cc)
+}
+
+func TestRotateCertificatesDistributesCABundle(t *testing.T) {
+	tests := []struct {
+		runnerErr   error
+		expectedCAs []string
+	}{
+		{
+			expectedCAs: []string{"new CA\nprevious CA\n", "new CA\nprevious CA\n", "new CA\n"},
+		},
+		{
+			runnerErr:   errors.New("node unreachable"),
+			expectedCAs: []string{"new CA\nprevious CA\n"},
+		},
+	}
+	for i, test := range tests {
+		certsDir := mustGetTempDir(t)
+		// the fake PKI does not write the CA, so ca.pem holds the new CA
+		if err := ioutil.WriteFile(filepath.Join(certsDir, "ca.pem"), []byte("new CA\n"), 0644); err != nil {
+			t.Fatalf("error writing CA: %v", err)
+		}
+		runner := &caRecordingRunner{fakeRunner: fakeRunner{err: test.runnerErr}, certsDir: certsDir}
+		e := ansibleExecutor{
+			options:             ExecutorOptions{GeneratedAssetsDirectory: mustGetTempDir(t), RunsDirectory: mustGetTempDir(t)},
+			stdout:              ioutil.Discard,
+			consoleOutputFormat: ansible.RawFormat,
+			pki:                 &fakePKI{},
+			runnerExplainerFactory: func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+				return runner, &explain.AnsibleEventStreamExplainer{}, nil
+			},
+			certsDir: certsDir,
+		}
+		plan := Plan{
+			Master: MasterNodeGroup{
+				Nodes: []Node{{InternalIP: "10.10.2.20"}},
+			},
+			Cluster: Cluster{
+				Networking: NetworkConfig{
+					ServiceCIDRBlock: "10.0.0.0/16",
+				},
+			},
+		}
+		err := e.RotateCertificates(plan, true)
+		if (err != nil) != (test.runnerErr != nil) {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(runner.cas, test.expectedCAs) {
+			t.Errorf("test %d: expected the distributed CA certificates to be %q, but got %q", i, test.expectedCAs, runner.cas)
+		}
+		// ca.pem must hold a single certificate, even when the rotation fails
+		ca, err := ioutil.ReadFile(filepath.Join(certsDir, "ca.pem"))
+		if err != nil || string(ca) != "new CA\n" {
+			t.Errorf("test %d: expected ca.pem to only contain the new CA, but got %q (%v)", i, ca, err)
+		}
+	}
+}
@@ -6,12 +6,14 @@ import (
 	"strings"
 
 	"github.com/apprenda/kismatic/pkg/data"
+	"github.com/apprenda/kismatic/pkg/util"
 )
 
 const kubeCreatedBy = "kubernetes.io/created-by"
 
 type upgradeKubeInfoClient interface {
 	data.PodLister
+	data.PodDisruptionBudgetLister
 	data.DaemonSetGetter
 	data.ReplicationControllerGetter
 	data.ReplicaSetGetter
@@ -118,9 +120,14 @@ type replicasOnSingleNodeErr struct {
 	kind      string
 	namespace string
 	name      string
+	// wave is true when the node is upgraded along with other nodes
+	wave bool
 }
 
 func (e replicasOnSingleNodeErr) Error() string {
+	if e.wave {
+		return fmt.Sprintf(`All the replicas that belong to the %s "%s/%s" are running on the nodes of this upgrade wave.`, e.kind, e.namespace, e.name)
+	}
 	return fmt.Sprintf(`All the replicas that belong to the %s "%s/%s" are running on this node.`, e.kind, e.namespace, e.name)
 }
 
@@ -133,165 +140,278 @@ func (e podRunningJobErr) Error() string {
 	return fmt.Sprintf(`Pod that belongs to job "%s/%s" is running on this node.`, e.name, e.namespace)
 }
 
+type podDisruptionBudgetErr struct {
+	namespace string
+	name      string
+	pdbName   string
+}
+
+func (e podDisruptionBudgetErr) Error() string {
+	return fmt.Sprintf(`Evicting pod "%s/%s" would violate the PodDisruptionBudget "%s/%s", `+
+		"as the budget does not allow any more disruptions.", e.namespace, e.name, e.namespace, e.pdbName)
+}
+
+type podMultipleDisruptionBudgetsErr struct {
+	namespace string
+	name      string
+	pdbNames  []string
+}
+
+func (e podMultipleDisruptionBudgetsErr) Error() string {
+	return fmt.Sprintf(`Pod "%s/%s" is selected by more than one PodDisruptionBudget (%s), `+
+		"which prevents it from being evicted.", e.namespace, e.name, strings.Join(e.pdbNames, ", "))
+}
+
 // DetectNodeUpgradeSafety determines whether it's safe to upgrade a specific node
 // listed in the plan file. If any condition that could result in data or availability
 // loss is detected, the upgrade is deemed unsafe, and the conditions are returned as errors.
 func DetectNodeUpgradeSafety(plan Plan, node Node, kubeClient upgradeKubeInfoClient) []error {
-	errs := []error{}
-	roles := plan.GetRolesForIP(node.IP)
-	for _, role := range roles {
-		switch role {
-		case "etcd":
-			if plan.Etcd.ExpectedCount < 3 {
-				errs = append(errs, etcdNodeCountErr{})
-			}
-		case "master":
-			if plan.Master.ExpectedCount < 2 {
-				errs = append(errs, masterNodeCountErr{})
-			}
-			lbFQDN := plan.Master.LoadBalancedFQDN
-			if lbFQDN == node.Host || lbFQDN == node.IP {
-				errs = append(errs, masterNodeLoadBalancingErr{})
-			}
-		case "ingress":
-			// we don't control load balancing of ingress nodes. therefore,
-			// upgrading an ingress node is potentially unsafe
-			errs = append(errs, ingressNotSupportedErr{})
-		case "storage":
-			// we could potentially detect safety of upgrading storage nodes by inspecting
-			// the volumes on the node. for now, we are choosing not to support online upgrade of storage nodes
-			errs = append(errs, storageNotSupportedErr{})
-		case "worker":
-			if plan.Worker.ExpectedCount < 2 {
-				errs = append(errs, workerNodeCountErr{})
-			}
-			if workerErrs := detectWorkerNodeUpgradeSafety(node, kubeClient); workerErrs != nil {
-				errs = append(errs, workerErrs...)
+	return DetectWaveUpgradeSafety(plan, []Node{node}, kubeClient)[node.Host]
+}
+
+// DetectWaveUpgradeSafety determines whether it's safe to upgrade the nodes of a
+// wave at the same time. The nodes of a wave are drained together, so the disruptions
+// allowed by the PodDisruptionBudgets, and the replicas of the controllers, are shared
+// by all the nodes in the wave. The conditions that make the upgrade unsafe are
+// returned as errors, keyed by the host of the node they apply to.
+func DetectWaveUpgradeSafety(plan Plan, wave []Node, kubeClient upgradeKubeInfoClient) map[string][]error {
+	errs := map[string][]error{}
+	workers := []Node{}
+	for _, node := range wave {
+		errs[node.Host] = []error{}
+		if util.Contains("worker", plan.GetRolesForIP(node.IP)) {
+			workers = append(workers, node)
+		}
+	}
+	var workerErrs map[string][]error
+	if len(workers) > 0 {
+		workerErrs = detectWorkerNodesUpgradeSafety(workers, kubeClient)
+	}
+	for _, node := range wave {
+		roles := plan.GetRolesForIP(node.IP)
+		for _, role := range roles {
+			switch role {
+			case "etcd":
+				if plan.Etcd.ExpectedCount < 3 {
+					errs[node.Host] = append(errs[node.Host], etcdNodeCountErr{})
+				}
+			case "master":
+				if plan.Master.ExpectedCount < 2 {
+					errs[node.Host] = append(errs[node.Host], masterNodeCountErr{})
+				}
+				lbFQDN := plan.Master.LoadBalancedFQDN
+				if lbFQDN == node.Host || lbFQDN == node.IP {
+					errs[node.Host] = append(errs[node.Host], masterNodeLoadBalancingErr{})
+				}
+			case "ingress":
+				// we don't control load balancing of ingress nodes. therefore,
+				// upgrading an ingress node is potentially unsafe
+				errs[node.Host] = append(errs[node.Host], ingressNotSupportedErr{})
+			case "storage":
+				// we could potentially detect safety of upgrading storage nodes by inspecting
+				// the volumes on the node. for now, we are choosing not to support online upgrade of storage nodes
+				errs[node.Host] = append(errs[node.Host], storageNotSupportedErr{})
+			case "worker":
+				if plan.Worker.ExpectedCount < 2 {
+					errs[node.Host] = append(errs[node.Host], workerNodeCountErr{})
+				}
+				errs[node.Host] = append(errs[node.Host], workerErrs[node.Host]...)
 			}
 		}
 	}
 	return errs
 }
 
-func detectWorkerNodeUpgradeSafety(node Node, kubeClient upgradeKubeInfoClient) []error {
-	errs := []error{}
+// detectWorkerNodesUpgradeSafety simulates draining the worker nodes at the same time,
+// and returns the conditions that make it unsafe, keyed by the host of the node.
+func detectWorkerNodesUpgradeSafety(nodes []Node, kubeClient upgradeKubeInfoClient) map[string][]error {
+	errs := map[string][]error{}
+	// errors that prevent determining the safety apply to all the nodes
+	allNodesErr := func(err error) map[string][]error {
+		for _, n := range nodes {
+			errs[n.Host] = append(errs[n.Host], err)
+		}
+		return errs
+	}
 	podList, err := kubeClient.ListPods()
 	if err != nil || podList == nil {
-		errs = append(errs, fmt.Errorf("unable to determine node upgrade safety: %v", err))
-		return errs
+		return allNodesErr(fmt.Errorf("unable to determine node upgrade safety: %v", err))
+	}
+	drained := map[string]bool{}
+	for _, n := range nodes {
+		drained[n.Host] = true
 	}
 	nodePods := []data.Pod{}
 	for _, p := range podList.Items {
-		if p.Spec.NodeName == node.Host {
+		// Pods that have terminated are not disrupted by the upgrade
+		if p.Status.Phase == data.PodSucceeded || p.Status.Phase == data.PodFailed {
+			continue
+		}
+		if drained[p.Spec.NodeName] {
 			nodePods = append(nodePods, p)
 		}
 	}
+	pdbList, err := kubeClient.ListPodDisruptionBudgets()
+	if err != nil || pdbList == nil {
+		return allNodesErr(fmt.Errorf("unable to determine node upgrade safety: %v", err))
+	}
 
 	// Are there any pods using a hostPath, emptyDir volume OR a hostPath PersistentVolume?
 	for _, p := range nodePods {
+		host := p.Spec.NodeName
 		for _, v := range p.Spec.Volumes {
 			if v.VolumeSource.HostPath != nil {
-				errs = append(errs, podUnsafeVolumeErr{namespace: p.Namespace, name: p.Name, volType: "HostPath", volName: v.Name})
+				errs[host] = append(errs[host], podUnsafeVolumeErr{namespace: p.Namespace, name: p.Name, volType: "HostPath", volName: v.Name})
 			}
 			if v.VolumeSource.EmptyDir != nil {
-				errs = append(errs, podUnsafeVolumeErr{namespace: p.Namespace, name: p.Name, volType: "EmptyDir", volName: v.Name})
+				errs[host] = append(errs[host], podUnsafeVolumeErr{namespace: p.Namespace, name: p.Name, volType: "EmptyDir", volName: v.Name})
 			}
 			if v.VolumeSource.PersistentVolumeClaim != nil {
 				claimRef := v.VolumeSource.PersistentVolumeClaim
 				pvc, err := kubeClient.GetPersistentVolumeClaim(p.Namespace, claimRef.ClaimName)
 				if err != nil || pvc == nil {
-					errs = append(errs, fmt.Errorf(`Failed to get PersistentVolumeClaim "%s/%s."`, p.Namespace, claimRef.ClaimName))
+					errs[host] = append(errs[host], fmt.Errorf(`Failed to get PersistentVolumeClaim "%s/%s."`, p.Namespace, claimRef.ClaimName))
 					continue
 				}
 				pvName := pvc.Spec.VolumeName
 				pv, err := kubeClient.GetPersistentVolume(pvName)
 				if err != nil || pv == nil {
-					errs = append(errs, fmt.Errorf(`Failed to get PersistentVolume %q. This PV is being used by pod "%s/%s" on this node`, pvName, p.Namespace, p.Name))
+					errs[host] = append(errs[host], fmt.Errorf(`Failed to get PersistentVolume %q. This PV is being used by pod "%s/%s" on this node`, pvName, p.Namespace, p.Name))
 					continue
 				}
 				if pv.Spec.HostPath != nil {
-					errs = append(errs, podUnsafePersistentVolumeErr{namespace: p.Namespace, name: p.Name, volType: "HostPath", volName: v.Name})
+					errs[host] = append(errs[host], podUnsafePersistentVolumeErr{namespace: p.Namespace, name: p.Name, volType: "HostPath", volName: v.Name})
 				}
 			}
 		}
 	}
 
 	// Keep track of how many pods managed by replication controllers and replicasets
-	// are running on this node. If all replicas are running on the node, we need to
+	// are running on the nodes. If all replicas are running on the nodes, we need to
 	// return an error, as it would take the workload down.
 	rcPods := map[string]int32{}
 	rsPods := map[string]int32{}
 
-	// 1. Are there any pods running on this node that are not managed by a controller?
-	// 2. Are there any pods running on this node that are managed by a controller,
-	//    and have replicas less than 2?
-	// 3. Are there any daemonset managed pods running on this node? If so,
-	//    verify that it is not the only one
-	// 4. Are there any pods that belong to a job running on this node?
+	// Keep track of the disruptions left in each budget, as every pod on the
+	// nodes is evicted when the nodes are drained
+	disruptionsAllowed := map[string]int32{}
+	for _, pdb := range pdbList.Items {
+		disruptionsAllowed[pdb.Namespace+"/"+pdb.Name] = pdb.Status.PodDisruptionsAllowed
+	}
+
+	// 1. Are there any pods running on the nodes that are not managed by a controller?
+	// 2. Are there any daemonset managed pods running on the nodes? If so,
+	//    verify that they are not the only ones
+	// 3. Would evicting the pods violate the PodDisruptionBudgets that select them?
+	// 4. For pods that are not selected by a budget, are they managed by a
+	//    controller that has replicas less than 2?
+	// 5. For pods that are not selected by a budget, do any of them belong to a job?
 	for _, p := range nodePods {
-		creator, ok := p.Annotations[kubeCreatedBy]
-		if !ok {
-			errs = append(errs, unmanagedPodErr{namespace: p.Namespace, name: p.Name})
+		host := p.Spec.NodeName
+		ref, err := podControllerRef(p)
+		if err != nil {
+			errs[host] = append(errs[host], err)
 			continue
 		}
-		var r data.SerializedReference
-		err := json.Unmarshal([]byte(creator), &r)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("Unable to determine the creator of pod %s/%s", p.Namespace, p.Name))
+		if ref == nil {
+			errs[host] = append(errs[host], unmanagedPodErr{namespace: p.Namespace, name: p.Name})
 			continue
 		}
-		switch strings.ToLower(r.Reference.Kind) {
-		default:
-			errs = append(errs, fmt.Errorf("Unable to determine upgrade safety for a pod managed by a controller of type %q", r.Reference.Kind))
-		case "daemonset":
-			ds, err := kubeClient.GetDaemonSet(r.Reference.Namespace, r.Reference.Name)
+		// Daemons are not evicted when the node is drained, so budgets don't apply to them
+		if strings.ToLower(ref.Kind) == "daemonset" {
+			ds, err := kubeClient.GetDaemonSet(ref.Namespace, ref.Name)
 			if err != nil || ds == nil {
-				errs = append(errs, fmt.Errorf("Failed to get information about DaemonSet %s/%s", r.Reference.Namespace, r.Reference.Name))
+				errs[host] = append(errs[host], fmt.Errorf("Failed to get information about DaemonSet %s/%s", ref.Namespace, ref.Name))
 				continue
 			}
 			// Check if other nodes should be running this DS
 			if ds.Status.DesiredNumberScheduled < 2 {
-				errs = append(errs, podUnsafeDaemonErr{dsNamespace: r.Reference.Namespace, dsName: r.Reference.Name})
+				errs[host] = append(errs[host], podUnsafeDaemonErr{dsNamespace: ref.Namespace, dsName: ref.Name})
+			}
+			continue
+		}
+		// Simulate the eviction of the pod, in the same way the eviction API would
+		pdbs := []string{}
+		for _, pdb := range pdbList.Items {
+			if pdb.Selects(p) {
+				pdbs = append(pdbs, pdb.Name)
 			}
+		}
+		if len(pdbs) > 1 {
+			errs[host] = append(errs[host], podMultipleDisruptionBudgetsErr{namespace: p.Namespace, name: p.Name, pdbNames: pdbs})
+			continue
+		}
+		if len(pdbs) == 1 {
+			key := p.Namespace + "/" + pdbs[0]
+			if disruptionsAllowed[key] <= 0 {
+				errs[host] = append(errs[host], podDisruptionBudgetErr{namespace: p.Namespace, name: p.Name, pdbName: pdbs[0]})
+				continue
+			}
+			disruptionsAllowed[key]--
+			continue
+		}
+		switch strings.ToLower(ref.Kind) {
+		default:
+			errs[host] = append(errs[host], fmt.Errorf("Unable to determine upgrade safety for pod %s/%s, which is managed by a controller of type %q "+
+				"and is not selected by a PodDisruptionBudget", p.Namespace, p.Name, ref.Kind))
 		case "job":
-			errs = append(errs, podRunningJobErr{namespace: r.Reference.Namespace, name: r.Reference.Name})
+			errs[host] = append(errs[host], podRunningJobErr{namespace: ref.Namespace, name: ref.Name})
 		case "replicationcontroller":
-			rc, err := kubeClient.GetReplicationController(r.Reference.Namespace, r.Reference.Name)
+			rc, err := kubeClient.GetReplicationController(ref.Namespace, ref.Name)
 			if err != nil || rc == nil {
-				errs = append(errs, fmt.Errorf(`Failed to get information about ReplicationController "%s/%s"`, r.Reference.Namespace, r.Reference.Name))
+				errs[host] = append(errs[host], fmt.Errorf(`Failed to get information about ReplicationController "%s/%s"`, ref.Namespace, ref.Name))
 				continue
 			}
 			if rc.Status.Replicas < 2 {
-				errs = append(errs, unsafeReplicaCountErr{kind: r.Reference.Kind, namespace: r.Reference.Namespace, name: r.Reference.Name})
+				errs[host] = append(errs[host], unsafeReplicaCountErr{kind: ref.Kind, namespace: ref.Namespace, name: ref.Name})
 			}
-			rcPods[r.Reference.Namespace+r.Reference.Name]++
-			if rcPods[r.Reference.Namespace+r.Reference.Name] == rc.Status.Replicas {
-				errs = append(errs, replicasOnSingleNodeErr{kind: r.Reference.Kind, namespace: r.Reference.Namespace, name: r.Reference.Name})
+			rcPods[ref.Namespace+ref.Name]++
+			if rcPods[ref.Namespace+ref.Name] == rc.Status.Replicas {
+				errs[host] = append(errs[host], replicasOnSingleNodeErr{kind: ref.Kind, namespace: ref.Namespace, name: ref.Name, wave: len(nodes) > 1})
 			}
 		case "replicaset":
-			rs, err := kubeClient.GetReplicaSet(r.Reference.Namespace, r.Reference.Name)
+			rs, err := kubeClient.GetReplicaSet(ref.Namespace, ref.Name)
 			if err != nil || rs == nil {
-				errs = append(errs, fmt.Errorf(`Failed to get information about ReplicaSet "%s/%s"`, r.Reference.Namespace, r.Reference.Name))
+				errs[host] = append(errs[host], fmt.Errorf(`Failed to get information about ReplicaSet "%s/%s"`, ref.Namespace, ref.Name))
 				continue
 			}
 			if rs.Status.Replicas < 2 {
-				errs = append(errs, unsafeReplicaCountErr{kind: r.Reference.Kind, namespace: r.Reference.Namespace, name: r.Reference.Name})
+				errs[host] = append(errs[host], unsafeReplicaCountErr{kind: ref.Kind, namespace: ref.Namespace, name: ref.Name})
 			}
-			rsPods[r.Reference.Namespace+r.Reference.Name]++
-			if rsPods[r.Reference.Namespace+r.Reference.Name] == rs.Status.Replicas {
-				errs = append(errs, replicasOnSingleNodeErr{kind: r.Reference.Kind, namespace: r.Reference.Namespace, name: r.Reference.Name})
+			rsPods[ref.Namespace+ref.Name]++
+			if rsPods[ref.Namespace+ref.Name] == rs.Status.Replicas {
+				errs[host] = append(errs[host], replicasOnSingleNodeErr{kind: ref.Kind, namespace: ref.Namespace, name: ref.Name, wave: len(nodes) > 1})
 			}
 		case "statefulset":
-			sts, err := kubeClient.GetStatefulSet(r.Reference.Namespace, r.Reference.Name)
+			sts, err := kubeClient.GetStatefulSet(ref.Namespace, ref.Name)
 			if err != nil || sts == nil {
-				errs = append(errs, fmt.Errorf(`Failed to get information about StatefulSet "%s/%s"`, r.Reference.Namespace, r.Reference.Name))
+				errs[host] = append(errs[host], fmt.Errorf(`Failed to get information about StatefulSet "%s/%s"`, ref.Namespace, ref.Name))
 				continue
 			}
 			if sts.Status.Replicas < 2 {
-				errs = append(errs, unsafeReplicaCountErr{kind: r.Reference.Kind, namespace: r.Reference.Namespace, name: r.Reference.Name})
+				errs[host] = append(errs[host], unsafeReplicaCountErr{kind: ref.Kind, namespace: ref.Namespace, name: ref.Name})
 			}
 		}
 	}
 
 	return errs
 }
+
+// podControllerRef returns a reference to the controller that manages the pod,
+// or nil if the pod is not managed by a controller. The pod's owner references
+// are used, falling back to the created-by annotation set by older versions
+// of Kubernetes.
+func podControllerRef(p data.Pod) (*data.ObjectReference, error) {
+	if owner := p.ControllerRef(); owner != nil {
+		return &data.ObjectReference{Kind: owner.Kind, Namespace: p.Namespace, Name: owner.Name}, nil
+	}
+	creator, ok := p.Annotations[kubeCreatedBy]
+	if !ok {
+		return nil, nil
+	}
+	var r data.SerializedReference
+	if err := json.Unmarshal([]byte(creator), &r); err != nil {
+		return nil, fmt.Errorf("Unable to determine the creator of pod %s/%s", p.Namespace, p.Name)
+	}
+	return &r.Reference, nil
+}
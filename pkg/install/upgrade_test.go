@@ -12,6 +12,7 @@ import (
 
 type fakeUpgradeKubeClient struct {
 	listPods                 func() (*data.PodList, error)
+	listPDBs                 func() (*data.PodDisruptionBudgetList, error)
 	getDaemonSet             func() (*data.DaemonSet, error)
 	getReplicationController func() (*data.ReplicationController, error)
 	getReplicaSet            func() (*data.ReplicaSet, error)
@@ -27,6 +28,13 @@ func (f fakeUpgradeKubeClient) ListPods() (*data.PodList, error) {
 	return &data.PodList{}, nil
 }
 
+func (f fakeUpgradeKubeClient) ListPodDisruptionBudgets() (*data.PodDisruptionBudgetList, error) {
+	if f.listPDBs != nil {
+		return f.listPDBs()
+	}
+	return &data.PodDisruptionBudgetList{}, nil
+}
+
 func (f fakeUpgradeKubeClient) GetDaemonSet(namespace, name string) (*data.DaemonSet, error) {
 	if f.getDaemonSet != nil {
 		return f.getDaemonSet()
@@ -823,3 +831,296 @@ func TestDetectNodeUpgradeSafetyAllReplicaControllerPodsSameNode(t *testing.T) {
 		t.Errorf("expected replicasOnSingleNodeErr, but got %T", errs[0])
 	}
 }
+
+func getPodWithOwnerRef(nodeName, name, ownerKind string, labels map[string]string) data.Pod {
+	controller := true
+	return data.Pod{
+		ObjectMeta: data.ObjectMeta{
+			Name:      name,
+			Namespace: "foo",
+			Labels:    labels,
+			OwnerReferences: []data.OwnerReference{
+				{
+					Kind:       ownerKind,
+					Name:       "bar",
+					Controller: &controller,
+				},
+			},
+		},
+		Spec: data.PodSpec{
+			NodeName: nodeName,
+		},
+	}
+}
+
+func getPDB(name string, disruptionsAllowed int32, matchLabels map[string]string) data.PodDisruptionBudget {
+	return data.PodDisruptionBudget{
+		ObjectMeta: data.ObjectMeta{
+			Name:      name,
+			Namespace: "foo",
+		},
+		Spec: data.PodDisruptionBudgetSpec{
+			Selector: &data.LabelSelector{MatchLabels: matchLabels},
+		},
+		Status: data.PodDisruptionBudgetStatus{
+			PodDisruptionsAllowed: disruptionsAllowed,
+		},
+	}
+}
+
+func TestDetectNodeUpgradeSafetyOwnerReferences(t *testing.T) {
+	plan := Plan{
+		Worker: NodeGroup{
+			ExpectedCount: 2,
+			Nodes: []Node{
+				{
+					Host: "foo",
+					IP:   "10.0.0.1",
+				},
+			},
+		},
+	}
+	node := plan.Worker.Nodes[0]
+
+	// The pod has no created-by annotation, but is owned by a replica set
+	pod := getPodWithOwnerRef(node.Host, "pod1", "ReplicaSet", nil)
+	k8sClient := fakeUpgradeKubeClient{
+		listPods: func() (*data.PodList, error) {
+			return &data.PodList{Items: []data.Pod{pod}}, nil
+		},
+		getReplicaSet: func() (*data.ReplicaSet, error) {
+			return &data.ReplicaSet{Status: data.ReplicaSetStatus{Replicas: 3}}, nil
+		},
+	}
+	errs := DetectNodeUpgradeSafety(plan, node, k8sClient)
+	if len(errs) != 0 {
+		t.Errorf("Expected no errors, but got %v", errs)
+	}
+}
+
+func TestDetectNodeUpgradeSafetyPodDisruptionBudget(t *testing.T) {
+	plan := Plan{
+		Worker: NodeGroup{
+			ExpectedCount: 2,
+			Nodes: []Node{
+				{
+					Host: "foo",
+					IP:   "10.0.0.1",
+				},
+			},
+		},
+	}
+	node := plan.Worker.Nodes[0]
+	app := map[string]string{"app": "bar"}
+	tests := []struct {
+		description    string
+		pods           []data.Pod
+		pdbs           []data.PodDisruptionBudget
+		expectedErrors []error
+	}{
+		{
+			description: "budget allows evicting all the pods of an unreplicated custom controller",
+			pods: []data.Pod{
+				getPodWithOwnerRef(node.Host, "pod1", "EtcdCluster", app),
+				getPodWithOwnerRef(node.Host, "pod2", "EtcdCluster", app),
+			},
+			pdbs: []data.PodDisruptionBudget{getPDB("pdb", 2, app)},
+		},
+		{
+			description: "budget allows one eviction, but two pods are on the node",
+			pods: []data.Pod{
+				getPodWithOwnerRef(node.Host, "pod1", "StatefulSet", app),
+				getPodWithOwnerRef(node.Host, "pod2", "StatefulSet", app),
+			},
+			pdbs:           []data.PodDisruptionBudget{getPDB("pdb", 1, app)},
+			expectedErrors: []error{podDisruptionBudgetErr{namespace: "foo", name: "pod2", pdbName: "pdb"}},
+		},
+		{
+			description:    "budget does not allow disruptions",
+			pods:           []data.Pod{getPodWithOwnerRef(node.Host, "pod1", "ReplicaSet", app)},
+			pdbs:           []data.PodDisruptionBudget{getPDB("pdb", 0, app)},
+			expectedErrors: []error{podDisruptionBudgetErr{namespace: "foo", name: "pod1", pdbName: "pdb"}},
+		},
+		{
+			description: "pod is selected by more than one budget",
+			pods:        []data.Pod{getPodWithOwnerRef(node.Host, "pod1", "ReplicaSet", app)},
+			pdbs: []data.PodDisruptionBudget{
+				getPDB("pdb1", 1, app),
+				getPDB("pdb2", 1, nil),
+				getPDB("pdb3", 1, app),
+			},
+			expectedErrors: []error{podMultipleDisruptionBudgetsErr{namespace: "foo", name: "pod1", pdbNames: []string{"pdb1", "pdb3"}}},
+		},
+		{
+			description: "daemon pods are not evicted, so budgets don't apply to them",
+			pods:        []data.Pod{getPodWithOwnerRef(node.Host, "pod1", "DaemonSet", app)},
+			pdbs:        []data.PodDisruptionBudget{getPDB("pdb", 0, app)},
+		},
+		{
+			description: "terminated pods are not evicted",
+			pods: []data.Pod{
+				func() data.Pod {
+					p := getPodWithOwnerRef(node.Host, "pod1", "Job", app)
+					p.Status.Phase = data.PodSucceeded
+					return p
+				}(),
+			},
+			pdbs: []data.PodDisruptionBudget{getPDB("pdb", 0, app)},
+		},
+	}
+	for _, test := range tests {
+		k8sClient := fakeUpgradeKubeClient{
+			listPods: func() (*data.PodList, error) {
+				return &data.PodList{Items: test.pods}, nil
+			},
+			listPDBs: func() (*data.PodDisruptionBudgetList, error) {
+				return &data.PodDisruptionBudgetList{Items: test.pdbs}, nil
+			},
+			getDaemonSet: func() (*data.DaemonSet, error) {
+				return &data.DaemonSet{Status: data.DaemonSetStatus{DesiredNumberScheduled: 2}}, nil
+			},
+		}
+		errs := DetectNodeUpgradeSafety(plan, node, k8sClient)
+		if len(errs) != len(test.expectedErrors) {
+			t.Errorf("%s: expected %d errors, but got %v", test.description, len(test.expectedErrors), errs)
+			continue
+		}
+		for i, err := range errs {
+			if err.Error() != test.expectedErrors[i].Error() {
+				t.Errorf("%s: expected error %q, but got %q", test.description, test.expectedErrors[i], err)
+			}
+		}
+	}
+}
+
+func TestDetectNodeUpgradeSafetyPodDisruptionBudgetListError(t *testing.T) {
+	plan := Plan{
+		Worker: NodeGroup{
+			ExpectedCount: 2,
+			Nodes: []Node{
+				{
+					Host: "foo",
+					IP:   "10.0.0.1",
+				},
+			},
+		},
+	}
+	node := plan.Worker.Nodes[0]
+	k8sClient := fakeUpgradeKubeClient{
+		listPDBs: func() (*data.PodDisruptionBudgetList, error) {
+			return nil, errors.New("some error")
+		},
+	}
+	errs := DetectNodeUpgradeSafety(plan, node, k8sClient)
+	if len(errs) != 1 {
+		t.Errorf("Expected %d errors, but got %v", 1, errs)
+	}
+}
+
+func TestDetectWaveUpgradeSafetySharesDisruptions(t *testing.T) {
+	plan := Plan{
+		Worker: NodeGroup{
+			ExpectedCount: 3,
+			Nodes: []Node{
+				{
+					Host: "worker1",
+					IP:   "10.0.0.1",
+				},
+				{
+					Host: "worker2",
+					IP:   "10.0.0.2",
+				},
+				{
+					Host: "worker3",
+					IP:   "10.0.0.3",
+				},
+			},
+		},
+	}
+	app := map[string]string{"app": "bar"}
+	k8sClient := fakeUpgradeKubeClient{
+		listPods: func() (*data.PodList, error) {
+			return &data.PodList{
+				Items: []data.Pod{
+					getPodWithOwnerRef("worker1", "pod1", "StatefulSet", app),
+					getPodWithOwnerRef("worker2", "pod2", "StatefulSet", app),
+					getPodWithOwnerRef("worker3", "pod3", "StatefulSet", app),
+				},
+			}, nil
+		},
+		listPDBs: func() (*data.PodDisruptionBudgetList, error) {
+			return &data.PodDisruptionBudgetList{Items: []data.PodDisruptionBudget{getPDB("pdb", 1, app)}}, nil
+		},
+	}
+
+	// Each node is safe to upgrade on its own
+	for _, n := range plan.Worker.Nodes {
+		if errs := DetectNodeUpgradeSafety(plan, n, k8sClient); len(errs) != 0 {
+			t.Errorf("%s: expected no errors, but got %v", n.Host, errs)
+		}
+	}
+
+	// The budget only allows draining one of the nodes in the wave
+	errs := DetectWaveUpgradeSafety(plan, plan.Worker.Nodes[:2], k8sClient)
+	if len(errs) != 2 {
+		t.Fatalf("expected the errors of 2 nodes, but got %v", errs)
+	}
+	if len(errs["worker1"]) != 0 {
+		t.Errorf("expected no errors for worker1, but got %v", errs["worker1"])
+	}
+	expected := podDisruptionBudgetErr{namespace: "foo", name: "pod2", pdbName: "pdb"}
+	if len(errs["worker2"]) != 1 || errs["worker2"][0].Error() != expected.Error() {
+		t.Errorf("expected %q for worker2, but got %v", expected, errs["worker2"])
+	}
+}
+
+func TestDetectWaveUpgradeSafetyAllReplicasInWave(t *testing.T) {
+	plan := Plan{
+		Worker: NodeGroup{
+			ExpectedCount: 3,
+			Nodes: []Node{
+				{
+					Host: "worker1",
+					IP:   "10.0.0.1",
+				},
+				{
+					Host: "worker2",
+					IP:   "10.0.0.2",
+				},
+				{
+					Host: "worker3",
+					IP:   "10.0.0.3",
+				},
+			},
+		},
+	}
+	k8sClient := fakeUpgradeKubeClient{
+		listPods: func() (*data.PodList, error) {
+			return &data.PodList{
+				Items: []data.Pod{
+					getPodWithOwnerRef("worker1", "pod1", "ReplicaSet", nil),
+					getPodWithOwnerRef("worker2", "pod2", "ReplicaSet", nil),
+				},
+			}, nil
+		},
+		getReplicaSet: func() (*data.ReplicaSet, error) {
+			return &data.ReplicaSet{Status: data.ReplicaSetStatus{Replicas: 2}}, nil
+		},
+	}
+
+	errs := DetectWaveUpgradeSafety(plan, plan.Worker.Nodes[1:], k8sClient)
+	if len(errs["worker2"]) != 0 || len(errs["worker3"]) != 0 {
+		t.Errorf("expected no errors when one of the replicas is left running, but got %v", errs)
+	}
+
+	errs = DetectWaveUpgradeSafety(plan, plan.Worker.Nodes[:2], k8sClient)
+	if len(errs["worker1"]) != 0 {
+		t.Errorf("expected no errors for worker1, but got %v", errs["worker1"])
+	}
+	if len(errs["worker2"]) != 1 {
+		t.Fatalf("expected 1 error for worker2, but got %v", errs["worker2"])
+	}
+	if _, ok := errs["worker2"][0].(replicasOnSingleNodeErr); !ok {
+		t.Errorf("expected replicasOnSingleNodeErr, but got %T", errs["worker2"][0])
+	}
+}
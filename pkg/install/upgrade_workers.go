@@ -0,0 +1,227 @@
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+// WorkerUpgradeOptions determine how the nodes that are neither etcd nor
+// master nodes are upgraded
+type WorkerUpgradeOptions struct {
+	// MaxParallelWorkers is the number of nodes in each wave, when the wave
+	// size is not given as a percentage
+	MaxParallelWorkers int
+	// Canary determines whether a single node is upgraded and smoke tested
+	// before upgrading the rest of the nodes
+	Canary bool
+	// WavePercentage is the size of each wave, as a percentage of the nodes
+	// that are being upgraded. MaxParallelWorkers is used if zero.
+	WavePercentage int
+	// WavePause is the time to wait between waves of worker nodes. There is
+	// no pause after the canary, as it is smoke tested instead.
+	WavePause time.Duration
+	// MaxFailedNodesPerWave is the number of nodes in a wave that can fail to
+	// upgrade or to become Ready before the upgrade is aborted
+	MaxFailedNodesPerWave int
+}
+
+// UpgradeBatch is a set of nodes that are upgraded at the same time
+type UpgradeBatch struct {
+	// Phase is one of "etcd", "master", "canary" or "worker"
+	Phase string
+	Nodes []ListableNode
+}
+
+// UpgradeBatches returns the batches in which the nodes are upgraded, in the
+// order in which they are upgraded
+func UpgradeBatches(nodes []ListableNode, workerOpts WorkerUpgradeOptions) []UpgradeBatch {
+	batches := []UpgradeBatch{}
+	etcdNodes, masterNodes, workers := upgradePhases(nodes)
+	for _, n := range etcdNodes {
+		batches = append(batches, UpgradeBatch{Phase: "etcd", Nodes: []ListableNode{n}})
+	}
+	for _, n := range masterNodes {
+		batches = append(batches, UpgradeBatch{Phase: "master", Nodes: []ListableNode{n}})
+	}
+	for i, wave := range workerUpgradeWaves(workers, workerOpts) {
+		phase := "worker"
+		if workerOpts.Canary && i == 0 {
+			phase = "canary"
+		}
+		batches = append(batches, UpgradeBatch{Phase: phase, Nodes: wave})
+	}
+	return batches
+}
+
+// PauseBeforeWave returns true if the wave pause is waited before upgrading
+// the worker wave with the given index. Only the waves that follow another
+// wave of worker nodes wait, as the first wave follows the masters, and the
+// canary is smoke tested instead.
+func PauseBeforeWave(wave int, opts WorkerUpgradeOptions) bool {
+	if opts.WavePause <= 0 || wave == 0 {
+		return false
+	}
+	return !opts.Canary || wave > 1
+}
+
+// upgradePhases splits the nodes into the etcd nodes, the master nodes that
+// are not etcd nodes, and the rest of the nodes. Nodes can have multiple roles,
+// and all the components of a node are upgraded in the first phase it belongs to.
+func upgradePhases(nodes []ListableNode) (etcdNodes, masterNodes, workers []ListableNode) {
+	for _, n := range nodes {
+		switch {
+		case util.Contains("etcd", n.Roles):
+			etcdNodes = append(etcdNodes, n)
+		case util.Contains("master", n.Roles):
+			masterNodes = append(masterNodes, n)
+		default:
+			workers = append(workers, n)
+		}
+	}
+	return etcdNodes, masterNodes, workers
+}
+
+// upgradeWorkers upgrades the nodes in waves. After each wave, the nodes must
+// register with the API server and become Ready. If more nodes than allowed
+// fail in a wave, the upgrade is aborted. A failed canary always aborts the upgrade.
+func (ae *ansibleExecutor) upgradeWorkers(plan Plan, nodes []ListableNode, onlineUpgrade bool, opts WorkerUpgradeOptions) error {
+	errs := map[string]error{}
+	for i, wave := range workerUpgradeWaves(nodes, opts) {
+		if PauseBeforeWave(i, opts) && !ae.options.DryRun {
+			fmt.Fprintf(ae.stdout, "Waiting %v before upgrading the next wave of nodes\n", opts.WavePause)
+			time.Sleep(opts.WavePause)
+		}
+		ready := ae.upgradeWave(plan, wave, onlineUpgrade, errs)
+		canary := opts.Canary && i == 0
+		if canary && len(ready) == 1 && plan.NetworkConfigured() {
+			if err := ae.smokeTestCanary(plan, ready[0]); err != nil {
+				errs[wave[0].Node.Host] = fmt.Errorf("smoke test failed: %v", err)
+				ready = nil
+			}
+		}
+		if canary && len(ready) == 0 {
+			return fmt.Errorf("error upgrading canary node %q: %v", wave[0].Node.Host, errs[wave[0].Node.Host])
+		}
+		failed := len(wave) - len(ready)
+		if failed > opts.MaxFailedNodesPerWave {
+			return fmt.Errorf("aborting upgrade, as %d node(s) failed in the last wave, and at most %d are allowed to fail:\n%s",
+				failed, opts.MaxFailedNodesPerWave, formatNodeErrors(errs))
+		}
+		if failed > 0 {
+			util.PrettyPrintWarn(ae.stdout, "%d node(s) failed in the last wave. Continuing with the upgrade", failed)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("the following nodes failed to upgrade:\n%s", formatNodeErrors(errs))
+	}
+	return nil
+}
+
+// upgradeWave upgrades the nodes in the wave, and waits until they are Ready.
+// The nodes that are Ready are returned, and the errors of those that failed
+// are recorded in errs.
+func (ae *ansibleExecutor) upgradeWave(plan Plan, wave []ListableNode, onlineUpgrade bool, errs map[string]error) []Node {
+	recorder := &hostFailureRecorder{AnsibleEventExplainer: ae.defaultExplainer()}
+	err := ae.upgradeNodes(plan, onlineUpgrade, recorder, wave...)
+	failed := recorder.failures()
+	var upgraded []Node
+	for _, n := range wave {
+		if msg, ok := failed[n.Node.Host]; ok {
+			errs[n.Node.Host] = fmt.Errorf("error upgrading node: %s", msg)
+			continue
+		}
+		// The playbook failed without blaming a specific node
+		if err != nil && len(failed) == 0 {
+			errs[n.Node.Host] = fmt.Errorf("error upgrading node: %v", err)
+			continue
+		}
+		upgraded = append(upgraded, n.Node)
+	}
+	if len(upgraded) == 0 {
+		return nil
+	}
+	cc, err := ae.buildClusterCatalog(&plan)
+	if err != nil {
+		for _, n := range upgraded {
+			errs[n.Host] = fmt.Errorf("failed to generate ansible vars: %v", err)
+		}
+		return nil
+	}
+	util.PrintHeader(ae.stdout, "Validate Upgraded Nodes Are Ready", '=')
+	t := task{
+		name:           "upgrade-nodes-ready",
+		playbook:       "_worker-smoke-test.yaml",
+		plan:           plan,
+		inventory:      buildInventoryFromPlan(&plan),
+		clusterCatalog: *cc,
+		explainer:      ae.defaultExplainer(),
+	}
+	return ae.executeOnNodes(t, upgraded, errs, "node did not become Ready")
+}
+
+// smokeTestCanary runs a pod on the canary node, to verify that the
+// upgraded node is able to run workloads
+func (ae *ansibleExecutor) smokeTestCanary(plan Plan, node Node) error {
+	cc, err := ae.buildClusterCatalog(&plan)
+	if err != nil {
+		return err
+	}
+	cc.WorkerNode = node.Host
+	t := task{
+		name:           "canary-smoke-test",
+		playbook:       "canary-smoke-test.yaml",
+		explainer:      ae.defaultExplainer(),
+		plan:           plan,
+		inventory:      buildInventoryFromPlan(&plan),
+		clusterCatalog: *cc,
+	}
+	util.PrintHeader(ae.stdout, "Smoke Test Canary Node", '=')
+	return ae.execute(t)
+}
+
+// workerUpgradeWaves splits the nodes into the waves in which they are upgraded.
+// When a canary is requested, the first node is upgraded on its own.
+func workerUpgradeWaves(nodes []ListableNode, opts WorkerUpgradeOptions) [][]ListableNode {
+	waves := [][]ListableNode{}
+	if len(nodes) == 0 {
+		return waves
+	}
+	size := opts.MaxParallelWorkers
+	if opts.WavePercentage > 0 {
+		// Round up, so that no wave is empty
+		size = (len(nodes)*opts.WavePercentage + 99) / 100
+	}
+	if size < 1 {
+		size = 1
+	}
+	rest := nodes
+	if opts.Canary {
+		waves = append(waves, nodes[:1])
+		rest = nodes[1:]
+	}
+	for len(rest) > 0 {
+		if size > len(rest) {
+			size = len(rest)
+		}
+		waves = append(waves, rest[:size])
+		rest = rest[size:]
+	}
+	return waves
+}
+
+func formatNodeErrors(errs map[string]error) string {
+	hosts := make([]string, 0, len(errs))
+	for h := range errs {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	var b bytes.Buffer
+	for _, h := range hosts {
+		fmt.Fprintf(&b, "- %s: %v\n", h, errs[h])
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package install
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWorkerUpgradeWaves(t *testing.T) {
+	nodes := []ListableNode{}
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, ListableNode{Node: Node{Host: fmt.Sprintf("worker%02d", i)}, Roles: []string{"worker"}})
+	}
+	tests := []struct {
+		description string
+		nodes       []ListableNode
+		opts        WorkerUpgradeOptions
+		waveSizes   []int
+	}{
+		{
+			description: "no nodes",
+			opts:        WorkerUpgradeOptions{MaxParallelWorkers: 1, Canary: true},
+			waveSizes:   []int{},
+		},
+		{
+			description: "one node at a time",
+			nodes:       nodes[:3],
+			opts:        WorkerUpgradeOptions{MaxParallelWorkers: 1},
+			waveSizes:   []int{1, 1, 1},
+		},
+		{
+			description: "max parallel workers with a partial last wave",
+			nodes:       nodes,
+			opts:        WorkerUpgradeOptions{MaxParallelWorkers: 4},
+			waveSizes:   []int{4, 4, 2},
+		},
+		{
+			description: "canary followed by max parallel workers",
+			nodes:       nodes,
+			opts:        WorkerUpgradeOptions{MaxParallelWorkers: 3, Canary: true},
+			waveSizes:   []int{1, 3, 3, 3},
+		},
+		{
+			description: "percentage of the nodes",
+			nodes:       nodes,
+			opts:        WorkerUpgradeOptions{MaxParallelWorkers: 1, WavePercentage: 25},
+			waveSizes:   []int{3, 3, 3, 1},
+		},
+		{
+			description: "canary followed by a percentage of the nodes",
+			nodes:       nodes,
+			opts:        WorkerUpgradeOptions{MaxParallelWorkers: 1, Canary: true, WavePercentage: 50},
+			waveSizes:   []int{1, 5, 4},
+		},
+		{
+			description: "small percentage is rounded up to one node",
+			nodes:       nodes[:3],
+			opts:        WorkerUpgradeOptions{WavePercentage: 1},
+			waveSizes:   []int{1, 1, 1},
+		},
+		{
+			description: "only the canary",
+			nodes:       nodes[:1],
+			opts:        WorkerUpgradeOptions{Canary: true, WavePercentage: 100},
+			waveSizes:   []int{1},
+		},
+	}
+	for _, test := range tests {
+		waves := workerUpgradeWaves(test.nodes, test.opts)
+		if len(waves) != len(test.waveSizes) {
+			t.Errorf("%s: expected %d waves, but got %d", test.description, len(test.waveSizes), len(waves))
+			continue
+		}
+		upgraded := []ListableNode{}
+		for i, w := range waves {
+			if len(w) != test.waveSizes[i] {
+				t.Errorf("%s: expected wave %d to have %d nodes, but got %d", test.description, i, test.waveSizes[i], len(w))
+			}
+			upgraded = append(upgraded, w...)
+		}
+		for i, n := range upgraded {
+			if n.Node.Host != test.nodes[i].Node.Host {
+				t.Errorf("%s: expected node %q to be upgraded in position %d, but got %q", test.description, test.nodes[i].Node.Host, i, n.Node.Host)
+			}
+		}
+	}
+}
+
+func TestUpgradeBatches(t *testing.T) {
+	nodes := []ListableNode{
+		{Node: Node{Host: "worker01"}, Roles: []string{"worker"}},
+		{Node: Node{Host: "master01"}, Roles: []string{"master"}},
+		{Node: Node{Host: "ingress01"}, Roles: []string{"ingress"}},
+		{Node: Node{Host: "etcd01"}, Roles: []string{"etcd", "master"}},
+		{Node: Node{Host: "worker02"}, Roles: []string{"worker"}},
+		{Node: Node{Host: "worker03"}, Roles: []string{"worker"}},
+	}
+	expected := []struct {
+		phase string
+		hosts []string
+	}{
+		{phase: "etcd", hosts: []string{"etcd01"}},
+		{phase: "master", hosts: []string{"master01"}},
+		{phase: "canary", hosts: []string{"worker01"}},
+		{phase: "worker", hosts: []string{"ingress01", "worker02"}},
+		{phase: "worker", hosts: []string{"worker03"}},
+	}
+	batches := UpgradeBatches(nodes, WorkerUpgradeOptions{MaxParallelWorkers: 2, Canary: true})
+	if len(batches) != len(expected) {
+		t.Fatalf("expected %d batches, but got %d", len(expected), len(batches))
+	}
+	for i, b := range batches {
+		if b.Phase != expected[i].phase {
+			t.Errorf("batch %d: expected phase %q, but got %q", i, expected[i].phase, b.Phase)
+		}
+		if len(b.Nodes) != len(expected[i].hosts) {
+			t.Errorf("batch %d: expected %d nodes, but got %d", i, len(expected[i].hosts), len(b.Nodes))
+			continue
+		}
+		for j, n := range b.Nodes {
+			if n.Node.Host != expected[i].hosts[j] {
+				t.Errorf("batch %d: expected node %q, but got %q", i, expected[i].hosts[j], n.Node.Host)
+			}
+		}
+	}
+}
+
+func TestPauseBeforeWave(t *testing.T) {
+	tests := []struct {
+		opts     WorkerUpgradeOptions
+		expected []bool
+	}{
+		{
+			opts:     WorkerUpgradeOptions{},
+			expected: []bool{false, false, false},
+		},
+		{
+			opts:     WorkerUpgradeOptions{WavePause: time.Minute},
+			expected: []bool{false, true, true},
+		},
+		{
+			opts:     WorkerUpgradeOptions{WavePause: time.Minute, Canary: true},
+			expected: []bool{false, false, true},
+		},
+	}
+	for _, test := range tests {
+		for wave, expected := range test.expected {
+			if pause := PauseBeforeWave(wave, test.opts); pause != expected {
+				t.Errorf("%+v: expected pause before wave %d to be %v, but got %v", test.opts, wave, expected, pause)
+			}
+		}
+	}
+}
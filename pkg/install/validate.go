@@ -13,7 +13,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/apprenda/kismatic/pkg/inspector/rule"
 	"github.com/apprenda/kismatic/pkg/validation"
+	yaml "gopkg.in/yaml.v2"
 
 	"github.com/apprenda/kismatic/pkg/ssh"
 	"github.com/apprenda/kismatic/pkg/util"
@@ -120,6 +122,10 @@ func (v *validator) validateWithErrPrefix(prefix string, objs ...validatable) {
 		if ok, err := obj.validate(); !ok {
 			newErrs := make([]error, len(err), len(err))
 			for i, err := range err {
+				if fe, ok := err.(*fieldError); ok {
+					newErrs[i] = &fieldError{field: fe.field, err: fmt.Errorf("%s: %v", prefix, fe.err)}
+					continue
+				}
 				newErrs[i] = fmt.Errorf("%s: %v", prefix, err)
 			}
 			v.addError(newErrs...)
@@ -134,24 +140,71 @@ func (v *validator) valid() (bool, []error) {
 	return true, nil
 }
 
+// fieldError is a validation error that relates to a field of the plan file.
+// The field is the path of the field in the file, such as "master.nodes[0].ip".
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (e *fieldError) Error() string {
+	return e.err.Error()
+}
+
+func newFieldError(field string, err error) error {
+	return &fieldError{field: field, err: err}
+}
+
+// inField returns a validatable that attributes the errors of obj to the given
+// field. Errors that already relate to a field are attributed to the field
+// nested in the given one.
+func inField(field string, obj validatable) validatable {
+	return fieldValidatable{field: field, obj: obj}
+}
+
+type fieldValidatable struct {
+	field string
+	obj   validatable
+}
+
+func (f fieldValidatable) validate() (bool, []error) {
+	ok, errs := f.obj.validate()
+	for i, err := range errs {
+		fe, isFieldErr := err.(*fieldError)
+		if !isFieldErr {
+			errs[i] = newFieldError(f.field, err)
+			continue
+		}
+		sep := "."
+		if strings.HasPrefix(fe.field, "[") {
+			sep = ""
+		}
+		errs[i] = newFieldError(f.field+sep+fe.field, fe.err)
+	}
+	return ok, errs
+}
+
 func (p *Plan) validate() (bool, []error) {
 	v := newValidator()
 
-	v.validate(&p.Cluster)
-	v.validate(&p.DockerRegistry)
+	v.validate(inField("cluster", &p.Cluster))
+	v.validate(inField("docker_registry", &p.DockerRegistry))
 	if p.Cluster.DisconnectedInstallation && !p.PrivateRegistryProvided() {
-		v.addError(fmt.Errorf("A container image registry is required when disconnected_installation is true"))
+		v.addError(newFieldError("cluster.disconnected_installation", fmt.Errorf("A container image registry is required when disconnected_installation is true")))
 	}
 
-	v.validateWithErrPrefix("Docker", p.Docker)
-	v.validate(&p.AddOns)
+	v.validateWithErrPrefix("Docker", inField("docker", p.Docker))
+	v.validate(inField("add_ons", &p.AddOns))
 	v.validate(nodeList{Nodes: p.getAllNodes()})
-	v.validateWithErrPrefix("Etcd nodes", &p.Etcd)
-	v.validateWithErrPrefix("Master nodes", &p.Master)
-	v.validateWithErrPrefix("Worker nodes", &p.Worker)
-	v.validateWithErrPrefix("Ingress nodes", &p.Ingress)
-	v.validate(&p.NFS)
-	v.validateWithErrPrefix("Storage nodes", &p.Storage)
+	v.validateWithErrPrefix("Etcd nodes", inField("etcd", &p.Etcd))
+	v.validateWithErrPrefix("Master nodes", inField("master", &p.Master))
+	v.validateWithErrPrefix("Worker nodes", inField("worker", &p.Worker))
+	v.validateWithErrPrefix("Ingress nodes", inField("ingress", &p.Ingress))
+	v.validate(inField("nfs", &p.NFS))
+	v.validateWithErrPrefix("Storage nodes", inField("storage", &p.Storage))
+	if p.Inspector != nil {
+		v.validate(inField("inspector", p.Inspector))
+	}
 
 	return v.valid()
 }
@@ -159,17 +212,17 @@ func (p *Plan) validate() (bool, []error) {
 func (c *Cluster) validate() (bool, []error) {
 	v := newValidator()
 	if c.Name == "" {
-		v.addError(errors.New("Cluster name cannot be empty"))
-	}
-	v.validate(&c.Networking)
-	v.validate(&c.Certificates)
-	v.validate(&c.SSH)
-	v.validate(&c.APIServerOptions)
-	v.validate(&c.KubeControllerManagerOptions)
-	v.validate(&c.KubeProxyOptions)
-	v.validate(&c.KubeSchedulerOptions)
-	v.validate(&c.KubeletOptions)
-	v.validate(&c.CloudProvider)
+		v.addError(newFieldError("name", errors.New("Cluster name cannot be empty")))
+	}
+	v.validate(inField("networking", &c.Networking))
+	v.validate(inField("certificates", &c.Certificates))
+	v.validate(inField("ssh", &c.SSH))
+	v.validate(inField("kube_apiserver.option_overrides", &c.APIServerOptions))
+	v.validate(inField("kube_controller_manager.option_overrides", &c.KubeControllerManagerOptions))
+	v.validate(inField("kube_proxy.option_overrides", &c.KubeProxyOptions))
+	v.validate(inField("kube_scheduler.option_overrides", &c.KubeSchedulerOptions))
+	v.validate(inField("kubelet.option_overrides", &c.KubeletOptions))
+	v.validate(inField("cloud_provider", &c.CloudProvider))
 
 	return v.valid()
 }
@@ -177,17 +230,17 @@ func (c *Cluster) validate() (bool, []error) {
 func (n *NetworkConfig) validate() (bool, []error) {
 	v := newValidator()
 	if n.PodCIDRBlock == "" {
-		v.addError(errors.New("Pod CIDR block cannot be empty"))
+		v.addError(newFieldError("pod_cidr_block", errors.New("Pod CIDR block cannot be empty")))
 	}
 	if _, _, err := net.ParseCIDR(n.PodCIDRBlock); n.PodCIDRBlock != "" && err != nil {
-		v.addError(fmt.Errorf("Invalid Pod CIDR block provided: %v", err))
+		v.addError(newFieldError("pod_cidr_block", fmt.Errorf("Invalid Pod CIDR block provided: %v", err)))
 	}
 
 	if n.ServiceCIDRBlock == "" {
-		v.addError(errors.New("Service CIDR block cannot be empty"))
+		v.addError(newFieldError("service_cidr_block", errors.New("Service CIDR block cannot be empty")))
 	}
 	if _, _, err := net.ParseCIDR(n.ServiceCIDRBlock); n.ServiceCIDRBlock != "" && err != nil {
-		v.addError(fmt.Errorf("Invalid Service CIDR block provided: %v", err))
+		v.addError(newFieldError("service_cidr_block", fmt.Errorf("Invalid Service CIDR block provided: %v", err)))
 	}
 	return v.valid()
 }
@@ -195,10 +248,10 @@ func (n *NetworkConfig) validate() (bool, []error) {
 func (c *CertsConfig) validate() (bool, []error) {
 	v := newValidator()
 	if _, err := time.ParseDuration(c.Expiry); err != nil {
-		v.addError(fmt.Errorf("Invalid certificate expiry %q provided: %v", c.Expiry, err))
+		v.addError(newFieldError("expiry", fmt.Errorf("Invalid certificate expiry %q provided: %v", c.Expiry, err)))
 	}
 	if _, err := time.ParseDuration(c.CAExpiry); c.CAExpiry != "" && err != nil { // don't error when empty for backwards compat
-		v.addError(fmt.Errorf("Invalid CA certificate expiry %q provider: %v", c.CAExpiry, err))
+		v.addError(newFieldError("ca_expiry", fmt.Errorf("Invalid CA certificate expiry %q provider: %v", c.CAExpiry, err)))
 	}
 	return v.valid()
 }
@@ -206,19 +259,19 @@ func (c *CertsConfig) validate() (bool, []error) {
 func (s *SSHConfig) validate() (bool, []error) {
 	v := newValidator()
 	if s.User == "" {
-		v.addError(errors.New("SSH user field is required"))
+		v.addError(newFieldError("user", errors.New("SSH user field is required")))
 	}
 	if s.Key == "" {
-		v.addError(errors.New("SSH key field is required"))
+		v.addError(newFieldError("ssh_key", errors.New("SSH key field is required")))
 	}
 	if _, err := os.Stat(s.Key); os.IsNotExist(err) {
-		v.addError(fmt.Errorf("SSH Key file was not found at %q", s.Key))
+		v.addError(newFieldError("ssh_key", fmt.Errorf("SSH Key file was not found at %q", s.Key)))
 	}
 	if !filepath.IsAbs(s.Key) {
-		v.addError(errors.New("SSH Key field must be an absolute path"))
+		v.addError(newFieldError("ssh_key", errors.New("SSH Key field must be an absolute path")))
 	}
 	if s.Port < 1 || s.Port > 65535 {
-		v.addError(fmt.Errorf("SSH port %d is invalid. Port must be in the range 1-65535", s.Port))
+		v.addError(newFieldError("ssh_port", fmt.Errorf("SSH port %d is invalid. Port must be in the range 1-65535", s.Port)))
 	}
 	return v.valid()
 }
@@ -227,11 +280,11 @@ func (c *CloudProvider) validate() (bool, []error) {
 	v := newValidator()
 	if c.Provider != "" {
 		if !util.Contains(c.Provider, cloudProviders()) {
-			v.addError(fmt.Errorf("%q is not a valid cloud provider. Options are %v", c.Provider, cloudProviders()))
+			v.addError(newFieldError("provider", fmt.Errorf("%q is not a valid cloud provider. Options are %v", c.Provider, cloudProviders())))
 		}
 		if c.Config != "" {
 			if _, err := os.Stat(c.Config); os.IsNotExist(err) {
-				v.addError(fmt.Errorf("cloud config file was not found at %q", c.Config))
+				v.addError(newFieldError("config", fmt.Errorf("cloud config file was not found at %q", c.Config)))
 			}
 		}
 	}
@@ -240,9 +293,9 @@ func (c *CloudProvider) validate() (bool, []error) {
 
 func (f *AddOns) validate() (bool, []error) {
 	v := newValidator()
-	v.validate(f.CNI)
-	v.validate(f.HeapsterMonitoring)
-	v.validate(&f.PackageManager)
+	v.validate(inField("cni", f.CNI))
+	v.validate(inField("heapster", f.HeapsterMonitoring))
+	v.validate(inField("package_manager", &f.PackageManager))
 	return v.valid()
 }
 
@@ -250,14 +303,14 @@ func (n *CNI) validate() (bool, []error) {
 	v := newValidator()
 	if n != nil && !n.Disable {
 		if !util.Contains(n.Provider, cniProviders()) {
-			v.addError(fmt.Errorf("%q is not a valid CNI provider. Options are %v", n.Provider, cniProviders()))
+			v.addError(newFieldError("provider", fmt.Errorf("%q is not a valid CNI provider. Options are %v", n.Provider, cniProviders())))
 		}
 		if n.Provider == "calico" {
 			if !util.Contains(n.Options.Calico.Mode, calicoMode()) {
-				v.addError(fmt.Errorf("%q is not a valid Calico mode. Options are %v", n.Options.Calico.Mode, calicoMode()))
+				v.addError(newFieldError("options.calico.mode", fmt.Errorf("%q is not a valid Calico mode. Options are %v", n.Options.Calico.Mode, calicoMode())))
 			}
 			if !util.Contains(n.Options.Calico.LogLevel, calicoLogLevel()) {
-				v.addError(fmt.Errorf("%q is not a valid Calico log level. Options are %v", n.Options.Calico.LogLevel, calicoLogLevel()))
+				v.addError(newFieldError("options.calico.log_level", fmt.Errorf("%q is not a valid Calico log level. Options are %v", n.Options.Calico.LogLevel, calicoLogLevel())))
 			}
 		}
 	}
@@ -268,10 +321,10 @@ func (h *HeapsterMonitoring) validate() (bool, []error) {
 	v := newValidator()
 	if h != nil && !h.Disable {
 		if h.Options.Heapster.Replicas <= 0 {
-			v.addError(fmt.Errorf("Heapster replicas %d is not valid, must be greater than 0", h.Options.HeapsterReplicas))
+			v.addError(newFieldError("options.heapster.replicas", fmt.Errorf("Heapster replicas %d is not valid, must be greater than 0", h.Options.HeapsterReplicas)))
 		}
 		if !util.Contains(h.Options.Heapster.ServiceType, serviceTypes()) {
-			v.addError(fmt.Errorf("Heapster Service Type %q is not a valid option %v", h.Options.Heapster.ServiceType, serviceTypes()))
+			v.addError(newFieldError("options.heapster.service_type", fmt.Errorf("Heapster Service Type %q is not a valid option %v", h.Options.Heapster.ServiceType, serviceTypes())))
 		}
 	}
 	return v.valid()
@@ -281,7 +334,7 @@ func (p *PackageManager) validate() (bool, []error) {
 	v := newValidator()
 	if !p.Disable {
 		if !util.Contains(p.Provider, packageManagerProviders()) {
-			v.addError(fmt.Errorf("Package Manager %q is not a valid option %v", p.Provider, packageManagerProviders()))
+			v.addError(newFieldError("provider", fmt.Errorf("Package Manager %q is not a valid option %v", p.Provider, packageManagerProviders())))
 		}
 	}
 	return v.valid()
@@ -387,13 +440,13 @@ func (ng *NodeGroup) validate() (bool, []error) {
 		v.addError(fmt.Errorf("At least one node is required"))
 	}
 	if ng.ExpectedCount <= 0 {
-		v.addError(fmt.Errorf("Node count must be greater than 0"))
+		v.addError(newFieldError("expected_count", fmt.Errorf("Node count must be greater than 0")))
 	}
 	if len(ng.Nodes) != ng.ExpectedCount && (len(ng.Nodes) > 0 && ng.ExpectedCount > 0) {
-		v.addError(fmt.Errorf("Expected node count (%d) does not match the number of nodes provided (%d)", ng.ExpectedCount, len(ng.Nodes)))
+		v.addError(newFieldError("expected_count", fmt.Errorf("Expected node count (%d) does not match the number of nodes provided (%d)", ng.ExpectedCount, len(ng.Nodes))))
 	}
 	for i, n := range ng.Nodes {
-		v.validateWithErrPrefix(fmt.Sprintf("Node #%d", i+1), &n)
+		v.validateWithErrPrefix(fmt.Sprintf("Node #%d", i+1), inField(fmt.Sprintf("nodes[%d]", i), &n))
 	}
 
 	return v.valid()
@@ -412,7 +465,7 @@ func (ong *OptionalNodeGroup) validate() (bool, []error) {
 		return true, nil
 	}
 	if len(ong.Nodes) != ong.ExpectedCount {
-		return false, []error{fmt.Errorf("Expected node count (%d) does not match the number of nodes provided (%d)", ong.ExpectedCount, len(ong.Nodes))}
+		return false, []error{newFieldError("expected_count", fmt.Errorf("Expected node count (%d) does not match the number of nodes provided (%d)", ong.ExpectedCount, len(ong.Nodes)))}
 	}
 	ng := NodeGroup(*ong)
 	return ng.validate()
@@ -425,21 +478,21 @@ func (mng *MasterNodeGroup) validate() (bool, []error) {
 		v.addError(fmt.Errorf("At least one node is required"))
 	}
 	if mng.ExpectedCount <= 0 {
-		v.addError(fmt.Errorf("Node count must be greater than 0"))
+		v.addError(newFieldError("expected_count", fmt.Errorf("Node count must be greater than 0")))
 	}
 	if len(mng.Nodes) != mng.ExpectedCount && (len(mng.Nodes) > 0 && mng.ExpectedCount > 0) {
-		v.addError(fmt.Errorf("Expected node count (%d) does not match the number of nodes provided (%d)", mng.ExpectedCount, len(mng.Nodes)))
+		v.addError(newFieldError("expected_count", fmt.Errorf("Expected node count (%d) does not match the number of nodes provided (%d)", mng.ExpectedCount, len(mng.Nodes))))
 	}
 	for i, n := range mng.Nodes {
-		v.validateWithErrPrefix(fmt.Sprintf("Node #%d", i+1), &n)
+		v.validateWithErrPrefix(fmt.Sprintf("Node #%d", i+1), inField(fmt.Sprintf("nodes[%d]", i), &n))
 	}
 
 	if mng.LoadBalancedFQDN == "" {
-		v.addError(fmt.Errorf("Load balanced FQDN is required"))
+		v.addError(newFieldError("load_balanced_fqdn", fmt.Errorf("Load balanced FQDN is required")))
 	}
 
 	if mng.LoadBalancedShortName == "" {
-		v.addError(fmt.Errorf("Load balanced shortname is required"))
+		v.addError(newFieldError("load_balanced_short_name", fmt.Errorf("Load balanced shortname is required")))
 	}
 
 	return v.valid()
@@ -448,29 +501,29 @@ func (mng *MasterNodeGroup) validate() (bool, []error) {
 func (n *Node) validate() (bool, []error) {
 	v := newValidator()
 	if n.Host == "" {
-		v.addError(fmt.Errorf("Node host field is required"))
+		v.addError(newFieldError("host", fmt.Errorf("Node host field is required")))
 	}
 	if n.IP == "" {
-		v.addError(fmt.Errorf("Node IP field is required"))
+		v.addError(newFieldError("ip", fmt.Errorf("Node IP field is required")))
 	}
 	if ip := net.ParseIP(n.IP); ip == nil && n.IP != "" {
-		v.addError(fmt.Errorf("Invalid IP provided"))
+		v.addError(newFieldError("ip", fmt.Errorf("Invalid IP provided")))
 	}
 	if ip := net.ParseIP(n.InternalIP); n.InternalIP != "" && ip == nil {
-		v.addError(fmt.Errorf("Invalid InternalIP provided"))
+		v.addError(newFieldError("internalip", fmt.Errorf("Invalid InternalIP provided")))
 	}
 	// validate node labels don't start with 'kismatic/' as that is reserved
 	for key, val := range n.Labels {
 		if strings.HasPrefix(key, "kismatic/") {
-			v.addError(fmt.Errorf("Node label %q cannot start with 'kismatic/'", key))
+			v.addError(newFieldError("labels."+key, fmt.Errorf("Node label %q cannot start with 'kismatic/'", key)))
 		}
 		errs := validation.IsQualifiedName(key)
 		for _, err := range errs {
-			v.addError(fmt.Errorf("Node label name %q is not valid %s", key, err))
+			v.addError(newFieldError("labels."+key, fmt.Errorf("Node label name %q is not valid %s", key, err)))
 		}
 		errs = validation.IsValidLabelValue(val)
 		for _, err := range errs {
-			v.addError(fmt.Errorf("Node label %q is not valid %s", val, err))
+			v.addError(newFieldError("labels."+key, fmt.Errorf("Node label %q is not valid %s", val, err)))
 		}
 	}
 	return v.valid()
@@ -485,26 +538,26 @@ func (dr *DockerRegistry) validate() (bool, []error) {
 		v.addError(fmt.Errorf("Docker Registry server cannot be empty when a username is provided"))
 	}
 	if _, err := os.Stat(dr.CAPath); dr.CAPath != "" && os.IsNotExist(err) {
-		v.addError(fmt.Errorf("Docker Registry CA file was not found at %q", dr.CAPath))
+		v.addError(newFieldError("CA", fmt.Errorf("Docker Registry CA file was not found at %q", dr.CAPath)))
 	}
 	if dr.Username != "" && dr.Password == "" {
-		v.addError(fmt.Errorf("Docker Registry password cannot be blank for username %q", dr.Username))
+		v.addError(newFieldError("password", fmt.Errorf("Docker Registry password cannot be blank for username %q", dr.Username)))
 	}
 	if dr.Password != "" && dr.Username == "" {
-		v.addError(fmt.Errorf("Docker Registry username cannot be blank when a password is provided"))
+		v.addError(newFieldError("username", fmt.Errorf("Docker Registry username cannot be blank when a password is provided")))
 	}
 	return v.valid()
 }
 
 func (d Docker) validate() (bool, []error) {
 	v := newValidator()
-	v.validateWithErrPrefix("Storage", d.Storage)
+	v.validateWithErrPrefix("Storage", inField("storage", d.Storage))
 	return v.valid()
 }
 
 func (ds DockerStorage) validate() (bool, []error) {
 	v := newValidator()
-	v.validateWithErrPrefix("Direct LVM", ds.DirectLVM)
+	v.validateWithErrPrefix("Direct LVM", inField("direct_lvm", ds.DirectLVM))
 	return v.valid()
 }
 
@@ -512,10 +565,10 @@ func (dlvm DockerStorageDirectLVM) validate() (bool, []error) {
 	v := newValidator()
 	if dlvm.Enabled {
 		if dlvm.BlockDevice == "" {
-			v.addError(errors.New("DirectLVM is enabled, but no block device was specified"))
+			v.addError(newFieldError("block_device", errors.New("DirectLVM is enabled, but no block device was specified")))
 		}
 		if !filepath.IsAbs(dlvm.BlockDevice) {
-			v.addError(errors.New("Path to the block device must be absolute"))
+			v.addError(newFieldError("block_device", errors.New("Path to the block device must be absolute")))
 		}
 	}
 	return v.valid()
@@ -524,10 +577,11 @@ func (dlvm DockerStorageDirectLVM) validate() (bool, []error) {
 func (nfs *NFS) validate() (bool, []error) {
 	v := newValidator()
 	uniqueVolumes := make(map[NFSVolume]bool)
-	for _, vol := range nfs.Volumes {
-		v.validate(vol)
+	for i, vol := range nfs.Volumes {
+		field := fmt.Sprintf("nfs_volume[%d]", i)
+		v.validate(inField(field, vol))
 		if _, ok := uniqueVolumes[vol]; ok {
-			v.addError(fmt.Errorf("Duplicate NFS volume %v", vol))
+			v.addError(newFieldError(field, fmt.Errorf("Duplicate NFS volume %v", vol)))
 		} else {
 			uniqueVolumes[vol] = true
 		}
@@ -538,13 +592,60 @@ func (nfs *NFS) validate() (bool, []error) {
 func (nfsVol NFSVolume) validate() (bool, []error) {
 	v := newValidator()
 	if nfsVol.Host == "" {
-		v.addError(errors.New("NFS volume host cannot be empty"))
+		v.addError(newFieldError("nfs_host", errors.New("NFS volume host cannot be empty")))
 	}
 	if nfsVol.Path == "" {
-		v.addError(errors.New("NFS volume path cannot be empty"))
+		v.addError(newFieldError("mount_path", errors.New("NFS volume path cannot be empty")))
 	}
 	if len(nfsVol.Path) > 0 && nfsVol.Path[0] != '/' {
-		v.addError(errors.New("NFS volume path must be absolute"))
+		v.addError(newFieldError("mount_path", errors.New("NFS volume path must be absolute")))
+	}
+	return v.valid()
+}
+
+func (i *Inspector) validate() (bool, []error) {
+	v := newValidator()
+	for n, f := range i.rulesFilePaths() {
+		field := fmt.Sprintf("rules_files[%d]", n)
+		if _, err := rule.ReadFromFile(f); err != nil {
+			invalidErr, ok := err.(*rule.InvalidRulesError)
+			if !ok {
+				v.addError(newFieldError(field, err))
+				continue
+			}
+			for _, e := range invalidErr.Errs {
+				v.addError(newFieldError(field, e))
+			}
+		}
+	}
+	if len(i.Rules) == 0 {
+		return v.valid()
+	}
+	d, err := yaml.Marshal(i.Rules)
+	if err != nil {
+		v.addError(newFieldError("rules", fmt.Errorf("Invalid inspector rules: %v", err)))
+		return v.valid()
+	}
+	if _, err := rule.ParseRulesYAML(d); err != nil {
+		invalidErr, ok := err.(*rule.InvalidRulesError)
+		if !ok {
+			v.addError(newFieldError("rules", err))
+			return v.valid()
+		}
+		for _, e := range invalidErr.Errs {
+			// Attribute the error to the rule it was found in
+			re, ok := e.(*rule.RuleError)
+			if !ok {
+				v.addError(newFieldError("rules", e))
+				continue
+			}
+			field := fmt.Sprintf("rules[%d]", re.Index-1)
+			if re.Kind == "" {
+				v.addError(newFieldError(field, re.Err))
+				continue
+			}
+			v.addError(newFieldError(field, fmt.Errorf("%s rule: %v", re.Kind, re.Err)))
+		}
 	}
 	return v.valid()
 }
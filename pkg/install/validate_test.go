@@ -633,6 +633,64 @@ func TestValidateNFSVolume(t *testing.T) {
 	}
 }
 
+func TestValidatePlanInspectorRules(t *testing.T) {
+	tests := []struct {
+		inspector Inspector
+		valid     bool
+		field     string
+	}{
+		{
+			inspector: Inspector{
+				Rules: []InspectorRule{
+					{"kind": "FileContentMatches", "file": "/etc/ssh/sshd_config", "contentRegex": "PermitRootLogin no"},
+				},
+			},
+			valid: true,
+		},
+		{
+			inspector: Inspector{
+				Rules: []InspectorRule{
+					{"kind": "FileContentMatches", "file": "/etc/ssh/sshd_config", "contentRegex": "PermitRootLogin no"},
+					{"kind": "FreeSpace", "path": "/var", "minimumBytes": "lots"},
+				},
+			},
+			valid: false,
+			field: "inspector.rules[1]",
+		},
+		{
+			inspector: Inspector{
+				Rules: []InspectorRule{
+					{"kind": "NotARule"},
+				},
+			},
+			valid: false,
+			field: "inspector.rules[0]",
+		},
+		{
+			inspector: Inspector{
+				RulesFiles: []string{"/path/to/nonexistent/rules.yaml"},
+			},
+			valid: false,
+			field: "inspector.rules_files[0]",
+		},
+	}
+	for i, test := range tests {
+		p := validPlan
+		p.Inspector = &test.inspector
+		valid, errs := ValidatePlan(&p)
+		if valid != test.valid {
+			t.Errorf("test #%d: expected valid = %v, but got %v: %v", i, test.valid, valid, errs)
+			continue
+		}
+		for _, err := range errs {
+			fe, ok := err.(*fieldError)
+			if !ok || fe.field != test.field {
+				t.Errorf("test #%d: expected error in field %q, but got %v", i, test.field, err)
+			}
+		}
+	}
+}
+
 func TestValidatePlanCerts(t *testing.T) {
 	p := validPlan
 
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"syscall"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -27,6 +28,8 @@ var baseSSHArgs = []string{
 type Client interface {
 	Output(pty bool, args ...string) (string, error)
 	Shell(pty bool, args ...string) error
+	// Close releases the client, which must not be used afterwards
+	Close() error
 }
 
 type ExternalClient struct {
@@ -97,6 +100,23 @@ func (client *ExternalClient) Shell(pty bool, args ...string) error {
 	return cmd.Run()
 }
 
+// IsConnectionError returns true if the command failed because ssh could not
+// connect to the host, in which case ssh exits with 255
+func IsConnectionError(err error) bool {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && status.ExitStatus() == 255
+}
+
+// Close releases the client. Each command runs in its own ssh process,
+// which exits with the command, so there are no connections left to close.
+func (client *ExternalClient) Close() error {
+	return nil
+}
+
 func getSSHCmd(binaryPath string, pty bool, args ...string) *exec.Cmd {
 	if pty {
 		args = append([]string{"-t"}, args...)
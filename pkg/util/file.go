@@ -2,7 +2,10 @@ package util
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 // BackupDirectory checks for existence of the $sourceDir and backs it up to backupDir
@@ -21,3 +24,51 @@ func BackupDirectory(sourceDir string, backupDir string) (bool, error) {
 	// Directory does not already exist, nothing to do
 	return backedup, nil
 }
+
+// CopyFile copies the contents of the src file into dst, creating or truncating dst
+func CopyFile(src string, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("Could not open %q: %v", src, err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("Could not create %q: %v", dst, err)
+	}
+	defer out.Close()
+	if _, err = io.Copy(out, in); err != nil {
+		return fmt.Errorf("Could not copy %q to %q: %v", src, dst, err)
+	}
+	return out.Sync()
+}
+
+// WriteFileAtomic writes data to a temporary file in the same directory as
+// filename, and renames it to filename. Readers either see the previous
+// contents of the file, or the new contents, but never a partial write.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return fmt.Errorf("Could not create temporary file for %q: %v", filename, err)
+	}
+	// the temporary file is gone once renamed
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("Could not write %q: %v", f.Name(), err)
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("Could not sync %q: %v", f.Name(), err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("Could not close %q: %v", f.Name(), err)
+	}
+	if err = os.Chmod(f.Name(), perm); err != nil {
+		return fmt.Errorf("Could not set permissions of %q: %v", f.Name(), err)
+	}
+	if err = os.Rename(f.Name(), filename); err != nil {
+		return fmt.Errorf("Could not rename %q to %q: %v", f.Name(), filename, err)
+	}
+	return nil
+}
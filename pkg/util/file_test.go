@@ -40,3 +40,58 @@ func TestBackupClientDirectoryNotExists(t *testing.T) {
 		t.Errorf("Expected directory to not exist")
 	}
 }
+
+func TestCopyFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "ket-copyfile-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	src := filepath.Join(tmpDir, "src")
+	if err = ioutil.WriteFile(src, []byte("some contents"), 0644); err != nil {
+		t.Fatalf("error writing source file: %v", err)
+	}
+	dst := filepath.Join(tmpDir, "dst")
+	if err = CopyFile(src, dst, 0600); err != nil {
+		t.Errorf("Expected error to be nil, got: %v", err)
+	}
+	b, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("error reading destination file: %v", err)
+	}
+	if string(b) != "some contents" {
+		t.Errorf("Expected destination to contain %q, got %q", "some contents", string(b))
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "ket-writefileatomic-test")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	file := filepath.Join(tmpDir, "file")
+	if err = ioutil.WriteFile(file, []byte("old contents"), 0644); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	if err = WriteFileAtomic(file, []byte("new contents"), 0600); err != nil {
+		t.Errorf("Expected error to be nil, got: %v", err)
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	if string(b) != "new contents" {
+		t.Errorf("Expected file to contain %q, got %q", "new contents", string(b))
+	}
+	files, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("error reading temp dir: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Expected temporary file to be removed, but found %d files", len(files))
+	}
+	if files[0].Mode().Perm() != 0600 {
+		t.Errorf("Expected file permissions to be %v, got %v", os.FileMode(0600), files[0].Mode().Perm())
+	}
+}